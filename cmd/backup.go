@@ -0,0 +1,104 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"drexel.edu/voter-api/pkg/storage/json"
+	"drexel.edu/voter-api/pkg/storage/signature"
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultBackupDir  = "./backups"
+	defaultKeepDaily  = 7
+	defaultKeepWeekly = 4
+)
+
+var backupFilePath string
+var backupDir string
+var keepDaily int
+var keepWeekly int
+var signingKeyPath string
+
+// backupCmd represents the backup command
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Writes a timestamped snapshot of the database",
+	Long: `Writes a timestamped and checksummed snapshot of the Json DB to the
+	backup directory, then removes snapshots which fall outside of the
+	retention policy`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		signingKey, err := loadSigningKey(signingKeyPath)
+		if err != nil {
+			return err
+		}
+
+		snapshot, err := json.BackupFile(backupFilePath, backupDir)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("wrote %s (sha256 %s)\n", snapshot.Path, snapshot.Checksum)
+
+		if err := signSnapshot(snapshot, signingKey); err != nil {
+			return err
+		}
+
+		return pruneSnapshots(backupDir)
+	},
+}
+
+// loadSigningKey reads the key snapshots are signed with, nil if no key was
+// given.
+func loadSigningKey(fileName string) (ed25519.PrivateKey, error) {
+	if fileName == "" {
+		return nil, nil
+	}
+
+	return signature.LoadPrivateKey(fileName)
+}
+
+// signSnapshot writes a detached signature next to the snapshot, if there is
+// a key to sign it with.
+func signSnapshot(snapshot json.Snapshot, key ed25519.PrivateKey) error {
+	if key == nil {
+		return nil
+	}
+
+	sigName, err := signature.SignFile(snapshot.Path, key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote %s (key %s)\n", sigName, signature.Fingerprint(key.Public().(ed25519.PublicKey)))
+
+	return nil
+}
+
+func pruneSnapshots(dir string) error {
+	removed, err := json.PruneSnapshots(dir, keepDaily, keepWeekly)
+	if err != nil {
+		return err
+	}
+
+	for _, snapshot := range removed {
+		fmt.Printf("removed %s\n", snapshot.Path)
+	}
+
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+
+	backupCmd.Flags().StringVarP(&backupFilePath, "filePath", "f", defaultFilePath, "The file path to the Json DB")
+	backupCmd.Flags().StringVarP(&backupDir, "dir", "d", defaultBackupDir, "The directory snapshots are written to")
+	backupCmd.Flags().IntVar(&keepDaily, "keepDaily", defaultKeepDaily, "The number of daily snapshots to keep")
+	backupCmd.Flags().IntVar(&keepWeekly, "keepWeekly", defaultKeepWeekly, "The number of weekly snapshots to keep")
+	backupCmd.Flags().StringVar(&signingKeyPath, "signingKey", "", "A private key from \"voter-api keys generate\" to sign the snapshot with")
+}
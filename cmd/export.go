@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"drexel.edu/voter-api/pkg/storage/json"
+	"drexel.edu/voter-api/pkg/storage/signature"
+	"github.com/spf13/cobra"
+)
+
+const defaultExportFilePath = "./voters-export.json"
+
+var exportFilePath string
+var exportOutputPath string
+var exportKeyPath string
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Writes a signed voter roll",
+	Long: `Writes the voters and history that are not deleted to a Json file and
+	signs it with an Ed25519 key from "keys generate". the roll is always
+	signed, so whoever receives it can check it with verify-signature. the
+	Json DB is only read, so it can be exported while the server is running`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// the key is read first so no unsigned roll is left behind
+		key, err := signature.LoadPrivateKey(exportKeyPath)
+		if err != nil {
+			return err
+		}
+
+		count, err := json.ExportFile(exportFilePath, exportOutputPath)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("wrote %s (%d voters)\n", exportOutputPath, count)
+
+		sigName, err := signature.SignFile(exportOutputPath, key)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("wrote %s (key %s)\n", sigName, signature.Fingerprint(key.Public().(ed25519.PublicKey)))
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().StringVarP(&exportFilePath, "filePath", "f", defaultFilePath, "The file path to the Json DB")
+	exportCmd.Flags().StringVarP(&exportOutputPath, "output", "o", defaultExportFilePath, "The file path the voter roll is written to")
+	exportCmd.Flags().StringVar(&exportKeyPath, "signingKey", defaultKeyPath+signature.PrivateKeySuffix, "A private key from \"voter-api keys generate\" to sign the voter roll with")
+}
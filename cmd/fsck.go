@@ -0,0 +1,72 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"drexel.edu/voter-api/pkg/storage/json"
+	"github.com/spf13/cobra"
+)
+
+var fsckFilePath string
+var fsckFix bool
+var fsckOutput string
+
+// fsckCmd represents the fsck command
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "Checks the database for inconsistencies",
+	Long: `Scans the Json DB and reports duplicate voters, history records which
+	disagree with their poll id, missing or out of order timestamps and
+	invalid emails. with --fix a repaired copy is written, the original
+	file is never modified`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		problems, voterList, pollList, ballotList, err := json.CheckFile(fsckFilePath)
+		if err != nil {
+			return err
+		}
+
+		for _, problem := range problems {
+			fmt.Println(problem)
+		}
+
+		if len(problems) == 0 {
+			fmt.Printf("%s: no problems found in %d voters\n", fsckFilePath, len(voterList))
+			return nil
+		}
+
+		if fsckFix && voterList != nil {
+			output := fsckOutput
+			if output == "" {
+				output = fsckFilePath + ".fixed"
+			}
+
+			repaired := json.RepairVoters(voterList, time.Now())
+
+			if err := json.WriteRepaired(output, repaired, pollList, ballotList); err != nil {
+				return err
+			}
+
+			remaining := json.CheckVoters(repaired)
+
+			fmt.Printf("wrote repaired copy to %s, %d problems remain\n", output, len(remaining))
+
+			return nil
+		}
+
+		return fmt.Errorf("%d problems found in %s", len(problems), fsckFilePath)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fsckCmd)
+
+	fsckCmd.Flags().StringVarP(&fsckFilePath, "filePath", "f", defaultFilePath, "The file path to the Json DB")
+	fsckCmd.Flags().BoolVar(&fsckFix, "fix", false, "write a repaired copy of the Json DB")
+	fsckCmd.Flags().StringVarP(&fsckOutput, "output", "o", "", "The file path of the repaired copy (default <filePath>.fixed)")
+}
@@ -0,0 +1,53 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"drexel.edu/voter-api/pkg/storage/signature"
+	"github.com/spf13/cobra"
+)
+
+const defaultKeyPath = "./voter-api"
+
+var keyPath string
+var overwriteKeys bool
+
+// keysCmd represents the keys command
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manages the keys snapshots are signed with",
+}
+
+// keysGenerateCmd represents the keys generate command
+var keysGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generates an Ed25519 key pair for signing snapshots",
+	Long: `Writes a new Ed25519 private key to <output>.key, readable only by its
+	owner, and the public key to <output>.pub. pass the private key to backup
+	or start with --signingKey and hand the public key to anyone who needs to
+	check the snapshots with verify-signature`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		public, err := signature.GenerateKeys(keyPath, overwriteKeys)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("wrote %s and %s\n", keyPath+signature.PrivateKeySuffix, keyPath+signature.PublicKeySuffix)
+		fmt.Printf("key %s\n", signature.Fingerprint(public))
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+	keysCmd.AddCommand(keysGenerateCmd)
+
+	keysGenerateCmd.Flags().StringVarP(&keyPath, "output", "o", defaultKeyPath, "The path of the key pair, without the .key and .pub suffixes")
+	keysGenerateCmd.Flags().BoolVar(&overwriteKeys, "force", false, "replace an existing key pair")
+}
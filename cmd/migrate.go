@@ -0,0 +1,58 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"drexel.edu/voter-api/pkg/storage/json"
+	"github.com/spf13/cobra"
+)
+
+var migrateFilePath string
+var migrateBackupPath string
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrades the Json DB to the current file format",
+	Long: `Upgrades the Json DB in place to the current file format.
+	the original file is copied to a backup first. if no backup path
+	is provided then <file>.v<old version>.bak is used`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		backupPath := migrateBackupPath
+
+		info, err := json.ReadFormat(migrateFilePath)
+		if err != nil {
+			return err
+		}
+
+		if info.SchemaVersion == json.CurrentSchemaVersion {
+			fmt.Printf("%s is already at schema version %d\n", migrateFilePath, info.SchemaVersion)
+			return nil
+		}
+
+		if backupPath == "" {
+			backupPath = fmt.Sprintf("%s.v%d.bak", migrateFilePath, info.SchemaVersion)
+		}
+
+		info, err = json.MigrateDB(migrateFilePath, backupPath)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("migrated %s from schema version %d to %d (%d voters), backup written to %s\n",
+			migrateFilePath, info.SchemaVersion, json.CurrentSchemaVersion, info.RecordCount, backupPath)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().StringVarP(&migrateFilePath, "filePath", "f", defaultFilePath, "The file path to the Json DB")
+	migrateCmd.Flags().StringVarP(&migrateBackupPath, "backup", "b", "", "The file path to write the original Json DB to")
+}
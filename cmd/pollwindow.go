@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	encoding "encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+)
+
+var pollWindowsFilePath string
+
+// pollWindow is one entry of the --pollWindows file, for example
+//
+//	[{"poll_id": 1, "opens_at": "2024-11-05T06:00:00Z", "closes_at": "2024-11-05T20:00:00Z"}]
+//
+// Either time may be left out to leave that end of the window open.
+type pollWindow struct {
+	PollId   int    `json:"poll_id"`
+	OpensAt  string `json:"opens_at"`
+	ClosesAt string `json:"closes_at"`
+}
+
+// applyPollWindows sets the window of every poll listed in fileName. The
+// polls must already exist.
+func applyPollWindows(fileName string, pollService process.PollService) error {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	var windows []pollWindow
+
+	if err := encoding.Unmarshal(data, &windows); err != nil {
+		return fmt.Errorf("%s: %v", fileName, err)
+	}
+
+	for _, window := range windows {
+		opensAt, err := parseWindowTime(window.OpensAt)
+		if err != nil {
+			return fmt.Errorf("%s: poll %d: %v", fileName, window.PollId, err)
+		}
+
+		closesAt, err := parseWindowTime(window.ClosesAt)
+		if err != nil {
+			return fmt.Errorf("%s: poll %d: %v", fileName, window.PollId, err)
+		}
+
+		if err := pollService.SetPollWindow(window.PollId, opensAt, closesAt); err != nil {
+			return fmt.Errorf("%s: poll %d: %v", fileName, window.PollId, err)
+		}
+	}
+
+	return nil
+}
+
+func parseWindowTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"fmt"
+	"path/filepath"
 
 	"drexel.edu/voter-api/pkg/storage/json"
 	"github.com/spf13/cobra"
@@ -19,25 +20,55 @@ var backupRoute string
 
 var targetFilePath string
 
+var listSnapshots bool
+
+var snapshotName string
+
+var restoreBackupDir string
+
 // restoreCmd represents the restore command
 var restoreCmd = &cobra.Command{
 	Use:   "restore",
 	Short: "Restores the database to a backup file",
-	Long: `Restores the database to a specified backup file. 
-	if no file is provided then a default with sample data is 
-	used`,
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println("restore called")
+	Long: `Restores the database to a specified backup file or to a snapshot
+	written by the backup command. if neither is provided then a default
+	with sample data is used`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		if listSnapshots {
+			snapshots, err := json.ListSnapshots(restoreBackupDir)
+			if err != nil {
+				return err
+			}
+
+			for _, snapshot := range snapshots {
+				fmt.Printf("%s\t%s\t%d bytes\tsha256 %s\n",
+					snapshot.Name, snapshot.Created.Local().Format("2006-01-02 15:04:05"), snapshot.Size, snapshot.Checksum)
+			}
+
+			return nil
+		}
+
+		source := backupRoute
 
-		db, err := json.NewJsonDB(jsonFilePath)
-		if err != nil {
-			panic(err)
+		if snapshotName != "" {
+			source = filepath.Join(restoreBackupDir, filepath.Base(snapshotName))
+
+			if err := json.VerifySnapshot(source); err != nil {
+				return err
+			}
 		}
 
-		err = db.RestoreDB(backupRoute)
-		if err != nil {
-			panic(err)
+		fmt.Println("restore called")
+
+		// the target is not loaded, so a corrupt database can be restored
+		if err := json.RestoreFile(targetFilePath, source); err != nil {
+			return err
 		}
+
+		fmt.Println("finished copying from ", source, " to ", targetFilePath)
+
+		return nil
 	},
 }
 
@@ -55,4 +86,7 @@ func init() {
 
 	restoreCmd.Flags().StringVarP(&backupRoute, "target", "t", defaultBackupFilePath, "target a specific backup file")
 	restoreCmd.Flags().StringVarP(&targetFilePath, "destination", "f", defaultTargetFilePath, "The file path to the Json DB")
+	restoreCmd.Flags().BoolVarP(&listSnapshots, "list", "l", false, "list the snapshots in the backup directory")
+	restoreCmd.Flags().StringVarP(&snapshotName, "snapshot", "s", "", "restore a snapshot from the backup directory by name")
+	restoreCmd.Flags().StringVarP(&restoreBackupDir, "dir", "d", defaultBackupDir, "The directory snapshots are read from")
 }
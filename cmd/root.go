@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 
+	"drexel.edu/voter-api/pkg/storage/json"
 	"github.com/spf13/cobra"
 )
 
@@ -39,6 +40,8 @@ func Execute() {
 }
 
 func init() {
+	json.AppVersion = version
+
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
@@ -4,22 +4,46 @@ Copyright © 2024 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"time"
 
 	"drexel.edu/voter-api/pkg/http/rest"
 	"drexel.edu/voter-api/pkg/process"
 	"drexel.edu/voter-api/pkg/retrieve"
 	"drexel.edu/voter-api/pkg/storage/json"
+	"drexel.edu/voter-api/pkg/storage/memory"
+	"drexel.edu/voter-api/pkg/storage/sqlite"
 	"github.com/spf13/cobra"
 )
 
 const (
-	defaultFilePath = "./Data"
+	defaultFilePath       = "./Data"
+	defaultSqliteFilePath = "./Data.db"
+	storageJson           = "json"
+	storageMemory         = "memory"
+	storageSqlite         = "sqlite"
 )
 
+type repository interface {
+	process.Repository
+	process.PollRepository
+	retrieve.Repository
+	retrieve.PollRepository
+	retrieve.AuditRepository
+	EnableChain() error
+}
+
 var port int
 var jsonFilePath string
+var useJournal bool
+var compactAfter int
+var storage string
+var sqliteFilePath string
+var backupEvery time.Duration
+var auditChain bool
 
 // startCmd represents the start command
 var startCmd = &cobra.Command{
@@ -28,15 +52,36 @@ var startCmd = &cobra.Command{
 	Long:  `Allows the user to specify the port, otherwise uses 3000 by default`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		repository, err := json.NewJsonDB(jsonFilePath)
+		repository, err := openRepository()
 		if err != nil {
 			panic(err)
 		}
 
+		if auditChain {
+			if err := repository.EnableChain(); err != nil {
+				panic(err)
+			}
+		}
+
+		if backupEvery > 0 {
+			if err := scheduleBackups(repository); err != nil {
+				panic(err)
+			}
+		}
+
 		processService := process.NewService(repository)
 		retrievalService := retrieve.NewService(repository)
+		pollProcessService := process.NewPollService(repository)
+		pollRetrievalService := retrieve.NewPollService(repository)
+		auditService := retrieve.NewAuditService(repository)
+
+		if pollWindowsFilePath != "" {
+			if err := applyPollWindows(pollWindowsFilePath, pollProcessService); err != nil {
+				panic(err)
+			}
+		}
 
-		router := rest.Handler(port, processService, retrievalService)
+		router := rest.Handler(port, processService, retrievalService, pollProcessService, pollRetrievalService, auditService)
 
 		fmt.Printf("The Server is started: http://localhost:%d", port)
 
@@ -44,6 +89,88 @@ var startCmd = &cobra.Command{
 	},
 }
 
+// openRepository creates the backend selected with --storage.
+func openRepository() (repository, error) {
+	switch storage {
+	case storageMemory:
+		return memory.NewMemoryDB(), nil
+	case storageSqlite:
+		return sqlite.NewSqliteDB(sqliteFilePath)
+	case storageJson:
+		if err := checkJsonFormat(jsonFilePath); err != nil {
+			return nil, err
+		}
+		if useJournal {
+			return json.NewJournaledJsonDB(jsonFilePath, compactAfter)
+		}
+		return json.NewJsonDB(jsonFilePath)
+	default:
+		return nil, fmt.Errorf("unknown storage %q, expected %s, %s or %s", storage, storageJson, storageMemory, storageSqlite)
+	}
+}
+
+// scheduleBackups writes a snapshot of the Json DB every backupEvery, signs
+// it if there is a signing key and applies the retention policy after each
+// one.
+func scheduleBackups(r repository) error {
+	db, ok := r.(*json.VoterDB)
+	if !ok {
+		return fmt.Errorf("scheduled backups are only supported with --storage=%s", storageJson)
+	}
+
+	signingKey, err := loadSigningKey(signingKeyPath)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		ticker := time.NewTicker(backupEvery)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			snapshot, err := db.Backup(backupDir)
+			if err != nil {
+				log.Printf("scheduled backup failed: %v", err)
+				continue
+			}
+
+			log.Printf("wrote %s (sha256 %s)", snapshot.Path, snapshot.Checksum)
+
+			if err := signSnapshot(snapshot, signingKey); err != nil {
+				log.Printf("signing backup failed: %v", err)
+			}
+
+			if err := pruneSnapshots(backupDir); err != nil {
+				log.Printf("pruning backups failed: %v", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// checkJsonFormat refuses to serve a Json DB in an older format. Those files
+// are upgraded with the migrate command, which keeps a backup of the original.
+func checkJsonFormat(filePath string) error {
+	stat, err := os.Stat(filePath)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && stat.Size() == 0) {
+		//a new database is created in the current format
+		return nil
+	}
+
+	info, err := json.ReadFormat(filePath)
+	if err != nil {
+		return err
+	}
+
+	if info.SchemaVersion < json.CurrentSchemaVersion && info.RecordCount > 0 {
+		return fmt.Errorf("%s uses schema version %d, run \"voter-api migrate -f %s\" to upgrade it to version %d",
+			filePath, info.SchemaVersion, filePath, json.CurrentSchemaVersion)
+	}
+
+	return nil
+}
+
 func init() {
 	rootCmd.AddCommand(startCmd)
 
@@ -57,5 +184,16 @@ func init() {
 	// is called directly, e.g.:
 	// startCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
 	startCmd.Flags().IntVarP(&port, "port", "p", 3000, "The port on which to start the server")
+	startCmd.Flags().StringVarP(&storage, "storage", "s", storageJson, "The storage backend to use: json, memory or sqlite")
+	startCmd.Flags().DurationVar(&backupEvery, "backupEvery", 0, "Write a snapshot of the Json DB at this interval, e.g. 24h (disabled by default)")
+	startCmd.Flags().StringVar(&backupDir, "backupDir", defaultBackupDir, "The directory scheduled snapshots are written to")
+	startCmd.Flags().IntVar(&keepDaily, "keepDaily", defaultKeepDaily, "The number of daily snapshots to keep")
+	startCmd.Flags().IntVar(&keepWeekly, "keepWeekly", defaultKeepWeekly, "The number of weekly snapshots to keep")
+	startCmd.Flags().StringVar(&signingKeyPath, "signingKey", "", "A private key from \"voter-api keys generate\" to sign scheduled snapshots with")
+	startCmd.Flags().StringVar(&sqliteFilePath, "sqlitePath", defaultSqliteFilePath, "The file path to the SQLite DB")
 	startCmd.Flags().StringVarP(&jsonFilePath, "filePath", "f", defaultFilePath, "The file path to the Json DB")
+	startCmd.Flags().BoolVarP(&useJournal, "journal", "j", false, "Append changes to a journal instead of rewriting the Json DB on every write")
+	startCmd.Flags().StringVar(&pollWindowsFilePath, "pollWindows", "", "A Json file of poll windows to apply on start up, see the README")
+	startCmd.Flags().BoolVar(&auditChain, "auditChain", false, "Keep a hash chain over every change to voter history, see the verify command")
+	startCmd.Flags().IntVar(&compactAfter, "compactAfter", json.DefaultCompactAfter, "The number of journal entries written before the Json DB is compacted")
 }
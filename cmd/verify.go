@@ -0,0 +1,86 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/json"
+	"drexel.edu/voter-api/pkg/storage/sqlite"
+	"github.com/spf13/cobra"
+)
+
+var verifyFilePath string
+var verifyStorage string
+var verifySqlitePath string
+var verifyHead string
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Checks the voter history against the audit chain",
+	Long: `Replays the audit chain kept with --auditChain and compares it with
+	the voter history in the database, reporting any entry which was edited
+	and any history changed without going through the API. with --head the
+	chain must still contain a head printed by an earlier run.
+
+	The hashes are not keyed: someone who can write the database can also
+	rebuild the chain with new hashes, which only --head catches. Keep the
+	head printed by each run somewhere the database cannot be edited from`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		var auditor retrieve.AuditRepository
+
+		switch verifyStorage {
+		case storageJson:
+			auditor = jsonFileAuditor(verifyFilePath)
+		case storageSqlite:
+			db, err := sqlite.NewSqliteDB(verifySqlitePath)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			auditor = db
+		default:
+			return fmt.Errorf("unknown storage %q, expected %s or %s", verifyStorage, storageJson, storageSqlite)
+		}
+
+		report, err := retrieve.NewAuditService(auditor).VerifyChain(verifyHead)
+		if err != nil {
+			return err
+		}
+
+		for _, problem := range report.GetProblems() {
+			fmt.Println(problem)
+		}
+
+		fmt.Printf("%d entries, head %s\n", report.GetEntries(), report.GetHead())
+
+		if !report.IsValid() {
+			return fmt.Errorf("%d problems found in the audit chain", len(report.GetProblems()))
+		}
+
+		return nil
+	},
+}
+
+// jsonFileAuditor checks the Json DB from the file, so the server does not
+// have to be stopped first.
+type jsonFileAuditor string
+
+func (a jsonFileAuditor) VerifyChain(head string) (retrieve.ChainReportDTO, error) {
+	return json.VerifyFile(string(a), head)
+}
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.Flags().StringVarP(&verifyFilePath, "filePath", "f", defaultFilePath, "The file path to the Json DB")
+	verifyCmd.Flags().StringVarP(&verifyStorage, "storage", "s", storageJson, "The storage backend to check: json or sqlite")
+	verifyCmd.Flags().StringVar(&verifySqlitePath, "sqlitePath", defaultSqliteFilePath, "The file path to the SQLite DB")
+	verifyCmd.Flags().StringVar(&verifyHead, "head", "", "A head hash from an earlier run which the chain must still contain")
+}
@@ -0,0 +1,49 @@
+/*
+Copyright © 2024 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"drexel.edu/voter-api/pkg/storage/signature"
+	"github.com/spf13/cobra"
+)
+
+var signedFilePath string
+var signatureFilePath string
+var publicKeyPath string
+
+// verifySignatureCmd represents the verify-signature command
+var verifySignatureCmd = &cobra.Command{
+	Use:   "verify-signature",
+	Short: "Checks a snapshot against its detached signature",
+	Long: `Checks that a file was signed with the private key matching the given
+	public key and has not been modified since. the signature is read from
+	<filePath>.sig unless another file is given`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		public, err := signature.LoadPublicKey(publicKeyPath)
+		if err != nil {
+			return err
+		}
+
+		if err := signature.VerifyFile(signedFilePath, signatureFilePath, public); err != nil {
+			return err
+		}
+
+		fmt.Printf("%s: signature OK (key %s)\n", signedFilePath, signature.Fingerprint(public))
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(verifySignatureCmd)
+
+	verifySignatureCmd.Flags().StringVarP(&signedFilePath, "filePath", "f", "", "The signed file")
+	verifySignatureCmd.Flags().StringVar(&signatureFilePath, "signature", "", "The detached signature (default <filePath>.sig)")
+	verifySignatureCmd.Flags().StringVarP(&publicKeyPath, "publicKey", "k", defaultKeyPath+signature.PublicKeySuffix, "The public key to check the signature with")
+	verifySignatureCmd.MarkFlagRequired("filePath")
+}
@@ -22,7 +22,10 @@ require (
 	golang.org/x/sys v0.17.0 // indirect
 )
 
-require github.com/brianvoe/gofakeit/v6 v6.28.0
+require (
+	github.com/brianvoe/gofakeit/v6 v6.28.0
+	github.com/mattn/go-sqlite3 v1.14.22
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
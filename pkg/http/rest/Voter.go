@@ -1,10 +1,71 @@
 package rest
 
+import (
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+)
+
+// dateFormat is how a date of birth is sent and received.
+const dateFormat = "2006-01-02"
+
 type Voter struct {
 	Id           int            `json:"id"`
 	Name         string         `json:"name"`
 	Email        string         `json:"email"`
-	VoterHistory []VoterHistory `json:"voter_history"`
+	VoterHistory []VoterHistory `json:"voter_history,omitempty"`
 	Created      string         `json:"created"`
 	Modified     string         `json:"modified"`
+	Deleted      string         `json:"deleted,omitempty"`
+	DeleteReason string         `json:"delete_reason,omitempty"`
+	Version      int            `json:"version,omitempty"`
+
+	DateOfBirth        string   `json:"date_of_birth,omitempty"`
+	ResidentialAddress *Address `json:"residential_address,omitempty"`
+	MailingAddress     *Address `json:"mailing_address,omitempty"`
+	Jurisdiction       string   `json:"jurisdiction,omitempty"`
+}
+
+type Address struct {
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+}
+
+// withProfile adds the date of birth, addresses and jurisdiction in the body
+// of a POST or PUT to /voters/:id. All of them may be left out.
+func withProfile(voterDTO process.VoterDTO, voter Voter) (process.VoterDTO, error) {
+	dateOfBirth := time.Time{}
+
+	if voter.DateOfBirth != "" {
+		var err error
+		if dateOfBirth, err = time.Parse(dateFormat, voter.DateOfBirth); err != nil {
+			return process.VoterDTO{}, err
+		}
+	}
+
+	return voterDTO.WithProfile(dateOfBirth, voter.ResidentialAddress.toDTO(), voter.MailingAddress.toDTO(), voter.Jurisdiction), nil
+}
+
+func (a *Address) toDTO() process.AddressDTO {
+	if a == nil {
+		return process.AddressDTO{}
+	}
+
+	return process.NewAddressDTO(a.Street, a.City, a.State, a.PostalCode)
+}
+
+func convertAddressToMuteable(addressDTO retrieve.AddressDTO) *Address {
+	if addressDTO.IsZero() {
+		return nil
+	}
+
+	return &Address{
+		Street:     addressDTO.GetStreet(),
+		City:       addressDTO.GetCity(),
+		State:      addressDTO.GetState(),
+		PostalCode: addressDTO.GetPostalCode(),
+	}
 }
@@ -0,0 +1,25 @@
+package rest
+
+import "drexel.edu/voter-api/pkg/retrieve"
+
+// ChainReport is the body of GET /audit/chain/verify. Head is the hash of
+// the last entry in the chain, problems is empty when valid is true.
+type ChainReport struct {
+	Valid    bool     `json:"valid"`
+	Entries  int      `json:"entries"`
+	Head     string   `json:"head"`
+	Problems []string `json:"problems"`
+}
+
+func convertChainReportToMuteable(reportDTO retrieve.ChainReportDTO) ChainReport {
+	report := ChainReport{
+		Valid:    reportDTO.IsValid(),
+		Entries:  reportDTO.GetEntries(),
+		Head:     reportDTO.GetHead(),
+		Problems: []string{},
+	}
+
+	report.Problems = append(report.Problems, reportDTO.GetProblems()...)
+
+	return report
+}
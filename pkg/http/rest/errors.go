@@ -0,0 +1,134 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// problemContentType is the media type of an RFC 7807 problem details body.
+const problemContentType = "application/problem+json"
+
+// internalErrorDetail is the detail of every 5xx problem. The error itself
+// can name files and storage internals, so it is only logged.
+const internalErrorDetail = "internal server error"
+
+type handlerError string
+
+const (
+	ErrInvalidParameter     handlerError = "a path or query parameter is not valid"
+	ErrInvalidBody          handlerError = "the request body is not valid json for this endpoint"
+	ErrInvalidTime          handlerError = "times must be RFC 3339 and dates YYYY-MM-DD"
+	ErrInvalidIfMatch       handlerError = "If-Match must be * or a single entity tag returned by a GET."
+	ErrInvalidResultsMethod handlerError = "method must be plurality or irv"
+	ErrInvalidFields        handlerError = "fields must be a comma separated list of voter fields"
+	ErrInvalidInclude       handlerError = "include must be history"
+)
+
+var handlerErrors = map[handlerError]process.Error{
+	ErrInvalidParameter:     {Kind: process.KindInvalid, Code: "invalid_parameter"},
+	ErrInvalidBody:          {Kind: process.KindInvalid, Code: "invalid_body"},
+	ErrInvalidTime:          {Kind: process.KindInvalid, Code: "invalid_time"},
+	ErrInvalidIfMatch:       {Kind: process.KindInvalid, Code: "invalid_if_match"},
+	ErrInvalidResultsMethod: {Kind: process.KindInvalid, Code: "invalid_results_method"},
+	ErrInvalidFields:        {Kind: process.KindInvalid, Code: "invalid_fields"},
+	ErrInvalidInclude:       {Kind: process.KindInvalid, Code: "invalid_include"},
+}
+
+func (e handlerError) Error() error {
+	return handlerErrors[e].WithMessage(string(e))
+}
+
+// kindStatus is the status code sent for each kind of process.Error.
+var kindStatus = map[process.ErrorKind]int{
+	process.KindInvalid:            fiber.StatusBadRequest,
+	process.KindNotFound:           fiber.StatusNotFound,
+	process.KindConflict:           fiber.StatusConflict,
+	process.KindPreconditionFailed: fiber.StatusPreconditionFailed,
+	process.KindUnprocessable:      fiber.StatusUnprocessableEntity,
+	process.KindInternal:           fiber.StatusInternalServerError,
+}
+
+// Problem is an RFC 7807 problem details body. Code is the stable code of the
+// error, which clients should match on rather than the detail.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+}
+
+// errorHandler is the error handler of the whole app. Every error a route
+// returns is sent as a problem, with the status code picked from its kind.
+func errorHandler(c *fiber.Ctx, err error) error {
+	problem := problemFor(err)
+	problem.Instance = c.OriginalURL()
+
+	if problem.Status >= fiber.StatusInternalServerError {
+		log.Printf("%s %s: %v", c.Method(), problem.Instance, err)
+	}
+
+	return c.Status(problem.Status).JSON(problem, problemContentType)
+}
+
+// problemFor turns an error into a problem. Errors from parsing the path,
+// query or body, which the routes return as they are, are client errors.
+// Anything else without a kind is a 500. The detail of a 500 is always
+// internalErrorDetail.
+func problemFor(err error) Problem {
+	var typed *process.Error
+	var fiberError *fiber.Error
+	var numError *strconv.NumError
+	var syntaxError *json.SyntaxError
+	var typeError *json.UnmarshalTypeError
+	var timeError *time.ParseError
+
+	switch {
+	case errors.As(err, &typed):
+	case errors.As(err, &numError):
+		typed = ErrInvalidParameter.Error().(*process.Error)
+	case errors.As(err, &syntaxError), errors.As(err, &typeError):
+		typed = ErrInvalidBody.Error().(*process.Error)
+	case errors.As(err, &timeError):
+		typed = ErrInvalidTime.Error().(*process.Error)
+	case errors.As(err, &fiberError):
+		// errors raised by fiber itself, such as a route that does not exist
+		return newProblem(fiberError.Code, strings.ReplaceAll(strings.ToLower(utils.StatusMessage(fiberError.Code)), " ", "_"), fiberError.Message)
+	default:
+		return newProblem(fiber.StatusInternalServerError, "internal_error", err.Error())
+	}
+
+	code := typed.Code
+	if code == "" {
+		code = "internal_error"
+	}
+
+	return newProblem(kindStatus[typed.Kind], code, err.Error())
+}
+
+func newProblem(status int, code string, detail string) Problem {
+	if status == 0 {
+		status = fiber.StatusInternalServerError
+	}
+
+	if status >= fiber.StatusInternalServerError {
+		detail = internalErrorDetail
+	}
+
+	return Problem{
+		Type:   "about:blank",
+		Title:  utils.StatusMessage(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
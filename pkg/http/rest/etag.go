@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"strconv"
+	"strings"
+
+	"drexel.edu/voter-api/pkg/process"
+	"github.com/gofiber/fiber/v2"
+)
+
+// etag formats a record version as a strong entity tag.
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// parseETag reads the version back out of an entity tag. Weak tags are
+// accepted since the version is the same either way.
+func parseETag(tag string) (int, bool) {
+	unquoted, err := strconv.Unquote(strings.TrimPrefix(strings.TrimSpace(tag), "W/"))
+	if err != nil {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// expectedVersion reads the If-Match header of a PUT or DELETE. Without the
+// header, or with *, the change is made whatever the current version is.
+func expectedVersion(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+
+	if header == "" || header == "*" {
+		return process.AnyVersion, nil
+	}
+
+	version, ok := parseETag(header)
+	if !ok {
+		return 0, ErrInvalidIfMatch.Error()
+	}
+
+	return version, nil
+}
+
+// notModified sets the ETag header for a GET and reports whether the
+// If-None-Match header already matches it, in which case the caller responds
+// with 304 and no body.
+func notModified(c *fiber.Ctx, version int) bool {
+	c.Set(fiber.HeaderETag, etag(version))
+
+	header := c.Get(fiber.HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(header, ",") {
+		if strings.TrimSpace(tag) == "*" {
+			return true
+		}
+
+		if tagVersion, ok := parseETag(tag); ok && tagVersion == version {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// historyField is the field of Voter that holds its history.
+const historyField = "voter_history"
+
+// voterFields are the json names of the fields of Voter, which is what
+// ?fields= picks from.
+var voterFields = jsonFields(reflect.TypeOf(Voter{}))
+
+// fieldSet is the fields a client asked for, nil for every field.
+type fieldSet map[string]bool
+
+// parseVoterFields reads ?fields=, a comma separated list of fields to return
+// for each voter, and ?include=history. It reports whether the history was
+// asked for by either.
+func parseVoterFields(c *fiber.Ctx) (fieldSet, bool, error) {
+	includeHistory := false
+
+	for _, name := range splitList(c.Query("include")) {
+		if name != "history" {
+			return nil, false, ErrInvalidInclude.Error()
+		}
+		includeHistory = true
+	}
+
+	names := splitList(c.Query("fields"))
+	if len(names) == 0 {
+		return nil, includeHistory, nil
+	}
+
+	fields := make(fieldSet)
+
+	for _, name := range names {
+		if !voterFields[name] {
+			return nil, false, ErrInvalidFields.Error()
+		}
+		fields[name] = true
+	}
+
+	return fields, includeHistory || fields[historyField], nil
+}
+
+// selectFields drops the fields of the voter that were not asked for.
+func selectFields(voter Voter, fields fieldSet) (any, error) {
+	if fields == nil {
+		return voter, nil
+	}
+
+	data, err := json.Marshal(voter)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected map[string]json.RawMessage
+	if err := json.Unmarshal(data, &selected); err != nil {
+		return nil, err
+	}
+
+	for name := range selected {
+		if !fields[name] {
+			delete(selected, name)
+		}
+	}
+
+	return selected, nil
+}
+
+func splitList(value string) []string {
+	var list []string
+
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
+func jsonFields(t reflect.Type) map[string]bool {
+	fields := make(map[string]bool)
+
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[name] = true
+	}
+
+	return fields
+}
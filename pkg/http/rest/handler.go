@@ -10,10 +10,10 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func Handler(port int, processService process.Service, retrievalService retrieve.Service) *fiber.App {
+func Handler(port int, processService process.Service, retrievalService retrieve.Service, pollProcessService process.PollService, pollRetrievalService retrieve.PollService, auditService retrieve.AuditService) *fiber.App {
 	startTime := time.Now()
 
-	router := fiber.New()
+	router := fiber.New(fiber.Config{ErrorHandler: errorHandler})
 
 	//GET /voters/health - Returns a "health" record indicating that the voter API is functioning properly and some metadata about the API.  Note the payload can be hard coded, we are mainly looking for a HTTP status code of 200, which means the API is functioning properly.
 	router.Get("/voters/health", func(c *fiber.Ctx) error {
@@ -31,26 +31,88 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 		return c.SendString(msg)
 	})
 
-	//GET /voters - Get all voter resources including all voter history for each voter (note we will discuss the concept of "paging" later, for now you can ignore)
+	//GET /voters - Get a page of voters, see parseVoterQuery for the paging, sorting and filters.  Deleted voters are only included with ?include_deleted=true, and voter history only with ?include=history
+	//GET /voters?email= - Get the single voter registered with that email, ignoring case
+	//Both take ?fields= to return only some fields of each voter, see parseVoterFields
 	router.Get("/voters", func(c *fiber.Ctx) error {
 
-		votersDTO, err := retrievalService.GetAllVoters()
+		fields, includeHistory, err := parseVoterFields(c)
 		if err != nil {
-			c.Status(fiber.StatusInternalServerError)
 			return err
 		}
 
-		var voters []Voter
+		if email := c.Query("email"); email != "" {
+			// a single voter has its history unless ?fields= leaves it out
+			voterDTO, err := retrievalService.GetVoterByEmail(email, includeHistory || fields == nil)
+			if err != nil {
+				return err
+			}
 
-		for _, voter := range votersDTO {
-			voters = append(voters, convertVoterToMuteable(voter))
+			voter, err := selectFields(convertVoterToMuteable(voterDTO), fields)
+			if err != nil {
+				return err
+			}
+
+			c.Status(fiber.StatusOK)
+			return c.JSON(voter)
+		}
+
+		query, err := parseVoterQuery(c)
+		if err != nil {
+			return err
+		}
+
+		query.IncludeHistory = includeHistory
+
+		pageDTO, err := retrievalService.ListVoters(query)
+		if err != nil {
+			return err
 		}
 
+		voters := []any{}
+
+		for _, voterDTO := range pageDTO.GetVoters() {
+			voter, err := selectFields(convertVoterToMuteable(voterDTO), fields)
+			if err != nil {
+				return err
+			}
+
+			voters = append(voters, voter)
+		}
+
+		setPageHeaders(c, query, pageDTO.GetTotal())
+
 		c.Status(fiber.StatusOK)
 		return c.JSON(voters)
 	})
 
-	//GET&POST /voters/:id - Get a single voter resource with voterID=:id including their entire voting history.  POST version adds one to the "database"
+	//GET /voters/search?q= - Find voters by name, closest first, even when misspelled.  ?limit= sets the most matches returned, 20 by default
+	router.Get("/voters/search", func(c *fiber.Ctx) error {
+
+		limit, err := optionalInt(c.Query("limit"))
+		if err != nil {
+			return err
+		}
+
+		matchDTOs, err := retrievalService.SearchVoters(retrieve.SearchQuery{Text: c.Query("q"), Limit: limit})
+		if err != nil {
+			return err
+		}
+
+		matches := []VoterMatch{}
+
+		for _, matchDTO := range matchDTOs {
+			matches = append(matches, VoterMatch{
+				Distance: matchDTO.GetDistance(),
+				Voter:    convertVoterToMuteable(matchDTO.GetVoter()),
+			})
+		}
+
+		c.Status(fiber.StatusOK)
+		return c.JSON(matches)
+	})
+
+	//GET&POST /voters/:id - Get a single voter resource with voterID=:id including their entire voting history, or only the ?fields= asked for.  POST version adds one to the "database"
 	router.Get("/voters/:id", func(c *fiber.Ctx) error {
 
 		voterId, err := strconv.Atoi(c.Params("id"))
@@ -58,14 +120,28 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 			return err
 		}
 
-		voterDTO, err := retrievalService.GetSingleVoter(voterId)
+		fields, includeHistory, err := parseVoterFields(c)
+		if err != nil {
+			return err
+		}
+
+		// the history is only left unloaded when ?fields= leaves it out
+		voterDTO, err := retrievalService.GetSingleVoter(voterId, includeHistory || fields == nil)
+		if err != nil {
+			return err
+		}
+
+		if notModified(c, voterDTO.GetVersion()) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+
+		voter, err := selectFields(convertVoterToMuteable(voterDTO), fields)
 		if err != nil {
-			c.Status(fiber.StatusInternalServerError)
 			return err
 		}
 
 		c.Status(fiber.StatusOK)
-		return c.JSON(convertVoterToMuteable(voterDTO))
+		return c.JSON(voter)
 	})
 
 	router.Post("/voters/:id", func(c *fiber.Ctx) error {
@@ -87,9 +163,13 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 			voter.Email,
 		)
 
+		voterDTO, err = withProfile(voterDTO, voter)
+		if err != nil {
+			return err
+		}
+
 		err = processService.CreateVoter(voterDTO)
 		if err != nil {
-			c.Status(fiber.StatusInternalServerError)
 			return err
 		}
 
@@ -98,18 +178,19 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 		return c.SendString("Voter registration successful.")
 	})
 
-	//GET /voters/:id/polls - Gets the JUST the voter history for the voter with VoterID = :id
+	//GET /voters/:id/polls - Gets the JUST the voter history for the voter with VoterID = :id.  Deleted history is only included with ?include_deleted=true, and ?filter= only keeps the history matching a filter expression
 	router.Get("/voters/:id/polls", func(c *fiber.Ctx) error {
 		var voter []retrieve.VoterHistoryDTO
 
-		c.Status(fiber.StatusInternalServerError)
-
 		voterId, err := strconv.Atoi(c.Params("id"))
 		if err != nil {
 			return err
 		}
 
-		voter, err = retrievalService.GetVoterHistory(voterId)
+		voter, err = retrievalService.ListVoterHistory(voterId, retrieve.HistoryQuery{
+			IncludeDeleted: c.QueryBool("include_deleted"),
+			Filter:         c.Query("filter"),
+		})
 		if err != nil {
 			return err
 		}
@@ -130,8 +211,6 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 	router.Get("/voters/:voterId/polls/:pollId", func(c *fiber.Ctx) error {
 		var voter retrieve.VoterHistoryDTO
 
-		c.Status(fiber.StatusInternalServerError)
-
 		voterId, err := strconv.Atoi(c.Params("voterId"))
 		if err != nil {
 			return err
@@ -147,6 +226,10 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 			return err
 		}
 
+		if notModified(c, voter.GetVersion()) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+
 		c.Status(fiber.StatusOK)
 		return c.JSON(convertHistoryToMuteable(voter))
 
@@ -155,8 +238,6 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 	router.Post("/voters/:voterId/polls/:pollId", func(c *fiber.Ctx) error {
 		var voterHistory VoterHistory
 
-		c.Status(fiber.StatusInternalServerError)
-
 		voterId, err := strconv.Atoi(c.Params("voterId"))
 		if err != nil {
 			return err
@@ -180,19 +261,24 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 			pollId,
 			voterId,
 			voteDate,
-		)
+		).WithChoice(voterHistory.Choice).WithRanking(voterHistory.Ranking)
 
-		err = processService.CreateVoterHistory(voterId, pollId, historyDTO)
+		receipt, err := processService.CreateVoterHistory(voterId, pollId, historyDTO)
 		if err != nil {
 			return err
 		}
 
 		c.Status(fiber.StatusCreated)
 
+		if receipt != "" {
+			return c.JSON(BallotReceipt{Receipt: receipt})
+		}
+
 		return c.SendString("CREATED")
 
 	})
 
+	//PUT /voters/:id - Updates the voter.  With If-Match the update is only made if the voter is still at that ETag, otherwise 412 is returned
 	router.Put("/voters/:id", func(c *fiber.Ctx) error {
 
 		var voter Voter
@@ -202,6 +288,11 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 			return err
 		}
 
+		version, err := expectedVersion(c)
+		if err != nil {
+			return err
+		}
+
 		if err := c.BodyParser(&voter); err != nil {
 			return err
 		}
@@ -212,9 +303,13 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 			voter.Email,
 		)
 
-		err = processService.UpdateVoterInfo(voterDTO)
+		voterDTO, err = withProfile(voterDTO, voter)
+		if err != nil {
+			return err
+		}
+
+		err = processService.UpdateVoterInfo(voterDTO, version)
 		if err != nil {
-			c.Status(fiber.StatusInternalServerError)
 			return err
 		}
 
@@ -223,11 +318,10 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 		return c.SendString("Voter update successful.")
 	})
 
+	//PUT /voters/:voterId/polls/:pollId - Updates the voter history.  If-Match is checked against the ETag of the history
 	router.Put("/voters/:voterId/polls/:pollId", func(c *fiber.Ctx) error {
 		var voterHistory VoterHistory
 
-		c.Status(fiber.StatusInternalServerError)
-
 		voterId, err := strconv.Atoi(c.Params("voterId"))
 		if err != nil {
 			return err
@@ -238,6 +332,11 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 			return err
 		}
 
+		version, err := expectedVersion(c)
+		if err != nil {
+			return err
+		}
+
 		if err := c.BodyParser(&voterHistory); err != nil {
 			return err
 		}
@@ -251,9 +350,9 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 			pollId,
 			voterId,
 			voteDate,
-		)
+		).WithChoice(voterHistory.Choice).WithRanking(voterHistory.Ranking)
 
-		err = processService.UpdateVoterHistoryInfo(voterId, pollId, historyDTO)
+		err = processService.UpdateVoterHistoryInfo(voterId, pollId, historyDTO, version)
 		if err != nil {
 			return err
 		}
@@ -264,6 +363,7 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 
 	})
 
+	//DELETE /voters/:id - Marks the voter as deleted, an optional ?reason= is kept with the record.  If-Match is honored as for PUT
 	router.Delete("/voters/:id", func(c *fiber.Ctx) error {
 
 		voterId, err := strconv.Atoi(c.Params("id"))
@@ -271,9 +371,13 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 			return err
 		}
 
-		err = processService.DeleteSingleVoter(voterId)
+		version, err := expectedVersion(c)
+		if err != nil {
+			return err
+		}
+
+		err = processService.DeleteSingleVoter(voterId, c.Query("reason"), version)
 		if err != nil {
-			c.Status(fiber.StatusInternalServerError)
 			return err
 		}
 
@@ -282,10 +386,9 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 		return c.SendString("Voter was removed.")
 	})
 
+	//DELETE /voters/:voterId/polls/:pollId - Marks the voter history as deleted, an optional ?reason= is kept with the record.  If-Match is honored as for PUT
 	router.Delete("/voters/:voterId/polls/:pollId", func(c *fiber.Ctx) error {
 
-		c.Status(fiber.StatusInternalServerError)
-
 		voterId, err := strconv.Atoi(c.Params("voterId"))
 		if err != nil {
 			return err
@@ -296,7 +399,12 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 			return err
 		}
 
-		err = processService.DeleteSingleVoterPoll(voterId, pollId)
+		version, err := expectedVersion(c)
+		if err != nil {
+			return err
+		}
+
+		err = processService.DeleteSingleVoterPoll(voterId, pollId, c.Query("reason"), version)
 		if err != nil {
 			return err
 		}
@@ -307,9 +415,332 @@ func Handler(port int, processService process.Service, retrievalService retrieve
 
 	})
 
+	//POST /voters/:id/restore - Brings back a deleted voter
+	router.Post("/voters/:id/restore", func(c *fiber.Ctx) error {
+
+		voterId, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return err
+		}
+
+		err = processService.RestoreVoter(voterId)
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusOK)
+
+		return c.SendString("Voter was restored.")
+	})
+
+	//POST /voters/:voterId/polls/:pollId/restore - Brings back deleted voter history
+	router.Post("/voters/:voterId/polls/:pollId/restore", func(c *fiber.Ctx) error {
+
+		voterId, err := strconv.Atoi(c.Params("voterId"))
+		if err != nil {
+			return err
+		}
+
+		pollId, err := strconv.Atoi(c.Params("pollId"))
+		if err != nil {
+			return err
+		}
+
+		err = processService.RestoreVoterPoll(voterId, pollId)
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusOK)
+
+		return c.SendString("The voter history was successfully restored.")
+	})
+
+	//GET /voters/:id/revisions - Lists every version of the voter with the fields changed in each one
+	router.Get("/voters/:id/revisions", func(c *fiber.Ctx) error {
+
+		voterId, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return err
+		}
+
+		revisionsDTO, err := retrievalService.GetVoterRevisions(voterId)
+		if err != nil {
+			return err
+		}
+
+		revisions := []Revision{}
+
+		for _, item := range revisionsDTO {
+			revisions = append(revisions, convertRevisionToMuteable(item, false))
+		}
+
+		c.Status(fiber.StatusOK)
+		return c.JSON(revisions)
+	})
+
+	//GET /voters/:id/revisions/:rev - Gets a single version of the voter including the voter and its history as they were
+	router.Get("/voters/:id/revisions/:rev", func(c *fiber.Ctx) error {
+
+		voterId, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return err
+		}
+
+		rev, err := strconv.Atoi(c.Params("rev"))
+		if err != nil {
+			return err
+		}
+
+		revisionDTO, err := retrievalService.GetVoterRevision(voterId, rev)
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusOK)
+		return c.JSON(convertRevisionToMuteable(revisionDTO, true))
+	})
+
+	//POST /voters/:id/revisions/:rev/revert - Puts the voter and its history back to a previous version, recorded as a new revision
+	router.Post("/voters/:id/revisions/:rev/revert", func(c *fiber.Ctx) error {
+
+		voterId, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return err
+		}
+
+		rev, err := strconv.Atoi(c.Params("rev"))
+		if err != nil {
+			return err
+		}
+
+		err = processService.RevertVoter(voterId, rev)
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusOK)
+
+		return c.SendString(fmt.Sprintf("Voter was reverted to revision %d.", rev))
+	})
+
+	//GET /polls - Get all polls ordered by id
+	router.Get("/polls", func(c *fiber.Ctx) error {
+
+		pollsDTO, err := pollRetrievalService.GetAllPolls()
+		if err != nil {
+			return err
+		}
+
+		polls := []Poll{}
+
+		for _, poll := range pollsDTO {
+			polls = append(polls, convertPollToMuteable(poll))
+		}
+
+		c.Status(fiber.StatusOK)
+		return c.JSON(polls)
+	})
+
+	//GET /polls/:id - Get a single poll with pollID=:id
+	router.Get("/polls/:id", func(c *fiber.Ctx) error {
+
+		pollId, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return err
+		}
+
+		pollDTO, err := pollRetrievalService.GetSinglePoll(pollId)
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusOK)
+		return c.JSON(convertPollToMuteable(pollDTO))
+	})
+
+	//GET /polls/:id/results - Counts the votes for each of the poll's options with the turnout of the registered voters.  ?method=irv counts them by instant-runoff instead
+	router.Get("/polls/:id/results", func(c *fiber.Ctx) error {
+
+		pollId, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return err
+		}
+
+		switch c.Query("method", "plurality") {
+		case "plurality":
+			resultsDTO, err := pollRetrievalService.GetPollResults(pollId)
+			if err != nil {
+				return err
+			}
+
+			c.Status(fiber.StatusOK)
+			return c.JSON(convertResultsToMuteable(resultsDTO))
+		case "irv":
+			resultsDTO, err := pollRetrievalService.GetRunoffResults(pollId)
+			if err != nil {
+				return err
+			}
+
+			c.Status(fiber.StatusOK)
+			return c.JSON(convertRunoffResultsToMuteable(resultsDTO))
+		}
+
+		return ErrInvalidResultsMethod.Error()
+	})
+
+	//GET /polls/:id/ballots/:receipt - Looks up a ballot cast in a secret poll by the receipt the voter was given, 404 if no ballot has that receipt
+	router.Get("/polls/:id/ballots/:receipt", func(c *fiber.Ctx) error {
+
+		pollId, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return err
+		}
+
+		ballotDTO, err := pollRetrievalService.GetBallot(pollId, c.Params("receipt"))
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusOK)
+		return c.JSON(convertBallotToMuteable(ballotDTO))
+	})
+
+	//POST /polls/:id - Creates a poll with pollID=:id.  opens_at and closes_at are RFC 3339 times and may be left out, a poll without a status is a draft
+	router.Post("/polls/:id", func(c *fiber.Ctx) error {
+
+		pollDTO, err := parsePoll(c)
+		if err != nil {
+			return err
+		}
+
+		err = pollProcessService.CreatePoll(pollDTO)
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusCreated)
+
+		return c.SendString("CREATED")
+	})
+
+	//PUT /polls/:id - Replaces the poll with pollID=:id
+	router.Put("/polls/:id", func(c *fiber.Ctx) error {
+
+		pollDTO, err := parsePoll(c)
+		if err != nil {
+			return err
+		}
+
+		err = pollProcessService.UpdatePoll(pollDTO)
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusOK)
+
+		return c.SendString("Poll update successful.")
+	})
+
+	//PUT /polls/:id/window - Sets only the times the poll opens and closes.  Votes are only recorded while the poll is open and must be dated within the window
+	router.Put("/polls/:id/window", func(c *fiber.Ctx) error {
+		var window PollWindow
+
+		pollId, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return err
+		}
+
+		if err := c.BodyParser(&window); err != nil {
+			return err
+		}
+
+		opensAt, err := parseOptionalTime(window.OpensAt)
+		if err != nil {
+			return err
+		}
+
+		closesAt, err := parseOptionalTime(window.ClosesAt)
+		if err != nil {
+			return err
+		}
+
+		err = pollProcessService.SetPollWindow(pollId, opensAt, closesAt)
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusOK)
+
+		return c.SendString("Poll window update successful.")
+	})
+
+	//DELETE /polls/:id - Deletes the poll.  A poll that voter history refers to cannot be deleted and 409 is returned
+	router.Delete("/polls/:id", func(c *fiber.Ctx) error {
+
+		pollId, err := strconv.Atoi(c.Params("id"))
+		if err != nil {
+			return err
+		}
+
+		err = pollProcessService.DeletePoll(pollId)
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusOK)
+
+		return c.SendString("Poll was successfully deleted.")
+	})
+
+	//GET /audit/chain/verify - Checks the voter history against the audit chain, 404 if the chain is not enabled.  ?head= also checks the chain still contains a head recorded earlier
+	router.Get("/audit/chain/verify", func(c *fiber.Ctx) error {
+
+		reportDTO, err := auditService.VerifyChain(c.Query("head"))
+		if err != nil {
+			return err
+		}
+
+		c.Status(fiber.StatusOK)
+		return c.JSON(convertChainReportToMuteable(reportDTO))
+	})
+
 	return router
 }
 
+// parsePoll reads the poll in the body of a POST or PUT to /polls/:id.
+func parsePoll(c *fiber.Ctx) (process.PollDTO, error) {
+	var poll Poll
+
+	pollId, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return process.PollDTO{}, err
+	}
+
+	if err := c.BodyParser(&poll); err != nil {
+		return process.PollDTO{}, err
+	}
+
+	opensAt, err := parseOptionalTime(poll.OpensAt)
+	if err != nil {
+		return process.PollDTO{}, err
+	}
+
+	closesAt, err := parseOptionalTime(poll.ClosesAt)
+	if err != nil {
+		return process.PollDTO{}, err
+	}
+
+	return process.NewPollDTO(
+		pollId,
+		poll.Title,
+		poll.Description,
+		opensAt,
+		closesAt,
+		poll.Status,
+	).WithOptions(poll.Options).WithRanked(poll.Ranked).WithSecret(poll.Secret), nil
+}
+
 func convertVoterToMuteable(voterDTO retrieve.VoterDTO) Voter {
 	voter := Voter{
 		Id:       voterDTO.GetId(),
@@ -317,16 +748,23 @@ func convertVoterToMuteable(voterDTO retrieve.VoterDTO) Voter {
 		Email:    voterDTO.GetEmail(),
 		Created:  voterDTO.GetCreated().Format(time.RFC3339),
 		Modified: voterDTO.GetModified().Format(time.RFC3339),
+		Version:  voterDTO.GetVersion(),
+	}
+
+	if voterDTO.IsDeleted() {
+		voter.Deleted = voterDTO.GetDeleted().Format(time.RFC3339)
+		voter.DeleteReason = voterDTO.GetDeleteReason()
+	}
+
+	if !voterDTO.GetDateOfBirth().IsZero() {
+		voter.DateOfBirth = voterDTO.GetDateOfBirth().Format(dateFormat)
 	}
+	voter.ResidentialAddress = convertAddressToMuteable(voterDTO.GetResidentialAddress())
+	voter.MailingAddress = convertAddressToMuteable(voterDTO.GetMailingAddress())
+	voter.Jurisdiction = voterDTO.GetJurisdiction()
 
 	for _, item := range voterDTO.GetHistory() {
-		voter.VoterHistory = append(voter.VoterHistory, VoterHistory{
-			PollId:   item.GetPollID(),
-			VoteId:   item.GetVoteID(),
-			VoteDate: item.GetVoteDate().Format(time.RFC3339),
-			Created:  item.GetCreated().Format(time.RFC3339),
-			Modified: item.GetModified().Format(time.RFC3339),
-		})
+		voter.VoterHistory = append(voter.VoterHistory, convertHistoryToMuteable(item))
 	}
 
 	return voter
@@ -337,8 +775,52 @@ func convertHistoryToMuteable(historyDTO retrieve.VoterHistoryDTO) VoterHistory
 		PollId:   historyDTO.GetPollID(),
 		VoteId:   historyDTO.GetVoteID(),
 		VoteDate: historyDTO.GetVoteDate().Format(time.RFC3339),
+		Choice:   historyDTO.GetChoice(),
+		Ranking:  historyDTO.GetRanking(),
 		Created:  historyDTO.GetCreated().Format(time.RFC3339),
 		Modified: historyDTO.GetModified().Format(time.RFC3339),
+		Version:  historyDTO.GetVersion(),
+	}
+
+	if historyDTO.IsDeleted() {
+		history.Deleted = historyDTO.GetDeleted().Format(time.RFC3339)
+		history.DeleteReason = historyDTO.GetDeleteReason()
 	}
+
 	return history
 }
+
+// convertRevisionToMuteable leaves out the voter unless includeVoter is set.
+// Snapshots do not keep created and modified times so those are left empty,
+// anything deleted at that revision shows the revision time as deleted.
+func convertRevisionToMuteable(revisionDTO retrieve.RevisionDTO, includeVoter bool) Revision {
+	revision := Revision{
+		Revision: revisionDTO.GetRevision(),
+		Created:  revisionDTO.GetCreated().Format(time.RFC3339),
+		Action:   revisionDTO.GetAction(),
+		Changes:  []Change{},
+	}
+
+	for _, item := range revisionDTO.GetChanges() {
+		revision.Changes = append(revision.Changes, Change{
+			Field: item.GetField(),
+			Old:   item.GetOld(),
+			New:   item.GetNew(),
+		})
+	}
+
+	if includeVoter {
+		voter := convertVoterToMuteable(revisionDTO.GetVoter())
+		voter.Created = ""
+		voter.Modified = ""
+
+		for i := range voter.VoterHistory {
+			voter.VoterHistory[i].Created = ""
+			voter.VoterHistory[i].Modified = ""
+		}
+
+		revision.Voter = &voter
+	}
+
+	return revision
+}
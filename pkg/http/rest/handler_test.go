@@ -1,8 +1,13 @@
 package rest
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"drexel.edu/voter-api/pkg/process"
@@ -19,7 +24,12 @@ func init() {
 	processService := process.NewService(&process.MockRepository{})
 	retrievalService := retrieve.NewService(&retrieve.MockRepository{})
 
-	router := Handler(3000, processService, retrievalService)
+	pollProcessService := process.NewPollService(&process.MockRepository{})
+	pollRetrievalService := retrieve.NewPollService(&retrieve.MockRepository{})
+
+	auditService := retrieve.NewAuditService(&retrieve.MockRepository{})
+
+	router := Handler(3000, processService, retrievalService, pollProcessService, pollRetrievalService, auditService)
 
 	testHandler = router
 }
@@ -36,10 +46,46 @@ func TestGetAllVoters(t *testing.T) {
 	assert.Equal(t, 200, resp.StatusCode)
 }
 
+func TestListVotersPages(t *testing.T) {
+	r := httptest.NewRequest("GET", "/voters?per_page=2&sort=-created&name=a", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "3", resp.Header.Get("X-Total-Count"))
+
+	link := resp.Header.Get("Link")
+	assert.Contains(t, link, `rel="first"`)
+	assert.Contains(t, link, `/voters?per_page=2&sort=-created&name=a&page=2>; rel="next"`)
+	assert.Contains(t, link, `page=2>; rel="last"`)
+	assert.NotContains(t, link, `rel="prev"`)
+
+	var voters []Voter
+	err := json.NewDecoder(resp.Body).Decode(&voters)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(voters))
+
+	r = httptest.NewRequest("GET", "/voters?page=2&per_page=2", nil)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Link"), `page=1&per_page=2>; rel="prev"`)
+	assert.NotContains(t, resp.Header.Get("Link"), `rel="next"`)
+
+	for _, target := range []string{"/voters?sort=email", "/voters?page=abc", "/voters?per_page=5000", "/voters?created_after=yesterday", "/voters?page=9223372036854775807&per_page=2"} {
+		r = httptest.NewRequest("GET", target, nil)
+		resp, _ = testHandler.Test(r, -1)
+		assert.Equal(t, 400, resp.StatusCode, target)
+	}
+}
+
 func TestGetVoterById(t *testing.T) {
 	r := httptest.NewRequest("GET", "/voters/1", nil)
 	resp, _ := testHandler.Test(r, -1)
 	assert.Equal(t, 200, resp.StatusCode)
+
+	//a single voter has its history without ?include=history
+	var voter Voter
+	err := json.NewDecoder(resp.Body).Decode(&voter)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(voter.VoterHistory))
 }
 
 func TestPostToCreateVoterById(t *testing.T) {
@@ -138,3 +184,470 @@ func TestDeleteSinglePollById(t *testing.T) {
 	resp, _ := testHandler.Test(r, -1)
 	assert.Equal(t, 200, resp.StatusCode)
 }
+
+func TestGetAllVotersIncludeDeleted(t *testing.T) {
+	r := httptest.NewRequest("GET", "/voters?include_deleted=true", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestDeleteVoterWithReason(t *testing.T) {
+	r := httptest.NewRequest("DELETE", "/voters/1?reason=duplicate", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestRestoreVoterById(t *testing.T) {
+	r := httptest.NewRequest("POST", "/voters/1/restore", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestRestoreSinglePollById(t *testing.T) {
+	r := httptest.NewRequest("POST", "/voters/1/polls/1/restore", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestGetVoterRevisions(t *testing.T) {
+	r := httptest.NewRequest("GET", "/voters/1/revisions", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestGetSingleVoterRevision(t *testing.T) {
+	r := httptest.NewRequest("GET", "/voters/1/revisions/1", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestRevertVoter(t *testing.T) {
+	r := httptest.NewRequest("POST", "/voters/1/revisions/1/revert", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestGetVoterETag(t *testing.T) {
+	r := httptest.NewRequest("GET", "/voters/1", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+
+	r = httptest.NewRequest("GET", "/voters/1", nil)
+	r.Header.Set("If-None-Match", `"1"`)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 304, resp.StatusCode)
+
+	r = httptest.NewRequest("GET", "/voters/1/polls/1", nil)
+	r.Header.Set("If-None-Match", `W/"2"`)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+}
+
+func TestIfMatch(t *testing.T) {
+	body := `{"Name": "Miguel","Email": "mad32@drexel.edu"}`
+
+	r := httptest.NewRequest("PUT", "/voters/1", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", `"1"`)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	r = httptest.NewRequest("PUT", "/voters/1", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("If-Match", `"2"`)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 412, resp.StatusCode)
+
+	r = httptest.NewRequest("DELETE", "/voters/1/polls/1", nil)
+	r.Header.Set("If-Match", `"2"`)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 412, resp.StatusCode)
+
+	r = httptest.NewRequest("DELETE", "/voters/1", nil)
+	r.Header.Set("If-Match", `not-a-tag`)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestGetVoterByEmail(t *testing.T) {
+	r := httptest.NewRequest("GET", "/voters?email=Someone@Example.com", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var voter Voter
+	err := json.NewDecoder(resp.Body).Decode(&voter)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(voter.VoterHistory))
+}
+
+func TestEmailConflict(t *testing.T) {
+	body := `{"Name": "Miguel","Email": "` + process.MockTakenEmail + `"}`
+
+	r := httptest.NewRequest("POST", "/voters/1", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 409, resp.StatusCode)
+
+	r = httptest.NewRequest("PUT", "/voters/1", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 409, resp.StatusCode)
+}
+
+func TestGetAllPolls(t *testing.T) {
+	r := httptest.NewRequest("GET", "/polls", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestGetPollById(t *testing.T) {
+	r := httptest.NewRequest("GET", "/polls/1", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestCreateUpdateDeletePoll(t *testing.T) {
+	body := `{"title": "General election", "opens_at": "2024-11-05T06:00:00Z", "closes_at": "2024-11-05T20:00:00Z", "status": "open"}`
+
+	r := httptest.NewRequest("POST", "/polls/1", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	r = httptest.NewRequest("PUT", "/polls/1", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	r = httptest.NewRequest("POST", "/polls/1", strings.NewReader(`{"title": "General election", "opens_at": "tomorrow"}`))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 400, resp.StatusCode)
+
+	r = httptest.NewRequest("DELETE", "/polls/1", nil)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestCreateHistoryForUnknownPoll(t *testing.T) {
+	body := `{"vote_date": "2024-11-05T10:00:00Z"}`
+
+	r := httptest.NewRequest("POST", fmt.Sprintf("/voters/1/polls/%d", process.MockUnknownPollId), strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestSetPollWindow(t *testing.T) {
+	body := `{"opens_at": "2024-11-05T06:00:00Z", "closes_at": "2024-11-05T20:00:00Z"}`
+
+	r := httptest.NewRequest("PUT", "/polls/1/window", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+}
+
+func TestVoteOutsidePollWindow(t *testing.T) {
+	body := `{"vote_date": "2024-11-05T10:00:00Z"}`
+
+	r := httptest.NewRequest("POST", fmt.Sprintf("/voters/1/polls/%d", process.MockClosedPollId), strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	//the mocked window was years ago, so the poll is closed by now
+	r = httptest.NewRequest("PUT", fmt.Sprintf("/voters/1/polls/%d", process.MockScheduledPollId), strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 422, resp.StatusCode)
+}
+
+func TestGetPollResults(t *testing.T) {
+	r := httptest.NewRequest("GET", "/polls/1/results", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var results PollResults
+	err := json.NewDecoder(resp.Body).Decode(&results)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, results.VotesCast)
+	assert.Equal(t, 50.0, results.Turnout)
+	assert.Equal(t, OptionResult{Option: "yes", Votes: 2, Percentage: 50}, results.Options[0])
+}
+
+func TestInvalidChoice(t *testing.T) {
+	body := `{"vote_date": "2024-11-05T10:00:00Z", "choice": "maybe"}`
+
+	r := httptest.NewRequest("POST", fmt.Sprintf("/voters/1/polls/%d", process.MockBallotPollId), strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	body = `{"vote_date": "2024-11-05T10:00:00Z", "choice": "yes"}`
+
+	r = httptest.NewRequest("POST", fmt.Sprintf("/voters/1/polls/%d", process.MockBallotPollId), strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 201, resp.StatusCode)
+}
+
+func TestGetRunoffResults(t *testing.T) {
+	r := httptest.NewRequest("GET", fmt.Sprintf("/polls/%d/results?method=irv", retrieve.MockRankedPollId), nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var results RunoffResults
+	err := json.NewDecoder(resp.Body).Decode(&results)
+	assert.NoError(t, err)
+	assert.Equal(t, "carol", results.Winner)
+	assert.Equal(t, 2, len(results.Rounds))
+	assert.Equal(t, "bob", results.Rounds[0].Eliminated)
+	assert.Equal(t, 1, results.Rounds[1].Exhausted)
+
+	r = httptest.NewRequest("GET", "/polls/1/results?method=borda", nil)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 400, resp.StatusCode)
+}
+
+func TestInvalidRanking(t *testing.T) {
+	body := `{"vote_date": "2024-11-05T10:00:00Z", "ranking": ["carol", "carol"]}`
+
+	r := httptest.NewRequest("POST", fmt.Sprintf("/voters/1/polls/%d", process.MockRankedPollId), strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	body = `{"vote_date": "2024-11-05T10:00:00Z", "ranking": ["carol", "alice"]}`
+
+	r = httptest.NewRequest("POST", fmt.Sprintf("/voters/1/polls/%d", process.MockRankedPollId), strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 201, resp.StatusCode)
+}
+
+func TestSecretBallot(t *testing.T) {
+	body := `{"vote_date": "2024-11-05T10:00:00Z", "choice": "yes"}`
+
+	r := httptest.NewRequest("POST", fmt.Sprintf("/voters/1/polls/%d", process.MockSecretPollId), strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	var receipt BallotReceipt
+	err := json.NewDecoder(resp.Body).Decode(&receipt)
+	assert.NoError(t, err)
+	assert.Equal(t, 16, len(receipt.Receipt))
+
+	r = httptest.NewRequest("PUT", fmt.Sprintf("/voters/1/polls/%d", process.MockSecretPollId), strings.NewReader(`{"vote_date": "2024-11-05T10:00:00Z", "choice": "no"}`))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 422, resp.StatusCode)
+}
+
+func TestGetBallot(t *testing.T) {
+	r := httptest.NewRequest("GET", fmt.Sprintf("/polls/%d/ballots/abcdefghijklmnop", retrieve.MockSecretPollId), nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var ballot Ballot
+	err := json.NewDecoder(resp.Body).Decode(&ballot)
+	assert.NoError(t, err)
+	assert.Equal(t, "ABCDEFGHIJKLMNOP", ballot.Receipt)
+	assert.Equal(t, "no", ballot.Choice)
+
+	r = httptest.NewRequest("GET", fmt.Sprintf("/polls/%d/ballots/AAAAAAAAAAAAAAAA", retrieve.MockSecretPollId), nil)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 404, resp.StatusCode)
+}
+
+func TestVerifyChain(t *testing.T) {
+	r := httptest.NewRequest("GET", "/audit/chain/verify", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var report ChainReport
+	err := json.NewDecoder(resp.Body).Decode(&report)
+	assert.NoError(t, err)
+	assert.True(t, report.Valid)
+	assert.Equal(t, retrieve.MockChainHead, report.Head)
+
+	r = httptest.NewRequest("GET", "/audit/chain/verify?head=abc", nil)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	report = ChainReport{}
+	err = json.NewDecoder(resp.Body).Decode(&report)
+	assert.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, 1, len(report.Problems))
+}
+
+func TestVoterProfile(t *testing.T) {
+	body := `{"name": "Miguel", "email": "mad32@drexel.edu", "date_of_birth": "1990-05-01",
+		"residential_address": {"street": "3141 Chestnut St", "city": "Philadelphia", "state": "PA", "postal_code": "19104"},
+		"jurisdiction": "Philadelphia County"}`
+
+	r := httptest.NewRequest("POST", "/voters/1", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 201, resp.StatusCode)
+
+	r = httptest.NewRequest("PUT", "/voters/1", strings.NewReader(strings.Replace(body, "1990-05-01", "2020-05-01", 1)))
+	r.Header.Set("Content-Type", "application/json")
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 422, resp.StatusCode)
+
+	r = httptest.NewRequest("GET", "/voters/1", nil)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var voter Voter
+	err := json.NewDecoder(resp.Body).Decode(&voter)
+	assert.NoError(t, err)
+	assert.Equal(t, "2000-01-01", voter.DateOfBirth)
+	assert.Equal(t, &Address{Street: "1 Main St", City: "Philadelphia", State: "PA", PostalCode: "19104"}, voter.ResidentialAddress)
+	assert.Nil(t, voter.MailingAddress)
+	assert.Equal(t, "Philadelphia County", voter.Jurisdiction)
+}
+
+func TestProblemDetails(t *testing.T) {
+	tests := []struct {
+		method string
+		target string
+		body   string
+		status int
+		code   string
+	}{
+		{"GET", "/voters/abc", "", 400, "invalid_parameter"},
+		{"POST", "/voters/0", `{"name": "Miguel", "email": "mad32@drexel.edu"}`, 400, "invalid_id"},
+		{"POST", "/voters/1", `{"name": "Miguel",`, 400, "invalid_body"},
+		{"POST", "/voters/1", `{"name": "Miguel", "email": "` + process.MockTakenEmail + `"}`, 409, "email_taken"},
+		{"GET", "/polls/1/ballots/NOPE", "", 404, "unknown_ballot"},
+		{"DELETE", "/voters/1/polls/99", "", 404, "history_not_found"},
+		{"GET", "/nowhere", "", 404, "not_found"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.target, strings.NewReader(test.body))
+		r.Header.Set("Content-Type", "application/json")
+		resp, _ := testHandler.Test(r, -1)
+		assert.Equal(t, test.status, resp.StatusCode, test.target)
+		assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"), test.target)
+
+		var problem Problem
+		err := json.NewDecoder(resp.Body).Decode(&problem)
+		assert.NoError(t, err)
+		assert.Equal(t, test.code, problem.Code, test.target)
+		assert.Equal(t, test.status, problem.Status, test.target)
+		assert.Equal(t, test.target, problem.Instance)
+	}
+}
+
+func TestInternalProblemDetail(t *testing.T) {
+	for _, err := range []error{
+		errors.New("failed to write to /srv/voters.json"),
+		process.Error{Kind: process.KindInternal, Code: "save_failed"}.WithMessage("failed to write to /srv/voters.json"),
+		process.Error{Code: "untyped"}.WithMessage("no kind"),
+	} {
+		problem := problemFor(err)
+		assert.Equal(t, 500, problem.Status)
+		assert.Equal(t, internalErrorDetail, problem.Detail)
+		assert.NotContains(t, problem.Detail, "/srv/voters.json")
+	}
+
+	//other problems keep their detail
+	problem := problemFor(process.ErrEmailTaken.Error())
+	assert.Equal(t, 409, problem.Status)
+	assert.Equal(t, process.ErrEmailTaken.Error().Error(), problem.Detail)
+}
+
+func TestVoterFields(t *testing.T) {
+	r := httptest.NewRequest("GET", "/voters?fields=id,name,email", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var voters []map[string]any
+	err := json.NewDecoder(resp.Body).Decode(&voters)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(voters))
+	for _, voter := range voters {
+		assert.Equal(t, map[string]any{"id": float64(1), "name": "test", "email": "123@abc.com"}, voter)
+	}
+
+	r = httptest.NewRequest("GET", "/voters/1?fields=name&include=history", nil)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var voter map[string]any
+	err = json.NewDecoder(resp.Body).Decode(&voter)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"name": "test"}, voter)
+
+	for _, target := range []string{"/voters?fields=id,ssn", "/voters?include=polls", "/voters/1?fields=password"} {
+		r = httptest.NewRequest("GET", target, nil)
+		resp, _ = testHandler.Test(r, -1)
+		assert.Equal(t, 400, resp.StatusCode, target)
+	}
+}
+
+func TestSearchVoters(t *testing.T) {
+	r := httptest.NewRequest("GET", "/voters/search?q=tset", nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var matches []VoterMatch
+	err := json.NewDecoder(resp.Body).Decode(&matches)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(matches))
+	assert.Equal(t, "test", matches[0].Voter.Name)
+	assert.Equal(t, 0, matches[0].Distance)
+
+	for target, code := range map[string]string{
+		"/voters/search":                  "invalid_search",
+		"/voters/search?q=test&limit=0x":  "invalid_parameter",
+		"/voters/search?q=test&limit=500": "invalid_limit",
+	} {
+		r = httptest.NewRequest("GET", target, nil)
+		resp, _ = testHandler.Test(r, -1)
+		assert.Equal(t, 400, resp.StatusCode, target)
+
+		var problem Problem
+		err := json.NewDecoder(resp.Body).Decode(&problem)
+		assert.NoError(t, err)
+		assert.Equal(t, code, problem.Code, target)
+	}
+}
+
+func TestFilterVoters(t *testing.T) {
+	r := httptest.NewRequest("GET", "/voters?per_page=2&filter="+url.QueryEscape(`name co "ES" and history pr or id eq 1`), nil)
+	resp, _ := testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "3", resp.Header.Get("X-Total-Count"))
+	assert.Contains(t, resp.Header.Get("Link"), "filter=name+co")
+
+	r = httptest.NewRequest("GET", "/voters/1/polls?filter="+url.QueryEscape(`poll_id eq 2`), nil)
+	resp, _ = testHandler.Test(r, -1)
+	assert.Equal(t, 200, resp.StatusCode)
+
+	var history []VoterHistory
+	err := json.NewDecoder(resp.Body).Decode(&history)
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	for target, detail := range map[string]string{
+		"/voters?filter=" + url.QueryEscape(`name eq "x" and age gt 30`): `filter: unknown attribute at position 17 near "age"`,
+		"/voters/1/polls?filter=" + url.QueryEscape(`poll_id eq "1"`):    `filter: expected a number at position 12 near "\"1\""`,
+	} {
+		r = httptest.NewRequest("GET", target, nil)
+		resp, _ = testHandler.Test(r, -1)
+		assert.Equal(t, 400, resp.StatusCode, target)
+
+		var problem Problem
+		err := json.NewDecoder(resp.Body).Decode(&problem)
+		assert.NoError(t, err)
+		assert.Equal(t, "invalid_filter", problem.Code, target)
+		assert.Equal(t, detail, problem.Detail, target)
+	}
+}
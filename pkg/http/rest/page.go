@@ -0,0 +1,111 @@
+package rest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"drexel.edu/voter-api/pkg/retrieve"
+	"github.com/gofiber/fiber/v2"
+)
+
+// totalCountHeader holds the number of records matching a paged query.
+const totalCountHeader = "X-Total-Count"
+
+// parseVoterQuery reads the query string of GET /voters:
+//
+//	page, per_page             the page to return, 1 and 100 by default
+//	sort                       id, name, created or modified, - for descending
+//	name                       names starting with this, ignoring case
+//	email_domain               emails at this domain, ignoring case
+//	created_after, _before     RFC 3339 times, after is inclusive
+//	modified_after, _before    the same for the last change
+//	voted_in                   voters with history for this poll id
+//	filter                     a filter expression, see retrieve.Filter
+func parseVoterQuery(c *fiber.Ctx) (retrieve.VoterQuery, error) {
+	query := retrieve.VoterQuery{
+		IncludeDeleted: c.QueryBool("include_deleted"),
+		NamePrefix:     c.Query("name"),
+		EmailDomain:    c.Query("email_domain"),
+		Sort:           c.Query("sort"),
+		Filter:         c.Query("filter"),
+	}
+
+	var err error
+
+	for name, value := range map[string]*int{"page": &query.Page, "per_page": &query.PerPage, "voted_in": &query.VotedInPoll} {
+		if *value, err = optionalInt(c.Query(name)); err != nil {
+			return retrieve.VoterQuery{}, err
+		}
+	}
+
+	if query.CreatedAfter, err = parseOptionalTime(c.Query("created_after")); err != nil {
+		return retrieve.VoterQuery{}, err
+	}
+	if query.CreatedBefore, err = parseOptionalTime(c.Query("created_before")); err != nil {
+		return retrieve.VoterQuery{}, err
+	}
+	if query.ModifiedAfter, err = parseOptionalTime(c.Query("modified_after")); err != nil {
+		return retrieve.VoterQuery{}, err
+	}
+	if query.ModifiedBefore, err = parseOptionalTime(c.Query("modified_before")); err != nil {
+		return retrieve.VoterQuery{}, err
+	}
+
+	return query, nil
+}
+
+// setPageHeaders sets the total count and the RFC 8288 Link header with the
+// first, prev, next and last pages. The links keep the rest of the query
+// string as it was sent.
+func setPageHeaders(c *fiber.Ctx, query retrieve.VoterQuery, total int) {
+	c.Set(totalCountHeader, strconv.Itoa(total))
+
+	page := query.Page
+	if page == 0 {
+		page = 1
+	}
+
+	perPage := query.PerPage
+	if perPage == 0 {
+		perPage = retrieve.DefaultPerPage
+	}
+
+	last := (total + perPage - 1) / perPage
+	if last < 1 {
+		last = 1
+	}
+
+	links := []string{pageLink(c, 1, perPage, "first")}
+
+	if page > 1 {
+		links = append(links, pageLink(c, min(page-1, last), perPage, "prev"))
+	}
+	if page < last {
+		links = append(links, pageLink(c, page+1, perPage, "next"))
+	}
+
+	links = append(links, pageLink(c, last, perPage, "last"))
+
+	c.Set(fiber.HeaderLink, strings.Join(links, ", "))
+}
+
+func pageLink(c *fiber.Ctx, page int, perPage int, rel string) string {
+	args := fiber.AcquireArgs()
+	defer fiber.ReleaseArgs(args)
+
+	c.Request().URI().QueryArgs().CopyTo(args)
+	args.Set("page", strconv.Itoa(page))
+	args.Set("per_page", strconv.Itoa(perPage))
+
+	return fmt.Sprintf(`<%s%s?%s>; rel="%s"`, c.BaseURL(), c.Path(), args.QueryString(), rel)
+}
+
+// optionalInt reads an integer that may be left out as 0.
+func optionalInt(value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.Atoi(value)
+}
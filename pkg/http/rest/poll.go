@@ -0,0 +1,175 @@
+package rest
+
+import (
+	"time"
+
+	"drexel.edu/voter-api/pkg/retrieve"
+)
+
+type Poll struct {
+	Id          int      `json:"id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	OpensAt     string   `json:"opens_at,omitempty"`
+	ClosesAt    string   `json:"closes_at,omitempty"`
+	Status      string   `json:"status"`
+	Options     []string `json:"options,omitempty"`
+	Ranked      bool     `json:"ranked,omitempty"`
+	Secret      bool     `json:"secret,omitempty"`
+	Created     string   `json:"created"`
+	Modified    string   `json:"modified"`
+}
+
+// PollWindow is the body of PUT /polls/:id/window.
+type PollWindow struct {
+	OpensAt  string `json:"opens_at"`
+	ClosesAt string `json:"closes_at"`
+}
+
+// PollResults is the body of GET /polls/:id/results. Percentages are shares
+// of the votes cast, turnout is the votes cast as a share of the registered
+// voters.
+type PollResults struct {
+	PollId           int            `json:"poll_id"`
+	Options          []OptionResult `json:"options"`
+	VotesCast        int            `json:"votes_cast"`
+	NoChoice         int            `json:"no_choice"`
+	RegisteredVoters int            `json:"registered_voters"`
+	Turnout          float64        `json:"turnout"`
+}
+
+type OptionResult struct {
+	Option     string  `json:"option"`
+	Votes      int     `json:"votes"`
+	Percentage float64 `json:"percentage"`
+}
+
+// RunoffResults is the body of GET /polls/:id/results?method=irv. Each round
+// lists the options still in the count, the winner is blank if every ballot
+// was exhausted first.
+type RunoffResults struct {
+	PollId           int     `json:"poll_id"`
+	Method           string  `json:"method"`
+	Winner           string  `json:"winner"`
+	Rounds           []Round `json:"rounds"`
+	Ballots          int     `json:"ballots"`
+	RegisteredVoters int     `json:"registered_voters"`
+	Turnout          float64 `json:"turnout"`
+}
+
+// BallotReceipt is the body returned when a ballot is cast in a secret poll.
+// The receipt is the only way back to the ballot.
+type BallotReceipt struct {
+	Receipt string `json:"receipt"`
+}
+
+// Ballot is the body of GET /polls/:id/ballots/:receipt.
+type Ballot struct {
+	PollId  int      `json:"poll_id"`
+	Receipt string   `json:"receipt"`
+	Choice  string   `json:"choice,omitempty"`
+	Ranking []string `json:"ranking,omitempty"`
+}
+
+type Round struct {
+	Round      int            `json:"round"`
+	Tallies    []OptionResult `json:"tallies"`
+	Exhausted  int            `json:"exhausted"`
+	Eliminated string         `json:"eliminated,omitempty"`
+}
+
+func convertPollToMuteable(pollDTO retrieve.PollDTO) Poll {
+	poll := Poll{
+		Id:          pollDTO.GetId(),
+		Title:       pollDTO.GetTitle(),
+		Description: pollDTO.GetDescription(),
+		Status:      pollDTO.GetStatus(),
+		Options:     pollDTO.GetOptions(),
+		Ranked:      pollDTO.IsRanked(),
+		Secret:      pollDTO.IsSecret(),
+		Created:     pollDTO.GetCreated().Format(time.RFC3339),
+		Modified:    pollDTO.GetModified().Format(time.RFC3339),
+	}
+
+	if !pollDTO.GetOpensAt().IsZero() {
+		poll.OpensAt = pollDTO.GetOpensAt().Format(time.RFC3339)
+	}
+
+	if !pollDTO.GetClosesAt().IsZero() {
+		poll.ClosesAt = pollDTO.GetClosesAt().Format(time.RFC3339)
+	}
+
+	return poll
+}
+
+func convertResultsToMuteable(resultsDTO retrieve.ResultsDTO) PollResults {
+	results := PollResults{
+		PollId:           resultsDTO.GetPollId(),
+		Options:          []OptionResult{},
+		VotesCast:        resultsDTO.GetVotesCast(),
+		NoChoice:         resultsDTO.GetNoChoice(),
+		RegisteredVoters: resultsDTO.GetRegisteredVoters(),
+		Turnout:          resultsDTO.GetTurnout(),
+	}
+
+	for _, item := range resultsDTO.GetOptions() {
+		results.Options = append(results.Options, convertOptionResultToMuteable(item))
+	}
+
+	return results
+}
+
+func convertRunoffResultsToMuteable(resultsDTO retrieve.RunoffResultsDTO) RunoffResults {
+	results := RunoffResults{
+		PollId:           resultsDTO.GetPollId(),
+		Method:           "irv",
+		Winner:           resultsDTO.GetWinner(),
+		Rounds:           []Round{},
+		Ballots:          resultsDTO.GetBallots(),
+		RegisteredVoters: resultsDTO.GetRegisteredVoters(),
+		Turnout:          resultsDTO.GetTurnout(),
+	}
+
+	for _, roundDTO := range resultsDTO.GetRounds() {
+		round := Round{
+			Round:      roundDTO.GetRound(),
+			Tallies:    []OptionResult{},
+			Exhausted:  roundDTO.GetExhausted(),
+			Eliminated: roundDTO.GetEliminated(),
+		}
+
+		for _, item := range roundDTO.GetTallies() {
+			round.Tallies = append(round.Tallies, convertOptionResultToMuteable(item))
+		}
+
+		results.Rounds = append(results.Rounds, round)
+	}
+
+	return results
+}
+
+func convertOptionResultToMuteable(resultDTO retrieve.OptionResultDTO) OptionResult {
+	return OptionResult{
+		Option:     resultDTO.GetOption(),
+		Votes:      resultDTO.GetVotes(),
+		Percentage: resultDTO.GetPercentage(),
+	}
+}
+
+func convertBallotToMuteable(ballotDTO retrieve.BallotDTO) Ballot {
+	return Ballot{
+		PollId:  ballotDTO.GetPollId(),
+		Receipt: ballotDTO.GetReceipt(),
+		Choice:  ballotDTO.GetChoice(),
+		Ranking: ballotDTO.GetRanking(),
+	}
+}
+
+// parseOptionalTime reads an RFC 3339 time that may be left out.
+func parseOptionalTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
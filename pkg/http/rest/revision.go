@@ -0,0 +1,15 @@
+package rest
+
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+type Revision struct {
+	Revision int      `json:"revision"`
+	Created  string   `json:"created"`
+	Action   string   `json:"action"`
+	Changes  []Change `json:"changes"`
+	Voter    *Voter   `json:"voter,omitempty"`
+}
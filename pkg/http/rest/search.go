@@ -0,0 +1,8 @@
+package rest
+
+// VoterMatch is a voter found by GET /voters/search, with the edit distance
+// between its name and the search, 0 for an exact match.
+type VoterMatch struct {
+	Distance int   `json:"distance"`
+	Voter    Voter `json:"voter"`
+}
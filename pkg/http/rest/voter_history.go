@@ -1,9 +1,15 @@
 package rest
 
 type VoterHistory struct {
-	PollId   int    `json:"poll_id"`
-	VoteId   int    `json:"vote_id"`
-	VoteDate string `json:"vote_date"`
-	Created  string `json:"created"`
-	Modified string `json:"modified"`
+	PollId   int      `json:"poll_id"`
+	VoteId   int      `json:"vote_id"`
+	VoteDate string   `json:"vote_date"`
+	Choice   string   `json:"choice,omitempty"`
+	Ranking  []string `json:"ranking,omitempty"`
+	Created  string   `json:"created"`
+	Modified string   `json:"modified"`
+
+	Deleted      string `json:"deleted,omitempty"`
+	DeleteReason string `json:"delete_reason,omitempty"`
+	Version      int    `json:"version,omitempty"`
 }
@@ -0,0 +1,53 @@
+package process
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+)
+
+// BallotDTO is the choice made in a secret poll. It is kept apart from the
+// voter history and carries nothing that identifies the voter, only the
+// receipt the voter was given when it was cast.
+type BallotDTO struct {
+	pollId  int
+	receipt string
+	choice  string
+	ranking []string
+}
+
+func NewBallotDTO(pollId int, receipt string, choice string, ranking []string) BallotDTO {
+	return BallotDTO{
+		pollId:  pollId,
+		receipt: receipt,
+		choice:  choice,
+		ranking: ranking,
+	}
+}
+
+func (b *BallotDTO) GetPollId() int {
+	return b.pollId
+}
+
+func (b *BallotDTO) GetReceipt() string {
+	return b.receipt
+}
+
+func (b *BallotDTO) GetChoice() string {
+	return b.choice
+}
+
+func (b *BallotDTO) GetRanking() []string {
+	return b.ranking
+}
+
+// newReceipt returns a random code for a voter to look their ballot up
+// with. It is 80 random bits so receipts cannot be guessed.
+func newReceipt() (string, error) {
+	data := make([]byte, 10)
+
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+
+	return base32.StdEncoding.EncodeToString(data), nil
+}
@@ -1,7 +1,5 @@
 package process
 
-import "errors"
-
 type processServiceError string
 
 const (
@@ -9,8 +7,77 @@ const (
 	ErrInvalidName  processServiceError = "name must not be blank"
 	ErrInvalidEmail processServiceError = "email must be in the format of <adddress>@<domain> "
 	ErrInvalidDate  processServiceError = "date must not be nil"
+
+	ErrInvalidDateOfBirth processServiceError = "date_of_birth must not be in the future."
+	ErrUnderage           processServiceError = "the voter must be 18 by the next election."
+	ErrInvalidAddress     processServiceError = "an address must have a street and city"
+	ErrInvalidState       processServiceError = "state must be a two letter code"
+	ErrInvalidPostalCode  processServiceError = "postal_code must be a five digit ZIP code or ZIP+4"
+
+	ErrInvalidVersion  processServiceError = "version must not be negative."
+	ErrVersionMismatch processServiceError = "the record has been changed since the expected version was read."
+	ErrEmailTaken      processServiceError = "email is already registered to another voter."
+
+	ErrInvalidTitle      processServiceError = "title must not be blank"
+	ErrInvalidPollStatus processServiceError = "status must be one of draft, open or closed"
+	ErrInvalidPollWindow processServiceError = "closes_at must be after opens_at"
+	ErrUnknownPoll       processServiceError = "the poll does not exist."
+	ErrPollInUse         processServiceError = "the poll has voter history and cannot be deleted."
+	ErrPollHasVotes      processServiceError = "the poll has votes, so its options, ranked and secret cannot be changed."
+
+	ErrPollNotOpen           processServiceError = "the poll is not open yet."
+	ErrPollClosed            processServiceError = "the poll is closed."
+	ErrVoteDateOutsideWindow processServiceError = "vote_date must be between the poll's opens_at and closes_at."
+
+	ErrInvalidPollOptions processServiceError = "options must not be blank or repeated"
+	ErrInvalidChoice      processServiceError = "choice must be one of the poll's options."
+	ErrInvalidRankedPoll  processServiceError = "a ranked-choice poll must have at least two options"
+	ErrInvalidRanking     processServiceError = "ranking must list the options of a ranked-choice poll, each at most once."
+
+	ErrSecretBallotCast processServiceError = "a secret ballot cannot be changed once it has been cast."
+	ErrUnknownBallot    processServiceError = "no ballot was cast with that receipt."
+
+	ErrChainDisabled processServiceError = "the audit chain is not enabled for this database."
 )
 
+var processServiceErrors = map[processServiceError]Error{
+	ErrInvalidId:    {Kind: KindInvalid, Code: "invalid_id"},
+	ErrInvalidName:  {Kind: KindInvalid, Code: "invalid_name"},
+	ErrInvalidEmail: {Kind: KindInvalid, Code: "invalid_email"},
+	ErrInvalidDate:  {Kind: KindInvalid, Code: "invalid_date"},
+
+	ErrInvalidDateOfBirth: {Kind: KindInvalid, Code: "invalid_date_of_birth"},
+	ErrUnderage:           {Kind: KindUnprocessable, Code: "underage"},
+	ErrInvalidAddress:     {Kind: KindInvalid, Code: "invalid_address"},
+	ErrInvalidState:       {Kind: KindInvalid, Code: "invalid_state"},
+	ErrInvalidPostalCode:  {Kind: KindInvalid, Code: "invalid_postal_code"},
+
+	ErrInvalidVersion:  {Kind: KindInvalid, Code: "invalid_version"},
+	ErrVersionMismatch: {Kind: KindPreconditionFailed, Code: "version_mismatch"},
+	ErrEmailTaken:      {Kind: KindConflict, Code: "email_taken"},
+
+	ErrInvalidTitle:      {Kind: KindInvalid, Code: "invalid_title"},
+	ErrInvalidPollStatus: {Kind: KindInvalid, Code: "invalid_poll_status"},
+	ErrInvalidPollWindow: {Kind: KindInvalid, Code: "invalid_poll_window"},
+	ErrUnknownPoll:       {Kind: KindNotFound, Code: "unknown_poll"},
+	ErrPollInUse:         {Kind: KindConflict, Code: "poll_in_use"},
+	ErrPollHasVotes:      {Kind: KindConflict, Code: "poll_has_votes"},
+
+	ErrPollNotOpen:           {Kind: KindUnprocessable, Code: "poll_not_open"},
+	ErrPollClosed:            {Kind: KindUnprocessable, Code: "poll_closed"},
+	ErrVoteDateOutsideWindow: {Kind: KindUnprocessable, Code: "vote_date_outside_window"},
+
+	ErrInvalidPollOptions: {Kind: KindInvalid, Code: "invalid_poll_options"},
+	ErrInvalidChoice:      {Kind: KindUnprocessable, Code: "invalid_choice"},
+	ErrInvalidRankedPoll:  {Kind: KindInvalid, Code: "invalid_ranked_poll"},
+	ErrInvalidRanking:     {Kind: KindUnprocessable, Code: "invalid_ranking"},
+
+	ErrSecretBallotCast: {Kind: KindUnprocessable, Code: "secret_ballot_cast"},
+	ErrUnknownBallot:    {Kind: KindNotFound, Code: "unknown_ballot"},
+
+	ErrChainDisabled: {Kind: KindNotFound, Code: "chain_disabled"},
+}
+
 func (e processServiceError) Error() error {
-	return errors.New(string(e))
+	return processServiceErrors[e].WithMessage(string(e))
 }
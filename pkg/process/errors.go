@@ -0,0 +1,49 @@
+package process
+
+// ErrorKind says what went wrong in terms a client can act on. The REST layer
+// picks the status code from the kind, so a new error only needs a kind to
+// be reported correctly.
+type ErrorKind string
+
+const (
+	// KindInternal is also used for an Error without a kind.
+	KindInternal           ErrorKind = "internal"
+	KindInvalid            ErrorKind = "invalid"
+	KindNotFound           ErrorKind = "not_found"
+	KindConflict           ErrorKind = "conflict"
+	KindPreconditionFailed ErrorKind = "precondition_failed"
+	KindUnprocessable      ErrorKind = "unprocessable"
+)
+
+// Error is what the Error method of the error constants in the process,
+// retrieve and storage packages returns. Code is stable and machine readable
+// so clients do not have to match the message, which may change.
+//
+// Errors are compared by code, so errors.Is(err, ErrUnknownPoll.Error())
+// holds for an err with the same code however it was wrapped, and
+// errors.As gives the kind and code of any of them.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	other, ok := target.(*Error)
+	return ok && other.Code == e.Code
+}
+
+// WithMessage returns a copy of the error with the given message. It is how
+// the error constants turn their kind and code into an error.
+func (e Error) WithMessage(message string) *Error {
+	if e.Kind == "" {
+		e.Kind = KindInternal
+	}
+
+	e.Message = message
+	return &e
+}
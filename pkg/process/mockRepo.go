@@ -8,6 +8,44 @@ import (
 
 type MockRepository struct{}
 
+// MockVersion is the version every mocked record is at.
+const MockVersion = 1
+
+// MockTakenEmail is already registered to a voter as far as the mock is
+// concerned.
+const MockTakenEmail = "taken@example.com"
+
+// MockUnknownPollId is the one poll id the mock has no poll for, nor any
+// voter history.
+const MockUnknownPollId = 99
+
+// errMockHistoryNotFound is what the repositories return for history that
+// does not exist.
+var errMockHistoryNotFound = Error{Kind: KindNotFound, Code: "history_not_found"}.WithMessage("The History Id for the Voter was not found")
+
+// MockScheduledPollId is open from MockOpensAt to MockClosesAt,
+// MockClosedPollId is closed, MockBallotPollId has MockOptions to choose
+// from, MockRankedPollId has MockRankedOptions to rank and MockSecretPollId
+// is a secret ballot of MockOptions. Every other poll is open with no window
+// and no options.
+const (
+	MockScheduledPollId = 98
+	MockClosedPollId    = 97
+	MockBallotPollId    = 96
+	MockRankedPollId    = 95
+	MockSecretPollId    = 94
+)
+
+var (
+	MockOptions       = []string{"yes", "no"}
+	MockRankedOptions = []string{"alice", "bob", "carol"}
+)
+
+var (
+	MockOpensAt  = time.Date(2024, time.November, 5, 6, 0, 0, 0, time.UTC)
+	MockClosesAt = time.Date(2024, time.November, 5, 20, 0, 0, 0, time.UTC)
+)
+
 var SampleValidrequest = NewVoterDTO(
 	fake.IntRange(1, 10),
 	fake.Name(),
@@ -74,26 +112,141 @@ var SampleValidVoterHistory = NewVoterHistoryDTO(
 	fake.Date(),
 )
 
+var SampleValidPoll = NewPollDTO(
+	fake.IntRange(1, 10),
+	fake.Sentence(3),
+	fake.Sentence(10),
+	time.Date(2024, time.November, 5, 6, 0, 0, 0, time.UTC),
+	time.Date(2024, time.November, 5, 20, 0, 0, 0, time.UTC),
+	PollOpen,
+)
+
 func (m *MockRepository) CreateVoter(voter VoterDTO) error {
+	return mockEmailCheck(voter)
+}
+
+func (m *MockRepository) UpdateVoterInfo(updatedVoter VoterDTO, expectedVersion int) error {
+	if err := mockEmailCheck(updatedVoter); err != nil {
+		return err
+	}
+	return mockVersionCheck(expectedVersion)
+}
+
+func (m *MockRepository) DeleteSingleVoter(id int, reason string, expectedVersion int) error {
+	return mockVersionCheck(expectedVersion)
+}
+
+func (m *MockRepository) RestoreVoter(id int) error {
 	return nil
 }
 
-func (m *MockRepository) UpdateVoterInfo(updatedVoter VoterDTO) error {
+func (m *MockRepository) CreateVoterHistory(voterId int, pollId int, history VoterHistoryDTO) error {
+	if pollId == MockUnknownPollId {
+		return ErrUnknownPoll.Error()
+	}
 	return nil
 }
 
-func (m *MockRepository) DeleteSingleVoter(id int) error {
+// CastSecretBallot refuses history that still has the choice on it, and a
+// ballot without a receipt, so tests notice if either is ever stored.
+func (m *MockRepository) CastSecretBallot(voterId int, pollId int, history VoterHistoryDTO, ballot BallotDTO) error {
+	if history.choice != "" || len(history.ranking) > 0 || ballot.receipt == "" {
+		return ErrSecretBallotCast.Error()
+	}
 	return nil
 }
 
-func (m *MockRepository) CreateVoterHistory(voterId int, pollId int, history VoterHistoryDTO) error {
+func (m *MockRepository) UpdateVoterHistoryInfo(voterId int, pollId int, history VoterHistoryDTO, expectedVersion int) error {
+	return mockVersionCheck(expectedVersion)
+}
+
+func (m *MockRepository) DeleteSingleVoterPoll(voterId int, pollId int, reason string, expectedVersion int) error {
+	if pollId == MockUnknownPollId {
+		return errMockHistoryNotFound
+	}
+	return mockVersionCheck(expectedVersion)
+}
+
+func (m *MockRepository) RestoreVoterPoll(voterId int, pollId int) error {
 	return nil
 }
 
-func (m *MockRepository) UpdateVoterHistoryInfo(voterId int, pollId int, history VoterHistoryDTO) error {
+func (m *MockRepository) RevertVoter(voterId int, revision int) error {
 	return nil
 }
 
-func (m *MockRepository) DeleteSingleVoterPoll(voterId int, pollId int) error {
+// GetVoterPoll returns a vote that is valid for every mocked poll that is
+// open.
+func (m *MockRepository) GetVoterPoll(voterId int, pollId int) (VoterHistoryDTO, error) {
+	history := NewVoterHistoryDTO(pollId, 1, MockOpensAt.Add(time.Hour))
+
+	switch pollId {
+	case MockUnknownPollId:
+		return VoterHistoryDTO{}, errMockHistoryNotFound
+	case MockBallotPollId:
+		history = history.WithChoice(MockOptions[0])
+	case MockRankedPollId:
+		history = history.WithRanking(MockRankedOptions)
+	}
+
+	return history, nil
+}
+
+// GetRevertedHistory brings back the vote in the poll with the same id as the
+// revision.
+func (m *MockRepository) GetRevertedHistory(voterId int, revision int) ([]VoterHistoryDTO, error) {
+	history, err := m.GetVoterPoll(voterId, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return []VoterHistoryDTO{history}, nil
+}
+
+func mockVersionCheck(expectedVersion int) error {
+	if expectedVersion != AnyVersion && expectedVersion != MockVersion {
+		return ErrVersionMismatch.Error()
+	}
 	return nil
 }
+
+func mockEmailCheck(voter VoterDTO) error {
+	if EmailKey(voter.GetEmail()) == MockTakenEmail {
+		return ErrEmailTaken.Error()
+	}
+	return nil
+}
+
+func (m *MockRepository) CreatePoll(poll PollDTO) error {
+	return nil
+}
+
+func (m *MockRepository) UpdatePoll(poll PollDTO) error {
+	return nil
+}
+
+func (m *MockRepository) DeletePoll(id int) error {
+	return nil
+}
+
+func (m *MockRepository) SetPollWindow(id int, opensAt time.Time, closesAt time.Time) error {
+	return nil
+}
+
+func (m *MockRepository) GetPoll(id int) (PollDTO, error) {
+	switch id {
+	case MockUnknownPollId:
+		return PollDTO{}, ErrUnknownPoll.Error()
+	case MockScheduledPollId:
+		return NewPollDTO(id, "scheduled", "", MockOpensAt, MockClosesAt, PollOpen), nil
+	case MockClosedPollId:
+		return NewPollDTO(id, "closed", "", time.Time{}, time.Time{}, PollClosed), nil
+	case MockBallotPollId:
+		return NewPollDTO(id, "ballot", "", time.Time{}, time.Time{}, PollOpen).WithOptions(MockOptions), nil
+	case MockRankedPollId:
+		return NewPollDTO(id, "ranked", "", time.Time{}, time.Time{}, PollOpen).WithOptions(MockRankedOptions).WithRanked(true), nil
+	case MockSecretPollId:
+		return NewPollDTO(id, "secret", "", time.Time{}, time.Time{}, PollOpen).WithOptions(MockOptions).WithSecret(true), nil
+	}
+	return NewPollDTO(id, "open", "", time.Time{}, time.Time{}, PollOpen), nil
+}
@@ -0,0 +1,112 @@
+package process
+
+import (
+	"slices"
+	"time"
+)
+
+// A poll moves from draft to open to closed. A poll without a status is
+// created as a draft.
+const (
+	PollDraft  = "draft"
+	PollOpen   = "open"
+	PollClosed = "closed"
+)
+
+type PollDTO struct {
+	id          int
+	title       string
+	description string
+	opensAt     time.Time
+	closesAt    time.Time
+	status      string
+	options     []string
+	ranked      bool
+	secret      bool
+}
+
+// NewPollDTO creates a poll. opensAt and closesAt may be left as the zero
+// time when the poll has not been scheduled yet.
+func NewPollDTO(id int, title string, description string, opensAt time.Time, closesAt time.Time, status string) PollDTO {
+	return PollDTO{
+		id:          id,
+		title:       title,
+		description: description,
+		opensAt:     opensAt,
+		closesAt:    closesAt,
+		status:      status,
+	}
+}
+
+func (p *PollDTO) GetId() int {
+	return p.id
+}
+
+func (p *PollDTO) GetTitle() string {
+	return p.title
+}
+
+func (p *PollDTO) GetDescription() string {
+	return p.description
+}
+
+func (p *PollDTO) GetOpensAt() time.Time {
+	return p.opensAt
+}
+
+func (p *PollDTO) GetClosesAt() time.Time {
+	return p.closesAt
+}
+
+func (p *PollDTO) GetStatus() string {
+	return p.status
+}
+
+// WithOptions returns a copy of the poll with the options a voter can choose
+// from. A poll without options only records that a voter took part.
+func (p PollDTO) WithOptions(options []string) PollDTO {
+	p.options = options
+	return p
+}
+
+func (p *PollDTO) GetOptions() []string {
+	return p.options
+}
+
+// WithRanked returns a copy of the poll set to be ranked-choice. Voters then
+// rank the options instead of choosing one.
+func (p PollDTO) WithRanked(ranked bool) PollDTO {
+	p.ranked = ranked
+	return p
+}
+
+func (p *PollDTO) IsRanked() bool {
+	return p.ranked
+}
+
+// WithSecret returns a copy of the poll set to be a secret ballot. The
+// history then only records that a voter took part, and what they chose is
+// kept in a ballot that cannot be traced back to them.
+func (p PollDTO) WithSecret(secret bool) PollDTO {
+	p.secret = secret
+	return p
+}
+
+func (p *PollDTO) IsSecret() bool {
+	return p.secret
+}
+
+// SameBallot reports whether a poll with the given options, ranked and secret
+// takes the same votes as p. The options may be in another order.
+func (p *PollDTO) SameBallot(options []string, ranked bool, secret bool) bool {
+	if p.ranked != ranked || p.secret != secret || len(p.options) != len(options) {
+		return false
+	}
+
+	before := slices.Clone(options)
+	after := slices.Clone(p.options)
+	slices.Sort(before)
+	slices.Sort(after)
+
+	return slices.Equal(before, after)
+}
@@ -0,0 +1,152 @@
+package process
+
+import (
+	"strings"
+	"time"
+)
+
+type PollService interface {
+	CreatePoll(poll PollDTO) error
+	UpdatePoll(poll PollDTO) error
+	DeletePoll(id int) error
+	SetPollWindow(id int, opensAt time.Time, closesAt time.Time) error
+}
+
+// PollRepository implementations refuse to delete a poll that any voter
+// history, deleted or not, refers to and return ErrPollInUse instead. Once
+// such history or a secret ballot exists UpdatePoll may still change the
+// title, description, window and status, but returns ErrPollHasVotes for any
+// change to the options, ranked or secret, see PollDTO.SameBallot.
+// Repository.CreateVoterHistory returns ErrUnknownPoll for a poll id that
+// has no poll.
+type PollRepository interface {
+	CreatePoll(poll PollDTO) error
+	UpdatePoll(poll PollDTO) error
+	DeletePoll(id int) error
+	SetPollWindow(id int, opensAt time.Time, closesAt time.Time) error
+}
+
+type pollService struct {
+	r PollRepository
+}
+
+func NewPollService(r PollRepository) PollService {
+	return &pollService{r}
+}
+
+func (s *pollService) CreatePoll(poll PollDTO) error {
+
+	poll, err := s.validatePoll(poll)
+	if err != nil {
+		return err
+	}
+
+	err = s.r.CreatePoll(poll)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *pollService) UpdatePoll(poll PollDTO) error {
+
+	poll, err := s.validatePoll(poll)
+	if err != nil {
+		return err
+	}
+
+	err = s.r.UpdatePoll(poll)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *pollService) DeletePoll(id int) error {
+
+	if id < 1 {
+		return ErrInvalidId.Error()
+	}
+
+	err := s.r.DeletePoll(id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetPollWindow changes only the times the poll opens and closes. Either may
+// be the zero time to leave that end of the window open.
+func (s *pollService) SetPollWindow(id int, opensAt time.Time, closesAt time.Time) error {
+
+	if id < 1 {
+		return ErrInvalidId.Error()
+	}
+
+	if !validWindow(opensAt, closesAt) {
+		return ErrInvalidPollWindow.Error()
+	}
+
+	err := s.r.SetPollWindow(id, opensAt, closesAt)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsValidPollStatus reports whether status is one of PollDraft, PollOpen or
+// PollClosed.
+func IsValidPollStatus(status string) bool {
+	switch status {
+	case PollDraft, PollOpen, PollClosed:
+		return true
+	}
+
+	return false
+}
+
+// validatePoll checks the poll and returns it with a blank status set to
+// PollDraft.
+func (s *pollService) validatePoll(poll PollDTO) (PollDTO, error) {
+	if poll.id < 1 {
+		return PollDTO{}, ErrInvalidId.Error()
+	}
+	if isInvalidString(poll.title) {
+		return PollDTO{}, ErrInvalidTitle.Error()
+	}
+
+	poll.status = strings.ToLower(strings.TrimSpace(poll.status))
+	if poll.status == "" {
+		poll.status = PollDraft
+	}
+
+	if !IsValidPollStatus(poll.status) {
+		return PollDTO{}, ErrInvalidPollStatus.Error()
+	}
+
+	if !validWindow(poll.opensAt, poll.closesAt) {
+		return PollDTO{}, ErrInvalidPollWindow.Error()
+	}
+
+	seen := make(map[string]bool, len(poll.options))
+	for _, option := range poll.options {
+		if isInvalidString(option) || seen[option] {
+			return PollDTO{}, ErrInvalidPollOptions.Error()
+		}
+		seen[option] = true
+	}
+
+	if poll.ranked && len(poll.options) < 2 {
+		return PollDTO{}, ErrInvalidRankedPoll.Error()
+	}
+
+	return poll, nil
+}
+
+func validWindow(opensAt time.Time, closesAt time.Time) bool {
+	return opensAt.IsZero() || closesAt.IsZero() || closesAt.After(opensAt)
+}
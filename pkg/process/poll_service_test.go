@@ -0,0 +1,91 @@
+package process
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPollService PollService
+
+func init() {
+	testPollService = NewPollService(&MockRepository{})
+}
+
+func TestInvalidRequestFailuresCreatePoll(t *testing.T) {
+	opensAt := time.Date(2024, time.November, 5, 6, 0, 0, 0, time.UTC)
+
+	err := testPollService.CreatePoll(NewPollDTO(0, "General", "", time.Time{}, time.Time{}, ""))
+	assert.Equal(t, ErrInvalidId.Error(), err)
+
+	err = testPollService.CreatePoll(NewPollDTO(1, " ", "", time.Time{}, time.Time{}, ""))
+	assert.Equal(t, ErrInvalidTitle.Error(), err)
+
+	err = testPollService.CreatePoll(NewPollDTO(1, "General", "", time.Time{}, time.Time{}, "counting"))
+	assert.Equal(t, ErrInvalidPollStatus.Error(), err)
+
+	err = testPollService.CreatePoll(NewPollDTO(1, "General", "", opensAt, opensAt, PollOpen))
+	assert.Equal(t, ErrInvalidPollWindow.Error(), err)
+}
+
+func TestValidCreatePoll(t *testing.T) {
+	err := testPollService.CreatePoll(SampleValidPoll)
+	assert.NoError(t, err)
+
+	//an unscheduled poll without a status is a draft
+	err = testPollService.CreatePoll(NewPollDTO(1, "General", "", time.Time{}, time.Time{}, ""))
+	assert.NoError(t, err)
+}
+
+func TestValidUpdatePoll(t *testing.T) {
+	err := testPollService.UpdatePoll(SampleValidPoll)
+	assert.NoError(t, err)
+
+	err = testPollService.UpdatePoll(NewPollDTO(1, "", "", time.Time{}, time.Time{}, PollClosed))
+	assert.Equal(t, ErrInvalidTitle.Error(), err)
+}
+
+func TestDeletePoll(t *testing.T) {
+	err := testPollService.DeletePoll(1)
+	assert.NoError(t, err)
+
+	err = testPollService.DeletePoll(-1)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+}
+
+func TestUnknownPoll(t *testing.T) {
+	_, err := testService.CreateVoterHistory(1, MockUnknownPollId, SampleValidVoterHistory)
+	assert.Equal(t, ErrUnknownPoll.Error(), err)
+}
+
+func TestSetPollWindow(t *testing.T) {
+	err := testPollService.SetPollWindow(1, MockOpensAt, MockClosesAt)
+	assert.NoError(t, err)
+
+	err = testPollService.SetPollWindow(1, time.Time{}, MockClosesAt)
+	assert.NoError(t, err)
+
+	err = testPollService.SetPollWindow(1, MockClosesAt, MockOpensAt)
+	assert.Equal(t, ErrInvalidPollWindow.Error(), err)
+
+	err = testPollService.SetPollWindow(0, MockOpensAt, MockClosesAt)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+}
+
+func TestPollOptions(t *testing.T) {
+	err := testPollService.CreatePoll(SampleValidPoll.WithOptions([]string{"yes", "no"}))
+	assert.NoError(t, err)
+
+	err = testPollService.CreatePoll(SampleValidPoll.WithOptions([]string{"yes", "yes"}))
+	assert.Equal(t, ErrInvalidPollOptions.Error(), err)
+
+	err = testPollService.UpdatePoll(SampleValidPoll.WithOptions([]string{"yes", " "}))
+	assert.Equal(t, ErrInvalidPollOptions.Error(), err)
+
+	err = testPollService.CreatePoll(SampleValidPoll.WithOptions([]string{"alice", "bob"}).WithRanked(true))
+	assert.NoError(t, err)
+
+	err = testPollService.CreatePoll(SampleValidPoll.WithOptions([]string{"alice"}).WithRanked(true))
+	assert.Equal(t, ErrInvalidRankedPoll.Error(), err)
+}
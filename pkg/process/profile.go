@@ -0,0 +1,77 @@
+package process
+
+import (
+	"regexp"
+	"time"
+)
+
+// VotingAge is the age a voter must have reached by the next election.
+const VotingAge = 18
+
+var (
+	postalCodePattern = regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`)
+	statePattern      = regexp.MustCompile(`^[A-Za-z]{2}$`)
+)
+
+// NextElection returns the date of the first general election on or after
+// the given time. General elections are held on the Tuesday after the first
+// Monday in November.
+func NextElection(after time.Time) time.Time {
+	day := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, time.UTC)
+
+	for year := after.Year(); ; year++ {
+		election := electionDay(year)
+		if !election.Before(day) {
+			return election
+		}
+	}
+}
+
+func electionDay(year int) time.Time {
+	first := time.Date(year, time.November, 1, 0, 0, 0, 0, time.UTC)
+
+	// days from the 1st to the first Monday, then one more to the Tuesday
+	offset := (int(time.Monday) - int(first.Weekday()) + 7) % 7
+
+	return first.AddDate(0, 0, offset+1)
+}
+
+// IsValidPostalCode reports whether code is a five digit ZIP code or a ZIP+4.
+func IsValidPostalCode(code string) bool {
+	return postalCodePattern.MatchString(code)
+}
+
+// validateProfile checks the parts of the profile that were given. A voter
+// with a date of birth must be VotingAge by the next election, and an
+// address must have a street, city, two letter state and a postal code.
+func (s *service) validateProfile(voter VoterDTO) error {
+	if !voter.dateOfBirth.IsZero() {
+		now := s.now()
+
+		if voter.dateOfBirth.After(now) {
+			return ErrInvalidDateOfBirth.Error()
+		}
+
+		if voter.dateOfBirth.AddDate(VotingAge, 0, 0).After(NextElection(now)) {
+			return ErrUnderage.Error()
+		}
+	}
+
+	for _, address := range []AddressDTO{voter.residentialAddress, voter.mailingAddress} {
+		if address.IsZero() {
+			continue
+		}
+
+		if isInvalidString(address.street) || isInvalidString(address.city) {
+			return ErrInvalidAddress.Error()
+		}
+		if !statePattern.MatchString(address.state) {
+			return ErrInvalidState.Error()
+		}
+		if !IsValidPostalCode(address.postalCode) {
+			return ErrInvalidPostalCode.Error()
+		}
+	}
+
+	return nil
+}
@@ -6,30 +6,59 @@ import (
 	"time"
 )
 
+// AnyVersion skips the version check on updates and deletes. Otherwise the
+// change is only made if the record is still at the expected version, and
+// the repository returns ErrVersionMismatch if it is not.
+const AnyVersion = 0
+
 type Service interface {
 	CreateVoter(voter VoterDTO) error
-	UpdateVoterInfo(updatedVoter VoterDTO) error
-	DeleteSingleVoter(id int) error
-	CreateVoterHistory(voterId int, pollId int, history VoterHistoryDTO) error
-	UpdateVoterHistoryInfo(voterId int, pollId int, history VoterHistoryDTO) error
-	DeleteSingleVoterPoll(voterId int, pollId int) error
+	UpdateVoterInfo(updatedVoter VoterDTO, expectedVersion int) error
+	DeleteSingleVoter(id int, reason string, expectedVersion int) error
+	RestoreVoter(id int) error
+	CreateVoterHistory(voterId int, pollId int, history VoterHistoryDTO) (string, error)
+	UpdateVoterHistoryInfo(voterId int, pollId int, history VoterHistoryDTO, expectedVersion int) error
+	DeleteSingleVoterPoll(voterId int, pollId int, reason string, expectedVersion int) error
+	RestoreVoterPoll(voterId int, pollId int) error
+	RevertVoter(voterId int, revision int) error
 }
 
+// Repository implementations keep email addresses unique, compared with
+// EmailKey, and return ErrEmailTaken when a create, update or revert would
+// give a voter an address that another voter, deleted or not, already has.
+// GetPoll returns ErrUnknownPoll for a poll id that has no poll.
+//
+// CastSecretBallot records the history and stores the ballot in one change,
+// keeping nothing that links the two.
 type Repository interface {
 	CreateVoter(voter VoterDTO) error
-	UpdateVoterInfo(voter VoterDTO) error
-	DeleteSingleVoter(id int) error
+	UpdateVoterInfo(voter VoterDTO, expectedVersion int) error
+	DeleteSingleVoter(id int, reason string, expectedVersion int) error
+	RestoreVoter(id int) error
 	CreateVoterHistory(voterId int, pollId int, history VoterHistoryDTO) error
-	UpdateVoterHistoryInfo(voterId int, pollId int, history VoterHistoryDTO) error
-	DeleteSingleVoterPoll(voterId int, pollId int) error
+	UpdateVoterHistoryInfo(voterId int, pollId int, history VoterHistoryDTO, expectedVersion int) error
+	DeleteSingleVoterPoll(voterId int, pollId int, reason string, expectedVersion int) error
+	RestoreVoterPoll(voterId int, pollId int) error
+	RevertVoter(voterId int, revision int) error
+	GetPoll(id int) (PollDTO, error)
+
+	// GetVoterPoll returns the voter's history for the poll, deleted or not.
+	GetVoterPoll(voterId int, pollId int) (VoterHistoryDTO, error)
+	// GetRevertedHistory returns the history that reverting the voter to the
+	// revision would bring back or change.
+	GetRevertedHistory(voterId int, revision int) ([]VoterHistoryDTO, error)
+	CastSecretBallot(voterId int, pollId int, history VoterHistoryDTO, ballot BallotDTO) error
 }
 
 type service struct {
 	r Repository
+
+	// now is the clock the poll windows are checked against
+	now func() time.Time
 }
 
 func NewService(r Repository) Service {
-	return &service{r}
+	return &service{r, time.Now}
 }
 
 func (s *service) CreateVoter(voter VoterDTO) error {
@@ -47,14 +76,18 @@ func (s *service) CreateVoter(voter VoterDTO) error {
 	return nil
 }
 
-func (s *service) UpdateVoterInfo(voter VoterDTO) error {
+func (s *service) UpdateVoterInfo(voter VoterDTO, expectedVersion int) error {
 
 	err := s.validateVoter(voter)
 	if err != nil {
 		return err
 	}
 
-	err = s.r.UpdateVoterInfo(voter)
+	if expectedVersion < AnyVersion {
+		return ErrInvalidVersion.Error()
+	}
+
+	err = s.r.UpdateVoterInfo(voter, expectedVersion)
 	if err != nil {
 		return err
 	}
@@ -62,13 +95,19 @@ func (s *service) UpdateVoterInfo(voter VoterDTO) error {
 	return nil
 }
 
-func (s *service) DeleteSingleVoter(id int) error {
+// DeleteSingleVoter marks the voter as deleted. The record is kept so it can
+// be brought back with RestoreVoter.
+func (s *service) DeleteSingleVoter(id int, reason string, expectedVersion int) error {
 
 	if id < 1 {
 		return ErrInvalidId.Error()
 	}
 
-	err := s.r.DeleteSingleVoter(id)
+	if expectedVersion < AnyVersion {
+		return ErrInvalidVersion.Error()
+	}
+
+	err := s.r.DeleteSingleVoter(id, strings.TrimSpace(reason), expectedVersion)
 	if err != nil {
 		return err
 	}
@@ -76,29 +115,100 @@ func (s *service) DeleteSingleVoter(id int) error {
 	return nil
 }
 
-func (s *service) CreateVoterHistory(voterId int, pollId int, history VoterHistoryDTO) error {
+func (s *service) RestoreVoter(id int) error {
 
-	err := s.validateVoterHistory(voterId, pollId, history)
+	if id < 1 {
+		return ErrInvalidId.Error()
+	}
+
+	err := s.r.RestoreVoter(id)
 	if err != nil {
 		return err
 	}
 
+	return nil
+}
+
+// CreateVoterHistory records a vote. The poll must be open, the vote date
+// must fall within its window and the choice must be one of its options.
+//
+// For a secret poll the choice is taken off the history and cast as a
+// separate ballot, and the ballot's receipt is returned. The receipt is
+// blank for any other poll.
+func (s *service) CreateVoterHistory(voterId int, pollId int, history VoterHistoryDTO) (string, error) {
+
+	err := s.validateVoterHistory(voterId, pollId, history)
+	if err != nil {
+		return "", err
+	}
+
+	poll, err := s.openPoll(pollId, history)
+	if err != nil {
+		return "", err
+	}
+
+	err = checkBallot(poll, history)
+	if err != nil {
+		return "", err
+	}
+
+	if poll.secret {
+		return s.castSecretBallot(voterId, pollId, history)
+	}
+
 	err = s.r.CreateVoterHistory(voterId, pollId, history)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return "", nil
 }
 
-func (s *service) UpdateVoterHistoryInfo(voterId int, pollId int, history VoterHistoryDTO) error {
+func (s *service) castSecretBallot(voterId int, pollId int, history VoterHistoryDTO) (string, error) {
+
+	receipt, err := newReceipt()
+	if err != nil {
+		return "", err
+	}
+
+	ballot := NewBallotDTO(pollId, receipt, history.choice, history.ranking)
+
+	err = s.r.CastSecretBallot(voterId, pollId, history.WithChoice("").WithRanking(nil), ballot)
+	if err != nil {
+		return "", err
+	}
+
+	return receipt, nil
+}
+
+// UpdateVoterHistoryInfo corrects a vote, which is only allowed while the
+// poll is open. The ballot of a secret poll cannot be found from the history
+// so it cannot be changed, only the rest of the history.
+func (s *service) UpdateVoterHistoryInfo(voterId int, pollId int, history VoterHistoryDTO, expectedVersion int) error {
 
 	err := s.validateVoterHistory(voterId, pollId, history)
 	if err != nil {
 		return err
 	}
 
-	err = s.r.UpdateVoterHistoryInfo(voterId, pollId, history)
+	poll, err := s.openPoll(pollId, history)
+	if err != nil {
+		return err
+	}
+
+	if poll.secret {
+		if history.choice != "" || len(history.ranking) > 0 {
+			return ErrSecretBallotCast.Error()
+		}
+	} else if err := checkBallot(poll, history); err != nil {
+		return err
+	}
+
+	if expectedVersion < AnyVersion {
+		return ErrInvalidVersion.Error()
+	}
+
+	err = s.r.UpdateVoterHistoryInfo(voterId, pollId, history, expectedVersion)
 	if err != nil {
 		return err
 	}
@@ -106,18 +216,83 @@ func (s *service) UpdateVoterHistoryInfo(voterId int, pollId int, history VoterH
 	return nil
 }
 
-func (s *service) DeleteSingleVoterPoll(voterId int, pollId int) error {
+// DeleteSingleVoterPoll marks the voter history as deleted. The record is
+// kept so it can be brought back with RestoreVoterPoll.
+func (s *service) DeleteSingleVoterPoll(voterId int, pollId int, reason string, expectedVersion int) error {
 
 	if voterId < 1 || pollId < 1 {
 		return ErrInvalidId.Error()
 	}
 
-	s.r.DeleteSingleVoterPoll(voterId, pollId)
+	if expectedVersion < AnyVersion {
+		return ErrInvalidVersion.Error()
+	}
+
+	err := s.r.DeleteSingleVoterPoll(voterId, pollId, strings.TrimSpace(reason), expectedVersion)
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
 
-func isValidEmail(email string) bool {
+// RestoreVoterPoll brings back a deleted vote. It is checked like a new one,
+// so the results of a poll cannot change once it has closed.
+func (s *service) RestoreVoterPoll(voterId int, pollId int) error {
+
+	if voterId < 1 || pollId < 1 {
+		return ErrInvalidId.Error()
+	}
+
+	history, err := s.r.GetVoterPoll(voterId, pollId)
+	if err != nil {
+		return err
+	}
+
+	err = s.checkRestoredVote(history)
+	if err != nil {
+		return err
+	}
+
+	err = s.r.RestoreVoterPoll(voterId, pollId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RevertVoter puts the voter and its history back the way they were at the
+// given revision. The revert is stored as a new revision. Every vote the
+// revert brings back or changes is checked like a new one.
+func (s *service) RevertVoter(voterId int, revision int) error {
+
+	if voterId < 1 || revision < 1 {
+		return ErrInvalidId.Error()
+	}
+
+	reverted, err := s.r.GetRevertedHistory(voterId, revision)
+	if err != nil {
+		return err
+	}
+
+	for _, history := range reverted {
+		err = s.checkRestoredVote(history)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.r.RevertVoter(voterId, revision)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IsValidEmail reports whether email is in the format of <address>@<domain>.
+func IsValidEmail(email string) bool {
 	// Regular expression pattern for basic email validation
 	// This pattern is a simplified version and may not cover all edge cases
 	pattern := `^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`
@@ -129,6 +304,12 @@ func isValidEmail(email string) bool {
 	return regExp.MatchString(email)
 }
 
+// EmailKey returns the form of an email address used to tell whether two
+// addresses are the same. Addresses are compared case-insensitively.
+func EmailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
 func isInvalidString(s string) bool {
 
 	trimmed := strings.TrimSpace(s)
@@ -143,11 +324,11 @@ func (s *service) validateVoter(voter VoterDTO) error {
 	if isInvalidString(voter.name) {
 		return ErrInvalidName.Error()
 	}
-	if !isValidEmail(voter.email) {
+	if !IsValidEmail(voter.email) {
 		return ErrInvalidEmail.Error()
 	}
 
-	return nil
+	return s.validateProfile(voter)
 }
 
 func (s *service) validateVoterHistory(voterId int, pollId int, history VoterHistoryDTO) error {
@@ -164,3 +345,103 @@ func (s *service) validateVoterHistory(voterId int, pollId int, history VoterHis
 
 	return nil
 }
+
+// openPoll returns the poll, rejecting votes for a poll that is a draft,
+// closed, or outside its window right now, and votes dated outside the
+// window. Either end of the window may be left unset.
+func (s *service) openPoll(pollId int, history VoterHistoryDTO) (PollDTO, error) {
+	poll, err := s.r.GetPoll(pollId)
+	if err != nil {
+		return PollDTO{}, err
+	}
+
+	now := s.now()
+
+	switch {
+	case poll.status == PollDraft:
+		return PollDTO{}, ErrPollNotOpen.Error()
+	case poll.status == PollClosed:
+		return PollDTO{}, ErrPollClosed.Error()
+	case !poll.opensAt.IsZero() && now.Before(poll.opensAt):
+		return PollDTO{}, ErrPollNotOpen.Error()
+	case !poll.closesAt.IsZero() && now.After(poll.closesAt):
+		return PollDTO{}, ErrPollClosed.Error()
+	}
+
+	if !poll.opensAt.IsZero() && history.voteDate.Before(poll.opensAt) {
+		return PollDTO{}, ErrVoteDateOutsideWindow.Error()
+	}
+
+	if !poll.closesAt.IsZero() && history.voteDate.After(poll.closesAt) {
+		return PollDTO{}, ErrVoteDateOutsideWindow.Error()
+	}
+
+	return poll, nil
+}
+
+// checkRestoredVote applies the checks for a new vote to one that is being
+// brought back. The ballot of a secret poll is not part of the history, so
+// only the poll and the vote date are checked for it.
+func (s *service) checkRestoredVote(history VoterHistoryDTO) error {
+	poll, err := s.openPoll(history.pollId, history)
+	if err != nil {
+		return err
+	}
+
+	if poll.secret {
+		return nil
+	}
+
+	return checkBallot(poll, history)
+}
+
+// checkBallot makes sure the choice is one of the poll's options, and left
+// blank for a poll without options. A ranked-choice poll takes a ranking
+// instead of a choice.
+func checkBallot(poll PollDTO, history VoterHistoryDTO) error {
+	if poll.ranked {
+		if history.choice != "" {
+			return ErrInvalidChoice.Error()
+		}
+
+		return checkRanking(poll.options, history.ranking)
+	}
+
+	if len(history.ranking) > 0 {
+		return ErrInvalidRanking.Error()
+	}
+
+	if len(poll.options) == 0 && history.choice == "" {
+		return nil
+	}
+
+	for _, option := range poll.options {
+		if option == history.choice {
+			return nil
+		}
+	}
+
+	return ErrInvalidChoice.Error()
+}
+
+// checkRanking makes sure a ranking lists at least one of the options and
+// none of them twice.
+func checkRanking(options []string, ranking []string) error {
+	if len(ranking) == 0 {
+		return ErrInvalidRanking.Error()
+	}
+
+	valid := make(map[string]bool, len(options))
+	for _, option := range options {
+		valid[option] = true
+	}
+
+	for _, option := range ranking {
+		if !valid[option] {
+			return ErrInvalidRanking.Error()
+		}
+		valid[option] = false
+	}
+
+	return nil
+}
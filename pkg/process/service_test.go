@@ -1,8 +1,12 @@
 package process
 
 import (
+	"errors"
+	"fmt"
 	"testing"
+	"time"
 
+	fake "github.com/brianvoe/gofakeit/v6" //aliasing package name
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,72 +31,72 @@ func TestInvalidRequestFailuresCreateVoter(t *testing.T) {
 }
 
 func TestInvalidRequestFailuresUpdateVoterInfo(t *testing.T) {
-	err := testService.UpdateVoterInfo(SampleVoterZeroId)
+	err := testService.UpdateVoterInfo(SampleVoterZeroId, AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.UpdateVoterInfo(SampleVoterNegativeId)
+	err = testService.UpdateVoterInfo(SampleVoterNegativeId, AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.UpdateVoterInfo(SampleVoterNoName)
+	err = testService.UpdateVoterInfo(SampleVoterNoName, AnyVersion)
 	assert.Equal(t, ErrInvalidName.Error(), err)
 
-	err = testService.UpdateVoterInfo(SampleVoterInvalidEmail)
+	err = testService.UpdateVoterInfo(SampleVoterInvalidEmail, AnyVersion)
 	assert.Equal(t, ErrInvalidEmail.Error(), err)
 }
 
 func TestInvalidRequestFailuresDeleteSingleVoter(t *testing.T) {
-	err := testService.DeleteSingleVoter(-1)
+	err := testService.DeleteSingleVoter(-1, "", AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.DeleteSingleVoter(0)
+	err = testService.DeleteSingleVoter(0, "", AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 }
 
 func TestInvalidRequestFailuresCreateVoterHistory(t *testing.T) {
-	err := testService.CreateVoterHistory(0, 1, SampleValidVoterHistory)
+	_, err := testService.CreateVoterHistory(0, 1, SampleValidVoterHistory)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.CreateVoterHistory(-1, 1, SampleValidVoterHistory)
+	_, err = testService.CreateVoterHistory(-1, 1, SampleValidVoterHistory)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.CreateVoterHistory(1, 0, SampleValidVoterHistory)
+	_, err = testService.CreateVoterHistory(1, 0, SampleValidVoterHistory)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.CreateVoterHistory(1, -1, SampleValidVoterHistory)
+	_, err = testService.CreateVoterHistory(1, -1, SampleValidVoterHistory)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.CreateVoterHistory(1, 1, SampleVoterHistoryMissingDate)
+	_, err = testService.CreateVoterHistory(1, 1, SampleVoterHistoryMissingDate)
 	assert.Equal(t, ErrInvalidDate.Error(), err)
 }
 
 func TestInvalidRequestFailuresUpdateVoterHistoryInfo(t *testing.T) {
-	err := testService.UpdateVoterHistoryInfo(0, 1, SampleValidVoterHistory)
+	err := testService.UpdateVoterHistoryInfo(0, 1, SampleValidVoterHistory, AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.UpdateVoterHistoryInfo(-1, 1, SampleValidVoterHistory)
+	err = testService.UpdateVoterHistoryInfo(-1, 1, SampleValidVoterHistory, AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.UpdateVoterHistoryInfo(1, 0, SampleValidVoterHistory)
+	err = testService.UpdateVoterHistoryInfo(1, 0, SampleValidVoterHistory, AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.UpdateVoterHistoryInfo(1, -1, SampleValidVoterHistory)
+	err = testService.UpdateVoterHistoryInfo(1, -1, SampleValidVoterHistory, AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.UpdateVoterHistoryInfo(1, 1, SampleVoterHistoryMissingDate)
+	err = testService.UpdateVoterHistoryInfo(1, 1, SampleVoterHistoryMissingDate, AnyVersion)
 	assert.Equal(t, ErrInvalidDate.Error(), err)
 }
 
 func TestInvalidRequestFailuresDeleteSingleVoterPoll(t *testing.T) {
-	err := testService.DeleteSingleVoterPoll(-1, 1)
+	err := testService.DeleteSingleVoterPoll(-1, 1, "", AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.DeleteSingleVoterPoll(0, 1)
+	err = testService.DeleteSingleVoterPoll(0, 1, "", AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.DeleteSingleVoterPoll(1, 0)
+	err = testService.DeleteSingleVoterPoll(1, 0, "", AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 
-	err = testService.DeleteSingleVoterPoll(1, -1)
+	err = testService.DeleteSingleVoterPoll(1, -1, "", AnyVersion)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 }
 
@@ -102,26 +106,262 @@ func TestValidCreateVoter(t *testing.T) {
 }
 
 func TestValidUpdateVoterInfo(t *testing.T) {
-	err := testService.UpdateVoterInfo(SampleValidrequest)
+	err := testService.UpdateVoterInfo(SampleValidrequest, AnyVersion)
 	assert.NoError(t, err)
 }
 
 func TestValidDeleteSingleVoter(t *testing.T) {
-	err := testService.DeleteSingleVoter(1)
+	err := testService.DeleteSingleVoter(1, "", AnyVersion)
 	assert.NoError(t, err)
 }
 
 func TestValidCreateVoterHistory(t *testing.T) {
-	err := testService.CreateVoterHistory(1, 1, SampleValidVoterHistory)
+	_, err := testService.CreateVoterHistory(1, 1, SampleValidVoterHistory)
 	assert.NoError(t, err)
 }
 
 func TestValidUpdateVoterHistoryInfo(t *testing.T) {
-	err := testService.UpdateVoterHistoryInfo(1, 1, SampleValidVoterHistory)
+	err := testService.UpdateVoterHistoryInfo(1, 1, SampleValidVoterHistory, AnyVersion)
 	assert.NoError(t, err)
 }
 
-func DeleteSingleVoterPoll(t *testing.T) {
-	err := testService.DeleteSingleVoterPoll(1, 1)
+func TestValidDeleteSingleVoterPoll(t *testing.T) {
+	err := testService.DeleteSingleVoterPoll(1, 1, "", AnyVersion)
 	assert.NoError(t, err)
 }
+
+func TestDeleteMissingVoterPoll(t *testing.T) {
+	err := testService.DeleteSingleVoterPoll(1, MockUnknownPollId, "", AnyVersion)
+	assert.Equal(t, errMockHistoryNotFound, err)
+}
+
+func TestInvalidRequestFailuresRestore(t *testing.T) {
+	err := testService.RestoreVoter(0)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+
+	err = testService.RestoreVoterPoll(1, 0)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+
+	err = testService.RestoreVoterPoll(-1, 1)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+}
+
+func TestValidRestore(t *testing.T) {
+	err := testService.RestoreVoter(1)
+	assert.NoError(t, err)
+
+	err = testService.RestoreVoterPoll(1, 1)
+	assert.NoError(t, err)
+
+	err = testService.RestoreVoterPoll(1, MockBallotPollId)
+	assert.NoError(t, err)
+}
+
+func TestRestoreVoteInClosedPoll(t *testing.T) {
+	err := testService.RestoreVoterPoll(1, MockClosedPollId)
+	assert.Equal(t, ErrPollClosed.Error(), err)
+
+	err = testService.RestoreVoterPoll(1, MockUnknownPollId)
+	assert.Equal(t, errMockHistoryNotFound, err)
+
+	//the window has passed
+	err = testService.RestoreVoterPoll(1, MockScheduledPollId)
+	assert.Equal(t, ErrPollClosed.Error(), err)
+
+	//the revert would bring back a vote in the poll with the revision's id
+	err = testService.RevertVoter(1, MockClosedPollId)
+	assert.Equal(t, ErrPollClosed.Error(), err)
+
+	err = testService.RevertVoter(1, MockRankedPollId)
+	assert.NoError(t, err)
+}
+
+func TestRevertVoter(t *testing.T) {
+	err := testService.RevertVoter(1, 0)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+
+	err = testService.RevertVoter(0, 1)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+
+	err = testService.RevertVoter(1, 1)
+	assert.NoError(t, err)
+}
+
+func TestInvalidExpectedVersion(t *testing.T) {
+	err := testService.UpdateVoterInfo(SampleValidrequest, -1)
+	assert.Equal(t, ErrInvalidVersion.Error(), err)
+
+	err = testService.DeleteSingleVoter(1, "", -1)
+	assert.Equal(t, ErrInvalidVersion.Error(), err)
+
+	err = testService.UpdateVoterHistoryInfo(1, 1, SampleValidVoterHistory, -1)
+	assert.Equal(t, ErrInvalidVersion.Error(), err)
+
+	err = testService.DeleteSingleVoterPoll(1, 1, "", -1)
+	assert.Equal(t, ErrInvalidVersion.Error(), err)
+
+	err = testService.UpdateVoterInfo(SampleValidrequest, MockVersion)
+	assert.NoError(t, err)
+
+	err = testService.UpdateVoterInfo(SampleValidrequest, MockVersion+1)
+	assert.Equal(t, ErrVersionMismatch.Error(), err)
+
+	err = testService.DeleteSingleVoterPoll(1, 1, "", MockVersion+1)
+	assert.Equal(t, ErrVersionMismatch.Error(), err)
+}
+
+func TestEmailTaken(t *testing.T) {
+	err := testService.CreateVoter(NewVoterDTO(1, "Pat", "Taken@Example.com"))
+	assert.Equal(t, ErrEmailTaken.Error(), err)
+
+	err = testService.UpdateVoterInfo(NewVoterDTO(1, "Pat", MockTakenEmail), AnyVersion)
+	assert.Equal(t, ErrEmailTaken.Error(), err)
+}
+
+func TestPollWindow(t *testing.T) {
+	windowService := &service{&MockRepository{}, func() time.Time { return MockOpensAt.Add(time.Hour) }}
+
+	inWindow := NewVoterHistoryDTO(MockScheduledPollId, 1, MockOpensAt.Add(30*time.Minute))
+
+	_, err := windowService.CreateVoterHistory(1, MockScheduledPollId, inWindow)
+	assert.NoError(t, err)
+
+	err = windowService.UpdateVoterHistoryInfo(1, MockScheduledPollId, inWindow, AnyVersion)
+	assert.NoError(t, err)
+
+	_, err = windowService.CreateVoterHistory(1, MockScheduledPollId, NewVoterHistoryDTO(MockScheduledPollId, 1, MockClosesAt.AddDate(3, 0, 0)))
+	assert.Equal(t, ErrVoteDateOutsideWindow.Error(), err)
+
+	_, err = windowService.CreateVoterHistory(1, MockScheduledPollId, NewVoterHistoryDTO(MockScheduledPollId, 1, MockOpensAt.Add(-time.Minute)))
+	assert.Equal(t, ErrVoteDateOutsideWindow.Error(), err)
+
+	_, err = windowService.CreateVoterHistory(1, MockClosedPollId, inWindow)
+	assert.Equal(t, ErrPollClosed.Error(), err)
+
+	//the poll has not opened yet
+	windowService.now = func() time.Time { return MockOpensAt.Add(-time.Hour) }
+	_, err = windowService.CreateVoterHistory(1, MockScheduledPollId, inWindow)
+	assert.Equal(t, ErrPollNotOpen.Error(), err)
+
+	//the poll has already closed
+	windowService.now = func() time.Time { return MockClosesAt.Add(time.Hour) }
+	err = windowService.UpdateVoterHistoryInfo(1, MockScheduledPollId, inWindow, AnyVersion)
+	assert.Equal(t, ErrPollClosed.Error(), err)
+}
+
+func TestChoice(t *testing.T) {
+	history := NewVoterHistoryDTO(MockBallotPollId, 1, fake.Date())
+
+	_, err := testService.CreateVoterHistory(1, MockBallotPollId, history.WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = testService.UpdateVoterHistoryInfo(1, MockBallotPollId, history.WithChoice("maybe"), AnyVersion)
+	assert.Equal(t, ErrInvalidChoice.Error(), err)
+
+	//a poll with options needs a choice, a poll without options takes none
+	_, err = testService.CreateVoterHistory(1, MockBallotPollId, history)
+	assert.Equal(t, ErrInvalidChoice.Error(), err)
+
+	_, err = testService.CreateVoterHistory(1, 1, NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("yes"))
+	assert.Equal(t, ErrInvalidChoice.Error(), err)
+}
+
+func TestRanking(t *testing.T) {
+	history := NewVoterHistoryDTO(MockRankedPollId, 1, fake.Date())
+
+	_, err := testService.CreateVoterHistory(1, MockRankedPollId, history.WithRanking([]string{"carol", "alice"}))
+	assert.NoError(t, err)
+
+	err = testService.UpdateVoterHistoryInfo(1, MockRankedPollId, history.WithRanking([]string{"carol", "carol"}), AnyVersion)
+	assert.Equal(t, ErrInvalidRanking.Error(), err)
+
+	_, err = testService.CreateVoterHistory(1, MockRankedPollId, history.WithRanking([]string{"dave"}))
+	assert.Equal(t, ErrInvalidRanking.Error(), err)
+
+	_, err = testService.CreateVoterHistory(1, MockRankedPollId, history)
+	assert.Equal(t, ErrInvalidRanking.Error(), err)
+
+	//a ranked-choice poll takes no choice, any other poll takes no ranking
+	_, err = testService.CreateVoterHistory(1, MockRankedPollId, history.WithChoice("alice").WithRanking([]string{"alice"}))
+	assert.Equal(t, ErrInvalidChoice.Error(), err)
+
+	_, err = testService.CreateVoterHistory(1, MockBallotPollId, NewVoterHistoryDTO(MockBallotPollId, 1, fake.Date()).WithChoice("yes").WithRanking([]string{"yes"}))
+	assert.Equal(t, ErrInvalidRanking.Error(), err)
+}
+
+func TestSecretBallot(t *testing.T) {
+	history := NewVoterHistoryDTO(MockSecretPollId, 1, fake.Date())
+
+	receipt, err := testService.CreateVoterHistory(1, MockSecretPollId, history.WithChoice("yes"))
+	assert.NoError(t, err)
+	assert.Equal(t, 16, len(receipt))
+
+	other, err := testService.CreateVoterHistory(2, MockSecretPollId, history.WithChoice("yes"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, receipt, other)
+
+	_, err = testService.CreateVoterHistory(1, MockSecretPollId, history.WithChoice("maybe"))
+	assert.Equal(t, ErrInvalidChoice.Error(), err)
+
+	//only the vote date can be corrected once the ballot is cast
+	err = testService.UpdateVoterHistoryInfo(1, MockSecretPollId, history, AnyVersion)
+	assert.NoError(t, err)
+
+	err = testService.UpdateVoterHistoryInfo(1, MockSecretPollId, history.WithChoice("no"), AnyVersion)
+	assert.Equal(t, ErrSecretBallotCast.Error(), err)
+
+	//any other poll has no receipt
+	receipt, err = testService.CreateVoterHistory(1, MockBallotPollId, NewVoterHistoryDTO(MockBallotPollId, 1, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+	assert.Equal(t, "", receipt)
+}
+
+func TestNextElection(t *testing.T) {
+	assert.Equal(t, time.Date(2024, time.November, 5, 0, 0, 0, 0, time.UTC), NextElection(time.Date(2024, time.August, 1, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, time.November, 5, 0, 0, 0, 0, time.UTC), NextElection(time.Date(2024, time.November, 5, 23, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2025, time.November, 4, 0, 0, 0, 0, time.UTC), NextElection(time.Date(2024, time.November, 6, 0, 0, 0, 0, time.UTC)))
+	//November 1st is a Tuesday, so the election is a week later
+	assert.Equal(t, time.Date(2022, time.November, 8, 0, 0, 0, 0, time.UTC), NextElection(time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestProfile(t *testing.T) {
+	profileService := &service{&MockRepository{}, func() time.Time { return time.Date(2024, time.August, 1, 12, 0, 0, 0, time.UTC) }}
+
+	home := NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104")
+	voter := NewVoterDTO(1, "Pat", "pat@example.com")
+
+	//18 on the day of the election
+	err := profileService.CreateVoter(voter.WithProfile(time.Date(2006, time.November, 5, 0, 0, 0, 0, time.UTC), home, NewAddressDTO("PO Box 1", "Philadelphia", "pa", "19104-0001"), "Philadelphia County"))
+	assert.NoError(t, err)
+
+	err = profileService.CreateVoter(voter.WithProfile(time.Date(2006, time.November, 6, 0, 0, 0, 0, time.UTC), home, AddressDTO{}, ""))
+	assert.Equal(t, ErrUnderage.Error(), err)
+
+	err = profileService.UpdateVoterInfo(voter.WithProfile(time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC), home, AddressDTO{}, ""), AnyVersion)
+	assert.Equal(t, ErrInvalidDateOfBirth.Error(), err)
+
+	err = profileService.CreateVoter(voter.WithProfile(time.Time{}, NewAddressDTO("1 Main St", "Philadelphia", "PA", "1910"), AddressDTO{}, ""))
+	assert.Equal(t, ErrInvalidPostalCode.Error(), err)
+
+	err = profileService.CreateVoter(voter.WithProfile(time.Time{}, home, NewAddressDTO("PO Box 1", "Philadelphia", "Penn", "19104"), ""))
+	assert.Equal(t, ErrInvalidState.Error(), err)
+
+	err = profileService.CreateVoter(voter.WithProfile(time.Time{}, NewAddressDTO(" ", "Philadelphia", "PA", "19104"), AddressDTO{}, ""))
+	assert.Equal(t, ErrInvalidAddress.Error(), err)
+}
+
+func TestErrorKinds(t *testing.T) {
+	err := testService.CreateVoter(NewVoterDTO(0, "Pat", "pat@example.com"))
+
+	var typed *Error
+	assert.True(t, errors.As(err, &typed))
+	assert.Equal(t, KindInvalid, typed.Kind)
+	assert.Equal(t, "invalid_id", typed.Code)
+
+	wrapped := fmt.Errorf("voter 1: %w", ErrEmailTaken.Error())
+	assert.ErrorIs(t, wrapped, ErrEmailTaken.Error())
+	assert.NotErrorIs(t, wrapped, ErrPollInUse.Error())
+
+	//an error without a code is internal
+	assert.Equal(t, KindInternal, processServiceError("unexpected").Error().(*Error).Kind)
+}
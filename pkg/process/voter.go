@@ -1,9 +1,25 @@
 package process
 
+import "time"
+
 type VoterDTO struct {
 	id    int
 	name  string
 	email string
+
+	dateOfBirth        time.Time
+	residentialAddress AddressDTO
+	mailingAddress     AddressDTO
+	jurisdiction       string
+}
+
+// AddressDTO is a postal address. The zero AddressDTO means no address was
+// given.
+type AddressDTO struct {
+	street     string
+	city       string
+	state      string
+	postalCode string
 }
 
 func NewVoterDTO(id int, name string, email string) VoterDTO {
@@ -14,6 +30,15 @@ func NewVoterDTO(id int, name string, email string) VoterDTO {
 	}
 }
 
+func NewAddressDTO(street string, city string, state string, postalCode string) AddressDTO {
+	return AddressDTO{
+		street:     street,
+		city:       city,
+		state:      state,
+		postalCode: postalCode,
+	}
+}
+
 func (v *VoterDTO) GetId() int {
 	return v.id
 }
@@ -25,3 +50,54 @@ func (v *VoterDTO) GetName() string {
 func (v *VoterDTO) GetEmail() string {
 	return v.email
 }
+
+// WithProfile returns a copy of the voter with the given date of birth,
+// addresses and jurisdiction. The date of birth only keeps its date.
+func (v VoterDTO) WithProfile(dateOfBirth time.Time, residentialAddress AddressDTO, mailingAddress AddressDTO, jurisdiction string) VoterDTO {
+	if !dateOfBirth.IsZero() {
+		dateOfBirth = time.Date(dateOfBirth.Year(), dateOfBirth.Month(), dateOfBirth.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	v.dateOfBirth = dateOfBirth
+	v.residentialAddress = residentialAddress
+	v.mailingAddress = mailingAddress
+	v.jurisdiction = jurisdiction
+	return v
+}
+
+// GetDateOfBirth returns the zero time if no date of birth was given.
+func (v *VoterDTO) GetDateOfBirth() time.Time {
+	return v.dateOfBirth
+}
+
+func (v *VoterDTO) GetResidentialAddress() AddressDTO {
+	return v.residentialAddress
+}
+
+func (v *VoterDTO) GetMailingAddress() AddressDTO {
+	return v.mailingAddress
+}
+
+func (v *VoterDTO) GetJurisdiction() string {
+	return v.jurisdiction
+}
+
+func (a *AddressDTO) GetStreet() string {
+	return a.street
+}
+
+func (a *AddressDTO) GetCity() string {
+	return a.city
+}
+
+func (a *AddressDTO) GetState() string {
+	return a.state
+}
+
+func (a *AddressDTO) GetPostalCode() string {
+	return a.postalCode
+}
+
+func (a *AddressDTO) IsZero() bool {
+	return *a == AddressDTO{}
+}
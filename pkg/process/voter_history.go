@@ -6,6 +6,8 @@ type VoterHistoryDTO struct {
 	pollId   int
 	voteId   int
 	voteDate time.Time
+	choice   string
+	ranking  []string
 }
 
 func NewVoterHistoryDTO(id int, voteId int, voteDate time.Time) VoterHistoryDTO {
@@ -27,3 +29,25 @@ func (v *VoterHistoryDTO) GetVoteID() int {
 func (v *VoterHistoryDTO) GetVoteDate() time.Time {
 	return v.voteDate
 }
+
+// WithChoice returns a copy of the history with the option the voter chose.
+func (v VoterHistoryDTO) WithChoice(choice string) VoterHistoryDTO {
+	v.choice = choice
+	return v
+}
+
+func (v *VoterHistoryDTO) GetChoice() string {
+	return v.choice
+}
+
+// WithRanking returns a copy of the history with the options of a
+// ranked-choice poll in the voter's order of preference, most preferred
+// first. A voter does not have to rank every option.
+func (v VoterHistoryDTO) WithRanking(ranking []string) VoterHistoryDTO {
+	v.ranking = ranking
+	return v
+}
+
+func (v *VoterHistoryDTO) GetRanking() []string {
+	return v.ranking
+}
@@ -0,0 +1,68 @@
+package retrieve
+
+import "strings"
+
+// ChainReportDTO is the result of checking the audit chain against the
+// stored voter history. Head is the hash of the last entry in the chain.
+type ChainReportDTO struct {
+	entries  int
+	head     string
+	problems []string
+}
+
+func NewChainReportDTO(entries int, head string, problems []string) ChainReportDTO {
+	return ChainReportDTO{
+		entries:  entries,
+		head:     head,
+		problems: problems,
+	}
+}
+
+func (r *ChainReportDTO) GetEntries() int {
+	return r.entries
+}
+
+func (r *ChainReportDTO) GetHead() string {
+	return r.head
+}
+
+func (r *ChainReportDTO) GetProblems() []string {
+	return r.problems
+}
+
+// IsValid reports whether the chain is intact and matches the history.
+func (r *ChainReportDTO) IsValid() bool {
+	return len(r.problems) == 0
+}
+
+// AuditService checks the voter history against the audit chain.
+type AuditService interface {
+	VerifyChain(head string) (ChainReportDTO, error)
+}
+
+// VerifyChain returns process.ErrChainDisabled if the database does not keep
+// an audit chain. A head that is not blank must be the hash of an entry in
+// the chain, otherwise it is reported as a problem.
+type AuditRepository interface {
+	VerifyChain(head string) (ChainReportDTO, error)
+}
+
+type auditService struct {
+	r AuditRepository
+}
+
+func NewAuditService(r AuditRepository) AuditService {
+	return &auditService{r}
+}
+
+// VerifyChain accepts the head in any case, with or without surrounding
+// spaces.
+func (s *auditService) VerifyChain(head string) (ChainReportDTO, error) {
+
+	report, err := s.r.VerifyChain(strings.ToLower(strings.TrimSpace(head)))
+	if err != nil {
+		return ChainReportDTO{}, err
+	}
+
+	return report, nil
+}
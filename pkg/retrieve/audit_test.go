@@ -0,0 +1,25 @@
+package retrieve
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyChain(t *testing.T) {
+	s := NewAuditService(&MockRepository{})
+
+	report, err := s.VerifyChain("")
+	assert.NoError(t, err)
+	assert.True(t, report.IsValid())
+	assert.Equal(t, MockChainHead, report.GetHead())
+
+	report, err = s.VerifyChain(" " + strings.ToUpper(MockChainHead) + " ")
+	assert.NoError(t, err)
+	assert.True(t, report.IsValid())
+
+	report, err = s.VerifyChain("abc")
+	assert.NoError(t, err)
+	assert.False(t, report.IsValid())
+}
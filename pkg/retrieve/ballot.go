@@ -0,0 +1,49 @@
+package retrieve
+
+// BallotDTO is a ballot cast in a secret poll. Nothing on it identifies the
+// voter, it can only be looked up by its receipt.
+type BallotDTO struct {
+	pollId  int
+	receipt string
+	choice  string
+	ranking []string
+}
+
+func NewBallotDTO(pollId int, receipt string, choice string, ranking []string) BallotDTO {
+	return BallotDTO{
+		pollId:  pollId,
+		receipt: receipt,
+		choice:  choice,
+		ranking: ranking,
+	}
+}
+
+func (b *BallotDTO) GetPollId() int {
+	return b.pollId
+}
+
+func (b *BallotDTO) GetReceipt() string {
+	return b.receipt
+}
+
+func (b *BallotDTO) GetChoice() string {
+	return b.choice
+}
+
+func (b *BallotDTO) GetRanking() []string {
+	return b.ranking
+}
+
+// preferences returns the options the ballot is for in order of preference,
+// the same as VoterHistoryDTO.ballot.
+func (b *BallotDTO) preferences() []string {
+	if len(b.ranking) > 0 {
+		return b.ranking
+	}
+
+	if b.choice != "" {
+		return []string{b.choice}
+	}
+
+	return nil
+}
@@ -1,13 +1,41 @@
 package retrieve
 
-import "errors"
+import "drexel.edu/voter-api/pkg/process"
 
 type RetrieveServiceError string
 
 const (
-	ErrInvalidId RetrieveServiceError = "Id must be a positive non-zero integer."
+	ErrInvalidId    RetrieveServiceError = "Id must be a positive non-zero integer."
+	ErrInvalidEmail RetrieveServiceError = "Email must not be blank."
+
+	ErrInvalidReceipt RetrieveServiceError = "Receipt must not be blank."
+
+	ErrInvalidSort    RetrieveServiceError = "sort must be id, name, created or modified, with a leading - to sort in descending order."
+	ErrInvalidPage    RetrieveServiceError = "page must be a positive integer."
+	ErrInvalidPerPage RetrieveServiceError = "per_page must be between 1 and 1000."
+
+	ErrInvalidSearch      RetrieveServiceError = "q must not be blank."
+	ErrInvalidSearchLimit RetrieveServiceError = "limit must be between 1 and 100."
+
+	ErrInvalidFilter RetrieveServiceError = "filter is not a valid filter expression."
 )
 
+var retrieveServiceErrors = map[RetrieveServiceError]process.Error{
+	ErrInvalidId:    {Kind: process.KindInvalid, Code: "invalid_id"},
+	ErrInvalidEmail: {Kind: process.KindInvalid, Code: "invalid_email"},
+
+	ErrInvalidReceipt: {Kind: process.KindInvalid, Code: "invalid_receipt"},
+
+	ErrInvalidSort:    {Kind: process.KindInvalid, Code: "invalid_sort"},
+	ErrInvalidPage:    {Kind: process.KindInvalid, Code: "invalid_page"},
+	ErrInvalidPerPage: {Kind: process.KindInvalid, Code: "invalid_per_page"},
+
+	ErrInvalidSearch:      {Kind: process.KindInvalid, Code: "invalid_search"},
+	ErrInvalidSearchLimit: {Kind: process.KindInvalid, Code: "invalid_limit"},
+
+	ErrInvalidFilter: {Kind: process.KindInvalid, Code: "invalid_filter"},
+}
+
 func (e RetrieveServiceError) Error() error {
-	return errors.New(string(e))
+	return retrieveServiceErrors[e].WithMessage(string(e))
 }
@@ -0,0 +1,439 @@
+package retrieve
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Filter is a parsed filter expression in the style of SCIM (RFC 7644,
+// section 3.4.2.2), for example
+//
+//	name co "smith" and created gt "2024-01-01T00:00:00Z" and history.poll_id eq 3
+//
+// Attribute expressions compare an attribute with a value using eq, ne, co
+// (contains), sw (starts with), ew (ends with), gt, ge, lt or le, or test that
+// it is present with pr. They can be combined with and, or, not and
+// parentheses, and is evaluated before or. Attribute names and operators
+// ignore case, and so do comparisons of text.
+//
+// A multi-valued attribute such as a voter's history matches when any of its
+// items does. history.poll_id eq 3 and history.choice eq "yes" can be met by
+// two different items, history[poll_id eq 3 and choice eq "yes"] only by one.
+type Filter[T any] struct {
+	match func(*T) bool
+
+	// multiValued is set if the filter refers to a multi-valued attribute,
+	// such as a voter's history
+	multiValued bool
+
+	// hints are the attribute expressions every item passing the filter
+	// also passes, which lets the caller narrow what it loads. They are
+	// the comparisons joined to the rest of the filter by and alone
+	hints []filterHint
+}
+
+// filterHint is a comparison of a single-valued attribute with a value.
+type filterHint struct {
+	path  string
+	op    string
+	value filterValue
+}
+
+// Matches reports whether the item passes the filter.
+func (f *Filter[T]) Matches(item T) bool {
+	return f.match(&item)
+}
+
+// ParseVoterFilter parses a filter on the fields of VoterDTO, see
+// voterFilterSchema for the attributes.
+func ParseVoterFilter(text string) (*Filter[VoterDTO], error) {
+	return parseFilter(text, voterFilterSchema)
+}
+
+// ParseHistoryFilter parses a filter on the fields of VoterHistoryDTO, see
+// historyFilterSchema for the attributes.
+func ParseHistoryFilter(text string) (*Filter[VoterHistoryDTO], error) {
+	return parseFilter(text, historyFilterSchema)
+}
+
+// FilterError is a filter expression that could not be parsed. Position is
+// where the offending token starts, counting the first character as 1, and
+// Token is the token itself, empty at the end of the filter. It wraps
+// ErrInvalidFilter.
+type FilterError struct {
+	Position int
+	Token    string
+	Reason   string
+}
+
+func (e *FilterError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("filter: %s at position %d, the end of the filter", e.Reason, e.Position)
+	}
+
+	return fmt.Sprintf("filter: %s at position %d near %q", e.Reason, e.Position, e.Token)
+}
+
+func (e *FilterError) Unwrap() error {
+	return ErrInvalidFilter.Error()
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenString
+	tokenNumber
+	tokenOpen
+	tokenClose
+	tokenOpenBracket
+	tokenCloseBracket
+)
+
+// token is a word, which is an attribute, an operator or a keyword, a
+// literal or a bracket. value is the unquoted text of a string.
+type token struct {
+	kind     tokenKind
+	text     string
+	value    string
+	position int
+}
+
+// is reports whether the token is the given word, ignoring case.
+func (t token) is(word string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, word)
+}
+
+func (t token) errorf(format string, args ...any) error {
+	return &FilterError{Position: t.position, Token: t.text, Reason: fmt.Sprintf(format, args...)}
+}
+
+var brackets = map[rune]tokenKind{
+	'(': tokenOpen,
+	')': tokenClose,
+	'[': tokenOpenBracket,
+	']': tokenCloseBracket,
+}
+
+// tokenize splits the filter into tokens, ending with a tokenEnd.
+func tokenize(text string) ([]token, error) {
+	runes := []rune(text)
+	var tokens []token
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+
+		case brackets[r] != tokenEnd:
+			i++
+			tokens = append(tokens, token{kind: brackets[r], text: string(r), position: start + 1})
+			continue
+
+		case r == '"':
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+
+			if i >= len(runes) {
+				return nil, &FilterError{Position: start + 1, Token: string(runes[start:]), Reason: "unterminated string"}
+			}
+			i++
+
+			literal := string(runes[start:i])
+
+			var value string
+			if err := json.Unmarshal([]byte(literal), &value); err != nil {
+				return nil, &FilterError{Position: start + 1, Token: literal, Reason: "invalid escape in string"}
+			}
+
+			tokens = append(tokens, token{kind: tokenString, text: literal, value: value, position: start + 1})
+			continue
+
+		case r == '-' || unicode.IsDigit(r):
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:i]), position: start + 1})
+			continue
+
+		case unicode.IsLetter(r) || r == '_':
+			i++
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[start:i]), position: start + 1})
+			continue
+		}
+
+		return nil, &FilterError{Position: start + 1, Token: string(r), Reason: "unexpected character"}
+	}
+
+	return append(tokens, token{kind: tokenEnd, position: len(runes) + 1}), nil
+}
+
+// filterParser reads the tokens of a filter one at a time. The grammar is
+//
+//	filter     = and *("or" and)
+//	and        = unary *("and" unary)
+//	unary      = "not" "(" filter ")" / "(" filter ")" / attribute
+//	attribute  = path "pr" / path operator value / multi "[" filter "]"
+type filterParser struct {
+	tokens []token
+	next   int
+
+	// depth counts the parentheses, nots and multi-valued attributes the
+	// parser is in, hints are only taken at depth 0 and only kept if there
+	// is no or at that depth
+	depth       int
+	or          bool
+	hints       []filterHint
+	multiValued bool
+}
+
+func (p *filterParser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *filterParser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEnd {
+		p.next++
+	}
+	return t
+}
+
+func (p *filterParser) expect(kind tokenKind, text string) error {
+	if t := p.take(); t.kind != kind {
+		return t.errorf("expected %q", text)
+	}
+	return nil
+}
+
+func parseFilter[T any](text string, schema filterSchema[T]) (*Filter[T], error) {
+	tokens, err := tokenize(text)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+
+	match, err := parseOr(p, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEnd {
+		return nil, t.errorf("expected and, or or the end of the filter")
+	}
+
+	filter := &Filter[T]{match: match, multiValued: p.multiValued}
+	if !p.or {
+		filter.hints = p.hints
+	}
+
+	return filter, nil
+}
+
+func parseOr[T any](p *filterParser, schema filterSchema[T]) (func(*T) bool, error) {
+	left, err := parseAnd(p, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().is("or") {
+		p.take()
+
+		if p.depth == 0 {
+			p.or = true
+		}
+
+		right, err := parseAnd(p, schema)
+		if err != nil {
+			return nil, err
+		}
+
+		either := left
+		left = func(item *T) bool { return either(item) || right(item) }
+	}
+
+	return left, nil
+}
+
+func parseAnd[T any](p *filterParser, schema filterSchema[T]) (func(*T) bool, error) {
+	left, err := parseUnary(p, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().is("and") {
+		p.take()
+
+		right, err := parseUnary(p, schema)
+		if err != nil {
+			return nil, err
+		}
+
+		both := left
+		left = func(item *T) bool { return both(item) && right(item) }
+	}
+
+	return left, nil
+}
+
+func parseUnary[T any](p *filterParser, schema filterSchema[T]) (func(*T) bool, error) {
+	negate := false
+
+	if p.peek().is("not") {
+		p.take()
+		negate = true
+
+		if t := p.peek(); t.kind != tokenOpen {
+			return nil, t.errorf(`expected "(" after not`)
+		}
+	}
+
+	var match func(*T) bool
+	var err error
+
+	if p.peek().kind == tokenOpen {
+		p.take()
+
+		p.depth++
+		if match, err = parseOr(p, schema); err != nil {
+			return nil, err
+		}
+		p.depth--
+
+		if err := p.expect(tokenClose, ")"); err != nil {
+			return nil, err
+		}
+	} else if match, err = parseAttribute(p, schema); err != nil {
+		return nil, err
+	}
+
+	if negate {
+		inner := match
+		match = func(item *T) bool { return !inner(item) }
+	}
+
+	return match, nil
+}
+
+func parseAttribute[T any](p *filterParser, schema filterSchema[T]) (func(*T) bool, error) {
+	name := p.take()
+	if name.kind != tokenWord || isKeyword(name) {
+		return nil, name.errorf("expected an attribute")
+	}
+
+	path := strings.ToLower(name.text)
+	multi, sub, _ := strings.Cut(path, ".")
+
+	if items, exists := schema.multiValued[multi]; exists {
+		return items(p, name, sub)
+	}
+
+	attribute, exists := schema.attributes[path]
+	if !exists {
+		return nil, name.errorf("unknown attribute")
+	}
+
+	return parseComparison(p, path, attribute)
+}
+
+// parseComparison reads the operator and value after an attribute.
+func parseComparison[T any](p *filterParser, path string, attribute filterAttribute[T]) (func(*T) bool, error) {
+	operator := p.take()
+
+	if operator.is("pr") {
+		return func(item *T) bool { return attribute.value(item).present }, nil
+	}
+
+	op := strings.ToLower(operator.text)
+	if operator.kind != tokenWord || !comparisons[op] {
+		return nil, operator.errorf("expected an operator: eq, ne, co, sw, ew, gt, ge, lt, le or pr")
+	}
+
+	if (op == "co" || op == "sw" || op == "ew") && attribute.kind != kindText {
+		return nil, operator.errorf("%s only applies to text attributes", op)
+	}
+
+	expected, err := parseValue(p.take(), attribute.kind)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.depth == 0 {
+		p.hints = append(p.hints, filterHint{path: path, op: op, value: expected})
+	}
+
+	return func(item *T) bool { return compare(op, attribute.kind, attribute.value(item), expected) }, nil
+}
+
+// parseValue converts the literal to the kind of the attribute it is
+// compared with.
+func parseValue(t token, kind filterKind) (filterValue, error) {
+	switch kind {
+	case kindNumber:
+		if t.kind != tokenNumber {
+			return filterValue{}, t.errorf("expected a number")
+		}
+
+		number, err := strconv.Atoi(t.text)
+		if err != nil {
+			return filterValue{}, t.errorf("expected a whole number")
+		}
+
+		return filterValue{present: true, number: number}, nil
+
+	case kindTime:
+		if t.kind != tokenString {
+			return filterValue{}, t.errorf("expected an RFC 3339 time in quotes")
+		}
+
+		value, err := time.Parse(time.RFC3339, t.value)
+		if err != nil {
+			return filterValue{}, t.errorf("expected an RFC 3339 time")
+		}
+
+		return filterValue{present: true, time: value}, nil
+
+	case kindDate:
+		if t.kind != tokenString {
+			return filterValue{}, t.errorf("expected a YYYY-MM-DD date in quotes")
+		}
+
+		value, err := time.Parse(dateFormat, t.value)
+		if err != nil {
+			return filterValue{}, t.errorf("expected a YYYY-MM-DD date")
+		}
+
+		return filterValue{present: true, time: value}, nil
+	}
+
+	if t.kind != tokenString {
+		return filterValue{}, t.errorf("expected a string in quotes")
+	}
+
+	return filterValue{present: true, text: t.value}, nil
+}
+
+func isKeyword(t token) bool {
+	return t.is("and") || t.is("or") || t.is("not")
+}
@@ -0,0 +1,227 @@
+package retrieve
+
+import (
+	"cmp"
+	"strings"
+	"time"
+)
+
+// dateFormat is how dates, such as a date of birth, are written in filters.
+const dateFormat = "2006-01-02"
+
+// filterKind is the type of an attribute, which decides the values it can be
+// compared with and how.
+type filterKind int
+
+const (
+	kindText filterKind = iota
+	kindNumber
+	kindTime
+	kindDate
+)
+
+// comparisons are the operators that take a value.
+var comparisons = map[string]bool{
+	"eq": true, "ne": true, "co": true, "sw": true, "ew": true,
+	"gt": true, "ge": true, "lt": true, "le": true,
+}
+
+// filterValue is the value of an attribute, or of a literal in the filter.
+// Text is present when it is not empty and times when they are not zero.
+type filterValue struct {
+	present bool
+	text    string
+	number  int
+	time    time.Time
+}
+
+type filterAttribute[T any] struct {
+	kind  filterKind
+	value func(*T) filterValue
+}
+
+// multiValuedAttribute parses what follows the name of a multi-valued
+// attribute, given the sub-attribute after the dot if there is one, and
+// matches an item when any of the values does.
+type multiValuedAttribute[T any] func(p *filterParser, name token, sub string) (func(*T) bool, error)
+
+// filterSchema is the attributes a filter on T can use, by lower case name.
+type filterSchema[T any] struct {
+	attributes  map[string]filterAttribute[T]
+	multiValued map[string]multiValuedAttribute[T]
+}
+
+// voterFilterSchema has the fields of a voter as GET /voters returns them,
+// with the address fields as residential_address.street and so on, and its
+// history as the multi-valued attribute history.
+var voterFilterSchema = filterSchema[VoterDTO]{
+	attributes: map[string]filterAttribute[VoterDTO]{
+		"id":            numberAttribute(func(v *VoterDTO) int { return v.id }),
+		"name":          textAttribute(func(v *VoterDTO) string { return v.name }),
+		"email":         textAttribute(func(v *VoterDTO) string { return v.email }),
+		"created":       timeAttribute(func(v *VoterDTO) time.Time { return v.created }),
+		"modified":      timeAttribute(func(v *VoterDTO) time.Time { return v.modified }),
+		"deleted":       timeAttribute(func(v *VoterDTO) time.Time { return v.deleted }),
+		"delete_reason": textAttribute(func(v *VoterDTO) string { return v.deleteReason }),
+		"version":       numberAttribute(func(v *VoterDTO) int { return v.version }),
+		"date_of_birth": {kind: kindDate, value: func(v *VoterDTO) filterValue { return timeValue(v.dateOfBirth) }},
+		"jurisdiction":  textAttribute(func(v *VoterDTO) string { return v.jurisdiction }),
+
+		"residential_address.street":      textAttribute(func(v *VoterDTO) string { return v.residentialAddress.street }),
+		"residential_address.city":        textAttribute(func(v *VoterDTO) string { return v.residentialAddress.city }),
+		"residential_address.state":       textAttribute(func(v *VoterDTO) string { return v.residentialAddress.state }),
+		"residential_address.postal_code": textAttribute(func(v *VoterDTO) string { return v.residentialAddress.postalCode }),
+		"mailing_address.street":          textAttribute(func(v *VoterDTO) string { return v.mailingAddress.street }),
+		"mailing_address.city":            textAttribute(func(v *VoterDTO) string { return v.mailingAddress.city }),
+		"mailing_address.state":           textAttribute(func(v *VoterDTO) string { return v.mailingAddress.state }),
+		"mailing_address.postal_code":     textAttribute(func(v *VoterDTO) string { return v.mailingAddress.postalCode }),
+	},
+	multiValued: map[string]multiValuedAttribute[VoterDTO]{
+		"history": anyItem(func(v *VoterDTO) []VoterHistoryDTO {
+			history := make([]VoterHistoryDTO, 0, len(v.history))
+			for _, item := range v.history {
+				history = append(history, item)
+			}
+			return history
+		}, historyFilterSchema),
+	},
+}
+
+// historyFilterSchema has the fields of an item of a voter's history as GET
+// /voters/:id/polls returns them.
+var historyFilterSchema = filterSchema[VoterHistoryDTO]{
+	attributes: map[string]filterAttribute[VoterHistoryDTO]{
+		"poll_id":       numberAttribute(func(h *VoterHistoryDTO) int { return h.pollId }),
+		"vote_id":       numberAttribute(func(h *VoterHistoryDTO) int { return h.voteId }),
+		"vote_date":     timeAttribute(func(h *VoterHistoryDTO) time.Time { return h.voteDate }),
+		"choice":        textAttribute(func(h *VoterHistoryDTO) string { return h.choice }),
+		"created":       timeAttribute(func(h *VoterHistoryDTO) time.Time { return h.created }),
+		"modified":      timeAttribute(func(h *VoterHistoryDTO) time.Time { return h.modified }),
+		"deleted":       timeAttribute(func(h *VoterHistoryDTO) time.Time { return h.deleted }),
+		"delete_reason": textAttribute(func(h *VoterHistoryDTO) string { return h.deleteReason }),
+		"version":       numberAttribute(func(h *VoterHistoryDTO) int { return h.version }),
+	},
+}
+
+func textAttribute[T any](get func(*T) string) filterAttribute[T] {
+	return filterAttribute[T]{kind: kindText, value: func(item *T) filterValue {
+		text := get(item)
+		return filterValue{present: text != "", text: text}
+	}}
+}
+
+func numberAttribute[T any](get func(*T) int) filterAttribute[T] {
+	return filterAttribute[T]{kind: kindNumber, value: func(item *T) filterValue {
+		return filterValue{present: true, number: get(item)}
+	}}
+}
+
+func timeAttribute[T any](get func(*T) time.Time) filterAttribute[T] {
+	return filterAttribute[T]{kind: kindTime, value: func(item *T) filterValue {
+		return timeValue(get(item))
+	}}
+}
+
+func timeValue(t time.Time) filterValue {
+	return filterValue{present: !t.IsZero(), time: t}
+}
+
+// anyItem is a multi-valued attribute whose values are the items of type S.
+// It takes a sub-attribute, as in history.poll_id eq 3, a filter on a single
+// item, as in history[poll_id eq 3 and choice eq "yes"], or pr for any item
+// at all.
+func anyItem[T any, S any](items func(*T) []S, schema filterSchema[S]) multiValuedAttribute[T] {
+	return func(p *filterParser, name token, sub string) (func(*T) bool, error) {
+		var match func(*S) bool
+		var err error
+
+		// a comparison on one of the items says nothing about the others
+		p.multiValued = true
+		p.depth++
+		defer func() { p.depth-- }()
+
+		switch {
+		case sub != "":
+			attribute, exists := schema.attributes[sub]
+			if !exists {
+				return nil, name.errorf("unknown attribute")
+			}
+
+			if match, err = parseComparison(p, sub, attribute); err != nil {
+				return nil, err
+			}
+
+		case p.peek().kind == tokenOpenBracket:
+			p.take()
+
+			if match, err = parseOr(p, schema); err != nil {
+				return nil, err
+			}
+
+			if err := p.expect(tokenCloseBracket, "]"); err != nil {
+				return nil, err
+			}
+
+		case p.peek().is("pr"):
+			p.take()
+			match = func(*S) bool { return true }
+
+		default:
+			return nil, p.peek().errorf(`expected "[" or pr after %s, or a sub-attribute such as %s.poll_id`, name.text, name.text)
+		}
+
+		return func(item *T) bool {
+			for _, value := range items(item) {
+				if match(&value) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+}
+
+// compare applies the operator to the attribute's value and the literal. An
+// attribute without a value is only not equal to anything.
+func compare(op string, kind filterKind, actual filterValue, expected filterValue) bool {
+	if !actual.present {
+		return op == "ne"
+	}
+
+	var order int
+
+	switch kind {
+	case kindText:
+		a, b := strings.ToLower(actual.text), strings.ToLower(expected.text)
+
+		switch op {
+		case "co":
+			return strings.Contains(a, b)
+		case "sw":
+			return strings.HasPrefix(a, b)
+		case "ew":
+			return strings.HasSuffix(a, b)
+		}
+
+		order = strings.Compare(a, b)
+	case kindNumber:
+		order = cmp.Compare(actual.number, expected.number)
+	default:
+		order = actual.time.Compare(expected.time)
+	}
+
+	switch op {
+	case "eq":
+		return order == 0
+	case "ne":
+		return order != 0
+	case "gt":
+		return order > 0
+	case "ge":
+		return order >= 0
+	case "lt":
+		return order < 0
+	}
+
+	return order <= 0
+}
@@ -0,0 +1,110 @@
+package retrieve
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func filterVoters() []VoterDTO {
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	yes := NewVoterHistoryDTO(3, 1, start, start, start).WithChoice("yes")
+	no := NewVoterHistoryDTO(4, 2, start, start, start).WithChoice("no")
+	deleted := NewVoterHistoryDTO(3, 3, start, start, start).WithChoice("no").WithDeleted(start, "duplicate")
+
+	return []VoterDTO{
+		NewVoterDTO(1, "Anna Smith", "anna@drexel.edu", HistoryMap{3: yes}, start.Add(-time.Hour), start),
+		NewVoterDTO(2, "Ben Smithers", "ben@example.com", HistoryMap{3: deleted, 4: no}, start.Add(time.Hour), start).
+			WithProfile(time.Date(1990, time.May, 1, 0, 0, 0, 0, time.UTC), NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104"), AddressDTO{}, ""),
+		NewVoterDTO(3, "Carl Jones", "carl@drexel.edu", HistoryMap{}, start.Add(2*time.Hour), start),
+	}
+}
+
+func TestVoterFilter(t *testing.T) {
+	tests := map[string][]int{
+		`name co "SMITH"`: {1, 2},
+		`name co "smith" and created gt "2024-01-01T00:00:00Z" and history.poll_id eq 3`: {2},
+		`name sw "a" or email ew "EXAMPLE.COM"`:                                          {1, 2},
+		`not (email ew "drexel.edu")`:                                                    {2},
+		`id ge 2 and id lt 3`:                                                            {2},
+		`date_of_birth lt "2000-01-01"`:                                                  {2},
+		`residential_address.state eq "pa"`:                                              {2},
+		`residential_address.city pr or jurisdiction ne "x"`:                             {1, 2, 3},
+		`history.poll_id eq 3 and history.choice eq "no"`:                                {2},
+		`history[poll_id eq 3 and choice eq "no"]`:                                       {2},
+		`history[poll_id eq 3 and choice eq "no" and not (deleted pr)]`:                  {},
+		`not (history pr)`:                                                               {3},
+		`NAME CO "jones" OR (id eq 1 AND history.choice eq "yes")`:                       {1, 3},
+	}
+
+	for text, expected := range tests {
+		filter, err := ParseVoterFilter(text)
+		if !assert.NoError(t, err, text) {
+			continue
+		}
+
+		matched := []int{}
+		for _, voter := range filterVoters() {
+			if filter.Matches(voter) {
+				matched = append(matched, voter.GetId())
+			}
+		}
+
+		assert.Equal(t, expected, matched, text)
+	}
+}
+
+func TestHistoryFilter(t *testing.T) {
+	filter, err := ParseHistoryFilter(`choice eq "no" and delete_reason pr`)
+	assert.NoError(t, err)
+
+	voter := filterVoters()[1]
+	history := voter.GetHistory()
+	assert.True(t, filter.Matches(history[3]))
+	assert.False(t, filter.Matches(history[4]))
+
+	_, err = ParseHistoryFilter(`history.poll_id eq 3`)
+	assert.Equal(t, &FilterError{Position: 1, Token: "history.poll_id", Reason: "unknown attribute"}, err)
+}
+
+func TestFilterErrors(t *testing.T) {
+	tests := []struct {
+		text     string
+		position int
+		token    string
+	}{
+		{`nmae eq "x"`, 1, "nmae"},
+		{`name eq "x" and id eq "1"`, 23, `"1"`},
+		{`name like "x"`, 6, "like"},
+		{`id co 1`, 4, "co"},
+		{`created gt "yesterday"`, 12, `"yesterday"`},
+		{`date_of_birth eq "2000-13-01"`, 18, `"2000-13-01"`},
+		{`id eq 1.5`, 7, "1.5"},
+		{`name eq "x`, 9, `"x`},
+		{`(name pr`, 9, ""},
+		{`name pr name pr`, 9, "name"},
+		{`name eq "x" and`, 16, ""},
+		{`not name pr`, 5, "name"},
+		{`history.foo eq 1`, 1, "history.foo"},
+		{`history[poll_id eq 1`, 21, ""},
+		{`history eq 1`, 9, "eq"},
+		{`name eq "x" & id eq 1`, 13, "&"},
+		{`Ñame eq "x"`, 1, "Ñame"},
+	}
+
+	for _, test := range tests {
+		_, err := ParseVoterFilter(test.text)
+
+		var filterError *FilterError
+		if !assert.ErrorAs(t, err, &filterError, test.text) {
+			continue
+		}
+
+		assert.Equal(t, test.position, filterError.Position, test.text)
+		assert.Equal(t, test.token, filterError.Token, test.text)
+		assert.True(t, errors.Is(err, ErrInvalidFilter.Error()), test.text)
+	}
+}
@@ -2,10 +2,15 @@ package retrieve
 
 import (
 	"time"
+
+	"drexel.edu/voter-api/pkg/process"
 )
 
 type MockRepository struct{}
 
+// MockVoterTotal is how many voters ListVoters pages through.
+const MockVoterTotal = 3
+
 var refTime, _ = time.Parse(
 	time.RFC3339,
 	"2024-02-14T16:01:55Z")
@@ -17,6 +22,19 @@ var SampleVoterDTO = NewVoterDTO(
 	make(HistoryMap),
 	refTime,
 	refTime,
+).WithVersion(1).WithProfile(
+	time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC),
+	NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104"),
+	AddressDTO{},
+	"Philadelphia County",
+)
+
+var SampleRevisionDTO = NewRevisionDTO(
+	1,
+	refTime,
+	"create",
+	[]ChangeDTO{NewChangeDTO("name", "", "test")},
+	SampleVoterDTO,
 )
 
 var SampleVoterHistoryDTO = NewVoterHistoryDTO(
@@ -25,9 +43,52 @@ var SampleVoterHistoryDTO = NewVoterHistoryDTO(
 	refTime,
 	refTime,
 	refTime,
+).WithVersion(1)
+
+var SamplePollDTO = NewPollDTO(
+	1,
+	"test",
+	"a test poll",
+	refTime,
+	refTime.Add(12*time.Hour),
+	"open",
+	refTime,
+	refTime,
+).WithOptions([]string{"yes", "no"})
+
+// MockRankedPollId is the ranked-choice SampleRankedPollDTO and
+// MockSecretPollId is the secret SampleSecretPollDTO, every other id is
+// SamplePollDTO.
+const (
+	MockRankedPollId = 2
+	MockSecretPollId = 3
 )
 
-func (m *MockRepository) GetAllVoters() ([]VoterDTO, error) {
+var SampleRankedPollDTO = NewPollDTO(
+	MockRankedPollId,
+	"ranked",
+	"a ranked-choice poll",
+	refTime,
+	refTime.Add(12*time.Hour),
+	"open",
+	refTime,
+	refTime,
+).WithOptions([]string{"alice", "bob", "carol"}).WithRanked(true)
+
+var SampleSecretPollDTO = NewPollDTO(
+	MockSecretPollId,
+	"secret",
+	"a secret ballot",
+	refTime,
+	refTime.Add(12*time.Hour),
+	"open",
+	refTime,
+	refTime,
+).WithOptions([]string{"yes", "no"}).WithSecret(true)
+
+var SampleBallotDTO = NewBallotDTO(MockSecretPollId, "ABCDEFGHIJKLMNOP", "no", nil)
+
+func (m *MockRepository) GetAllVoters(includeDeleted bool) ([]VoterDTO, error) {
 
 	var voters []VoterDTO
 
@@ -38,12 +99,48 @@ func (m *MockRepository) GetAllVoters() ([]VoterDTO, error) {
 	return voters, nil
 }
 
-func (m *MockRepository) GetSingleVoter(id int) (VoterDTO, error) {
+// ListVoters pages through MockVoterTotal copies of SampleVoterDTO, ignoring
+// the filters and sort.
+func (m *MockRepository) ListVoters(query VoterQuery) (VoterPageDTO, error) {
 
-	return SampleVoterDTO, nil
+	var voters []VoterDTO
+
+	for i := query.Offset(); i < MockVoterTotal && i < query.Offset()+query.PerPage; i++ {
+		voters = append(voters, SampleVoterDTO)
+	}
+
+	return NewVoterPageDTO(voters, MockVoterTotal), nil
 }
 
-func (m *MockRepository) GetVoterHistory(id int) ([]VoterHistoryDTO, error) {
+// SearchVoters matches SampleVoterDTO, at distance 0, whatever the text.
+func (m *MockRepository) SearchVoters(query SearchQuery) ([]VoterMatchDTO, error) {
+
+	return []VoterMatchDTO{NewVoterMatchDTO(SampleVoterDTO, 0)}, nil
+}
+
+func (m *MockRepository) GetSingleVoter(id int, includeHistory bool) (VoterDTO, error) {
+
+	return mockVoter(includeHistory), nil
+}
+
+func (m *MockRepository) GetVoterByEmail(email string, includeHistory bool) (VoterDTO, error) {
+
+	return mockVoter(includeHistory), nil
+}
+
+// mockVoter is SampleVoterDTO, which only has SampleVoterHistoryDTO in its
+// history when the history is asked for.
+func mockVoter(includeHistory bool) VoterDTO {
+	voter := SampleVoterDTO
+
+	if includeHistory {
+		voter.history = HistoryMap{SampleVoterHistoryDTO.pollId: SampleVoterHistoryDTO}
+	}
+
+	return voter
+}
+
+func (m *MockRepository) GetVoterHistory(id int, includeDeleted bool) ([]VoterHistoryDTO, error) {
 
 	var history []VoterHistoryDTO
 
@@ -59,3 +156,89 @@ func (m *MockRepository) GetSingleEvent(voterId int, pollId int) (VoterHistoryDT
 
 	return SampleVoterHistoryDTO, nil
 }
+
+func (m *MockRepository) GetVoterRevisions(voterId int) ([]RevisionDTO, error) {
+
+	return []RevisionDTO{SampleRevisionDTO}, nil
+}
+
+func (m *MockRepository) GetVoterRevision(voterId int, revision int) (RevisionDTO, error) {
+
+	return SampleRevisionDTO, nil
+}
+
+func (m *MockRepository) GetAllPolls() ([]PollDTO, error) {
+
+	return []PollDTO{SamplePollDTO}, nil
+}
+
+func (m *MockRepository) GetSinglePoll(id int) (PollDTO, error) {
+
+	switch id {
+	case MockRankedPollId:
+		return SampleRankedPollDTO, nil
+	case MockSecretPollId:
+		return SampleSecretPollDTO, nil
+	}
+
+	return SamplePollDTO, nil
+}
+
+// GetPollVotes returns two votes for yes, one for no and one without a
+// choice. The ranked-choice poll has four ballots, two of them for carol
+// first.
+func (m *MockRepository) GetPollVotes(pollId int) ([]VoterHistoryDTO, error) {
+
+	if pollId == MockRankedPollId {
+		return []VoterHistoryDTO{
+			SampleVoterHistoryDTO.WithRanking([]string{"alice", "bob"}),
+			SampleVoterHistoryDTO.WithRanking([]string{"bob"}),
+			SampleVoterHistoryDTO.WithRanking([]string{"carol", "bob"}),
+			SampleVoterHistoryDTO.WithRanking([]string{"carol"}),
+		}, nil
+	}
+
+	return []VoterHistoryDTO{
+		SampleVoterHistoryDTO.WithChoice("yes"),
+		SampleVoterHistoryDTO.WithChoice("yes"),
+		SampleVoterHistoryDTO.WithChoice("no"),
+		SampleVoterHistoryDTO,
+	}, nil
+}
+
+func (m *MockRepository) CountVoters() (int, error) {
+
+	return 8, nil
+}
+
+// GetPollBallots returns three ballots for no. The secret poll has no
+// history with a choice on it.
+func (m *MockRepository) GetPollBallots(pollId int) ([]BallotDTO, error) {
+
+	return []BallotDTO{SampleBallotDTO, SampleBallotDTO, SampleBallotDTO}, nil
+}
+
+// GetBallot only finds SampleBallotDTO, so the receipt must be normalised
+// before it gets here.
+func (m *MockRepository) GetBallot(pollId int, receipt string) (BallotDTO, error) {
+
+	if receipt != SampleBallotDTO.GetReceipt() {
+		return BallotDTO{}, process.ErrUnknownBallot.Error()
+	}
+
+	return SampleBallotDTO, nil
+}
+
+// MockChainHead is the head of the mock audit chain.
+const MockChainHead = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+
+// VerifyChain reports a head that is not MockChainHead as missing from the
+// chain, so the head must be normalised before it gets here.
+func (m *MockRepository) VerifyChain(head string) (ChainReportDTO, error) {
+
+	if head != "" && head != MockChainHead {
+		return NewChainReportDTO(3, MockChainHead, []string{"the chain does not contain head " + head}), nil
+	}
+
+	return NewChainReportDTO(3, MockChainHead, []string{}), nil
+}
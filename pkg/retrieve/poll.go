@@ -0,0 +1,97 @@
+package retrieve
+
+import (
+	"time"
+)
+
+type PollDTO struct {
+	id          int
+	title       string
+	description string
+	opensAt     time.Time
+	closesAt    time.Time
+	status      string
+	options     []string
+	ranked      bool
+	secret      bool
+	created     time.Time
+	modified    time.Time
+}
+
+func NewPollDTO(id int, title string, description string, opensAt time.Time, closesAt time.Time, status string, created time.Time, modified time.Time) PollDTO {
+	return PollDTO{
+		id:          id,
+		title:       title,
+		description: description,
+		opensAt:     opensAt,
+		closesAt:    closesAt,
+		status:      status,
+		created:     created,
+		modified:    modified,
+	}
+}
+
+func (p *PollDTO) GetId() int {
+	return p.id
+}
+
+func (p *PollDTO) GetTitle() string {
+	return p.title
+}
+
+func (p *PollDTO) GetDescription() string {
+	return p.description
+}
+
+// GetOpensAt returns the zero time if the poll has not been scheduled.
+func (p *PollDTO) GetOpensAt() time.Time {
+	return p.opensAt
+}
+
+// GetClosesAt returns the zero time if the poll has not been scheduled.
+func (p *PollDTO) GetClosesAt() time.Time {
+	return p.closesAt
+}
+
+func (p *PollDTO) GetStatus() string {
+	return p.status
+}
+
+func (p *PollDTO) GetCreated() time.Time {
+	return p.created
+}
+
+func (p *PollDTO) GetModified() time.Time {
+	return p.modified
+}
+
+// WithOptions returns a copy of the poll with the options a voter can choose
+// from.
+func (p PollDTO) WithOptions(options []string) PollDTO {
+	p.options = options
+	return p
+}
+
+func (p *PollDTO) GetOptions() []string {
+	return p.options
+}
+
+// WithRanked returns a copy of the poll set to be ranked-choice.
+func (p PollDTO) WithRanked(ranked bool) PollDTO {
+	p.ranked = ranked
+	return p
+}
+
+func (p *PollDTO) IsRanked() bool {
+	return p.ranked
+}
+
+// WithSecret returns a copy of the poll set to be a secret ballot.
+func (p PollDTO) WithSecret(secret bool) PollDTO {
+	p.secret = secret
+	return p
+}
+
+func (p *PollDTO) IsSecret() bool {
+	return p.secret
+}
@@ -0,0 +1,207 @@
+package retrieve
+
+import (
+	"math"
+	"strings"
+)
+
+// PollService lists polls ordered by id.
+type PollService interface {
+	GetAllPolls() ([]PollDTO, error)
+	GetSinglePoll(id int) (PollDTO, error)
+	GetPollResults(id int) (ResultsDTO, error)
+	GetRunoffResults(id int) (RunoffResultsDTO, error)
+	GetBallot(pollId int, receipt string) (BallotDTO, error)
+}
+
+// GetPollVotes returns the history recorded for the poll, leaving out
+// deleted history and the history of deleted voters. CountVoters counts the
+// voters that have not been deleted.
+//
+// GetPollBallots returns every ballot cast in a secret poll, ordered by
+// receipt so the order they were cast in is not given away. GetBallot
+// returns process.ErrUnknownBallot if no ballot has the receipt.
+type PollRepository interface {
+	GetAllPolls() ([]PollDTO, error)
+	GetSinglePoll(id int) (PollDTO, error)
+	GetPollVotes(pollId int) ([]VoterHistoryDTO, error)
+	CountVoters() (int, error)
+	GetPollBallots(pollId int) ([]BallotDTO, error)
+	GetBallot(pollId int, receipt string) (BallotDTO, error)
+}
+
+type pollService struct {
+	r PollRepository
+}
+
+func NewPollService(r PollRepository) PollService {
+	return &pollService{r}
+}
+
+func (s *pollService) GetAllPolls() ([]PollDTO, error) {
+
+	polls, err := s.r.GetAllPolls()
+	if err != nil {
+		return nil, err
+	}
+
+	return polls, nil
+}
+
+func (s *pollService) GetSinglePoll(id int) (PollDTO, error) {
+
+	if id < 1 {
+		return PollDTO{}, ErrInvalidId.Error()
+	}
+
+	poll, err := s.r.GetSinglePoll(id)
+	if err != nil {
+		return PollDTO{}, err
+	}
+
+	return poll, nil
+}
+
+// GetPollResults tallies the votes for each of the poll's options, counting
+// the first preference of a ranked-choice vote. Turnout is measured against
+// the voters registered now.
+func (s *pollService) GetPollResults(id int) (ResultsDTO, error) {
+
+	if id < 1 {
+		return ResultsDTO{}, ErrInvalidId.Error()
+	}
+
+	poll, err := s.r.GetSinglePoll(id)
+	if err != nil {
+		return ResultsDTO{}, err
+	}
+
+	ballots, err := s.ballots(poll)
+	if err != nil {
+		return ResultsDTO{}, err
+	}
+
+	registeredVoters, err := s.r.CountVoters()
+	if err != nil {
+		return ResultsDTO{}, err
+	}
+
+	counts := make(map[string]int, len(poll.options))
+	for _, option := range poll.options {
+		counts[option] = 0
+	}
+
+	noChoice := 0
+
+	for _, ballot := range ballots {
+		if len(ballot) == 0 {
+			noChoice++
+			continue
+		}
+
+		if _, exists := counts[ballot[0]]; exists {
+			counts[ballot[0]]++
+		} else {
+			noChoice++
+		}
+	}
+
+	options := make([]OptionResultDTO, 0, len(poll.options))
+	for _, option := range poll.options {
+		options = append(options, NewOptionResultDTO(option, counts[option], percentage(counts[option], len(ballots))))
+	}
+
+	return NewResultsDTO(id, options, len(ballots), noChoice, registeredVoters, percentage(len(ballots), registeredVoters)), nil
+}
+
+// GetRunoffResults counts the poll's votes by instant-runoff. A vote that
+// is not ranked counts as a ranking of just its choice.
+func (s *pollService) GetRunoffResults(id int) (RunoffResultsDTO, error) {
+
+	if id < 1 {
+		return RunoffResultsDTO{}, ErrInvalidId.Error()
+	}
+
+	poll, err := s.r.GetSinglePoll(id)
+	if err != nil {
+		return RunoffResultsDTO{}, err
+	}
+
+	ballots, err := s.ballots(poll)
+	if err != nil {
+		return RunoffResultsDTO{}, err
+	}
+
+	registeredVoters, err := s.r.CountVoters()
+	if err != nil {
+		return RunoffResultsDTO{}, err
+	}
+
+	rounds, winner := instantRunoff(poll.options, ballots)
+
+	return NewRunoffResultsDTO(id, rounds, winner, len(ballots), registeredVoters, percentage(len(ballots), registeredVoters)), nil
+}
+
+// GetBallot looks up a ballot of a secret poll by the receipt the voter was
+// given. Receipts are matched ignoring case and surrounding spaces.
+func (s *pollService) GetBallot(pollId int, receipt string) (BallotDTO, error) {
+
+	if pollId < 1 {
+		return BallotDTO{}, ErrInvalidId.Error()
+	}
+
+	receipt = strings.ToUpper(strings.TrimSpace(receipt))
+	if receipt == "" {
+		return BallotDTO{}, ErrInvalidReceipt.Error()
+	}
+
+	ballot, err := s.r.GetBallot(pollId, receipt)
+	if err != nil {
+		return BallotDTO{}, err
+	}
+
+	return ballot, nil
+}
+
+// ballots returns the preferences of every vote in the poll. The ballots of
+// a secret poll are counted from the ballot store, where they stay even if
+// the voter or their history is deleted later since they cannot be traced
+// back.
+func (s *pollService) ballots(poll PollDTO) ([][]string, error) {
+
+	if poll.secret {
+		secretBallots, err := s.r.GetPollBallots(poll.id)
+		if err != nil {
+			return nil, err
+		}
+
+		ballots := make([][]string, 0, len(secretBallots))
+		for _, ballot := range secretBallots {
+			ballots = append(ballots, ballot.preferences())
+		}
+
+		return ballots, nil
+	}
+
+	votes, err := s.r.GetPollVotes(poll.id)
+	if err != nil {
+		return nil, err
+	}
+
+	ballots := make([][]string, 0, len(votes))
+	for _, vote := range votes {
+		ballots = append(ballots, vote.ballot())
+	}
+
+	return ballots, nil
+}
+
+// percentage returns part as a percentage of whole rounded to two decimal
+// places, or 0 if whole is 0.
+func percentage(part int, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+
+	return math.Round(float64(part)*10000/float64(whole)) / 100
+}
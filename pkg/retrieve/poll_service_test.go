@@ -0,0 +1,193 @@
+package retrieve
+
+import (
+	"testing"
+
+	"drexel.edu/voter-api/pkg/process"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPollService PollService
+
+func init() {
+	testPollService = NewPollService(&MockRepository{})
+}
+
+func TestGetAllPolls(t *testing.T) {
+	polls, err := testPollService.GetAllPolls()
+	assert.NoError(t, err)
+	assert.Equal(t, []PollDTO{SamplePollDTO}, polls)
+}
+
+func TestGetSinglePoll(t *testing.T) {
+	poll, err := testPollService.GetSinglePoll(SamplePollDTO.id)
+	assert.NoError(t, err)
+	assert.Equal(t, SamplePollDTO, poll)
+
+	_, err = testPollService.GetSinglePoll(0)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+}
+
+func TestGetPollResults(t *testing.T) {
+	results, err := testPollService.GetPollResults(SamplePollDTO.id)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 4, results.GetVotesCast())
+	assert.Equal(t, 1, results.GetNoChoice())
+	assert.Equal(t, 8, results.GetRegisteredVoters())
+	assert.Equal(t, 50.0, results.GetTurnout())
+	assert.Equal(t, []OptionResultDTO{
+		NewOptionResultDTO("yes", 2, 50),
+		NewOptionResultDTO("no", 1, 25),
+	}, results.GetOptions())
+
+	_, err = testPollService.GetPollResults(0)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+}
+
+func TestPercentage(t *testing.T) {
+	assert.Equal(t, 33.33, percentage(1, 3))
+	assert.Equal(t, 66.67, percentage(2, 3))
+	assert.Equal(t, 0.0, percentage(1, 0))
+}
+
+func TestGetRunoffResults(t *testing.T) {
+	results, err := testPollService.GetRunoffResults(MockRankedPollId)
+	assert.NoError(t, err)
+
+	//alice and bob tie for the fewest votes and bob is listed last
+	assert.Equal(t, "carol", results.GetWinner())
+	assert.Equal(t, 4, results.GetBallots())
+	assert.Equal(t, 50.0, results.GetTurnout())
+	assert.Equal(t, []RoundDTO{
+		NewRoundDTO(1, []OptionResultDTO{
+			NewOptionResultDTO("alice", 1, 25),
+			NewOptionResultDTO("bob", 1, 25),
+			NewOptionResultDTO("carol", 2, 50),
+		}, 0, "bob"),
+		NewRoundDTO(2, []OptionResultDTO{
+			NewOptionResultDTO("alice", 1, 33.33),
+			NewOptionResultDTO("carol", 2, 66.67),
+		}, 1, ""),
+	}, results.GetRounds())
+
+	//the first preference is counted without a runoff
+	plurality, err := testPollService.GetPollResults(MockRankedPollId)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, plurality.GetNoChoice())
+	assert.Equal(t, NewOptionResultDTO("carol", 2, 50), plurality.GetOptions()[2])
+
+	_, err = testPollService.GetRunoffResults(0)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+}
+
+func TestInstantRunoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []string
+		ballots    [][]string
+		winner     string
+		eliminated []string
+		exhausted  int
+	}{
+		{
+			name:    "majority in the first round",
+			options: []string{"a", "b", "c"},
+			ballots: [][]string{{"a"}, {"a", "b"}, {"b"}},
+			winner:  "a",
+		},
+		{
+			name:       "votes transfer to the next preference",
+			options:    []string{"a", "b", "c"},
+			ballots:    repeat(4, []string{"a"}, 3, []string{"b"}, 2, []string{"c", "b"}),
+			winner:     "b",
+			eliminated: []string{"c"},
+		},
+		{
+			name:       "exhausted ballots no longer count towards a majority",
+			options:    []string{"a", "b", "c"},
+			ballots:    repeat(4, []string{"a"}, 3, []string{"b"}, 2, []string{"c"}),
+			winner:     "a",
+			eliminated: []string{"c"},
+			exhausted:  2,
+		},
+		{
+			name:       "ties are broken by the round before",
+			options:    []string{"a", "c", "b", "d"},
+			ballots:    repeat(4, []string{"a"}, 3, []string{"b"}, 2, []string{"c"}, 1, []string{"d", "c"}),
+			winner:     "a",
+			eliminated: []string{"d", "c"},
+			exhausted:  3,
+		},
+		{
+			name:       "ties in every round go to the option listed last",
+			options:    []string{"a", "b"},
+			ballots:    [][]string{{"a"}, {"b"}},
+			winner:     "a",
+			eliminated: []string{"b"},
+			exhausted:  1,
+		},
+		{
+			name:    "no ballots",
+			options: []string{"a", "b"},
+			winner:  "",
+		},
+	}
+
+	for _, test := range tests {
+		rounds, winner := instantRunoff(test.options, test.ballots)
+
+		assert.Equal(t, test.winner, winner, test.name)
+		assert.Equal(t, len(test.eliminated)+1, len(rounds), test.name)
+
+		for i, eliminated := range test.eliminated {
+			assert.Equal(t, eliminated, rounds[i].GetEliminated(), test.name)
+		}
+
+		last := rounds[len(rounds)-1]
+		assert.Equal(t, test.exhausted, last.GetExhausted(), test.name)
+	}
+}
+
+// repeat builds ballots from pairs of a count and a ranking.
+func repeat(pairs ...any) [][]string {
+	var ballots [][]string
+
+	for i := 0; i < len(pairs); i += 2 {
+		for n := 0; n < pairs[i].(int); n++ {
+			ballots = append(ballots, pairs[i+1].([]string))
+		}
+	}
+
+	return ballots
+}
+
+func TestSecretBallotResults(t *testing.T) {
+	//the votes come from the ballots, not the history
+	results, err := testPollService.GetPollResults(MockSecretPollId)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, results.GetVotesCast())
+	assert.Equal(t, []OptionResultDTO{
+		NewOptionResultDTO("yes", 0, 0),
+		NewOptionResultDTO("no", 3, 100),
+	}, results.GetOptions())
+
+	runoff, err := testPollService.GetRunoffResults(MockSecretPollId)
+	assert.NoError(t, err)
+	assert.Equal(t, "no", runoff.GetWinner())
+}
+
+func TestGetBallot(t *testing.T) {
+	ballot, err := testPollService.GetBallot(MockSecretPollId, " abcdefghijklmnop ")
+	assert.NoError(t, err)
+	assert.Equal(t, SampleBallotDTO, ballot)
+
+	_, err = testPollService.GetBallot(MockSecretPollId, "AAAAAAAAAAAAAAAA")
+	assert.Equal(t, process.ErrUnknownBallot.Error(), err)
+
+	_, err = testPollService.GetBallot(MockSecretPollId, " ")
+	assert.Equal(t, ErrInvalidReceipt.Error(), err)
+
+	_, err = testPollService.GetBallot(0, "ABCDEFGHIJKLMNOP")
+	assert.Equal(t, ErrInvalidId.Error(), err)
+}
@@ -0,0 +1,102 @@
+package retrieve
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultPerPage is the page size when a query does not set one.
+	DefaultPerPage = 100
+	MaxPerPage     = 1000
+)
+
+// Fields voters can be sorted on. A sort is one of them, prefixed with - to
+// sort in descending order. Ties are always broken by id, ascending.
+const (
+	SortId       = "id"
+	SortName     = "name"
+	SortCreated  = "created"
+	SortModified = "modified"
+)
+
+// VoterQuery selects a page of voters for ListVoters. Filters left at their
+// zero value are not applied. Names are compared ignoring case. The After
+// times are inclusive and the Before times exclusive.
+type VoterQuery struct {
+	IncludeDeleted bool
+
+	// IncludeHistory loads each voter's history, which is left empty
+	// otherwise so long histories are not copied for nothing
+	IncludeHistory bool
+
+	NamePrefix  string
+	EmailDomain string
+
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+
+	// VotedInPoll only keeps voters with history for the poll that has not
+	// been deleted
+	VotedInPoll int
+
+	// Filter is a filter expression, see Filter. It is applied by the
+	// service rather than the repository, after the other filters
+	Filter string
+
+	Sort    string
+	Page    int
+	PerPage int
+}
+
+// SortField splits the sort into the field and whether it is descending.
+func (q VoterQuery) SortField() (string, bool) {
+	if strings.HasPrefix(q.Sort, "-") {
+		return strings.TrimPrefix(q.Sort, "-"), true
+	}
+
+	return q.Sort, false
+}
+
+// Offset is the number of matching voters before the page. It is
+// math.MaxInt, past the end of any list, if it does not fit in an int.
+func (q VoterQuery) Offset() int {
+	if q.PerPage > 0 && q.Page-1 > math.MaxInt/q.PerPage {
+		return math.MaxInt
+	}
+
+	return (q.Page - 1) * q.PerPage
+}
+
+// HistoryQuery selects the history of a voter for ListVoterHistory.
+type HistoryQuery struct {
+	IncludeDeleted bool
+
+	// Filter is a filter expression on each item, see Filter
+	Filter string
+}
+
+// VoterPageDTO is one page of the voters matching a query, and how many match
+// in total.
+type VoterPageDTO struct {
+	voters []VoterDTO
+	total  int
+}
+
+func NewVoterPageDTO(voters []VoterDTO, total int) VoterPageDTO {
+	return VoterPageDTO{
+		voters: voters,
+		total:  total,
+	}
+}
+
+func (p *VoterPageDTO) GetVoters() []VoterDTO {
+	return p.voters
+}
+
+func (p *VoterPageDTO) GetTotal() int {
+	return p.total
+}
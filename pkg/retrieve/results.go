@@ -0,0 +1,79 @@
+package retrieve
+
+// OptionResultDTO is the tally of a single poll option.
+type OptionResultDTO struct {
+	option     string
+	votes      int
+	percentage float64
+}
+
+func NewOptionResultDTO(option string, votes int, percentage float64) OptionResultDTO {
+	return OptionResultDTO{
+		option:     option,
+		votes:      votes,
+		percentage: percentage,
+	}
+}
+
+func (o *OptionResultDTO) GetOption() string {
+	return o.option
+}
+
+func (o *OptionResultDTO) GetVotes() int {
+	return o.votes
+}
+
+// GetPercentage returns the share of the votes cast, from 0 to 100.
+func (o *OptionResultDTO) GetPercentage() float64 {
+	return o.percentage
+}
+
+// ResultsDTO is the outcome of a poll. Options are in the order the poll
+// lists them.
+type ResultsDTO struct {
+	pollId           int
+	options          []OptionResultDTO
+	votesCast        int
+	noChoice         int
+	registeredVoters int
+	turnout          float64
+}
+
+func NewResultsDTO(pollId int, options []OptionResultDTO, votesCast int, noChoice int, registeredVoters int, turnout float64) ResultsDTO {
+	return ResultsDTO{
+		pollId:           pollId,
+		options:          options,
+		votesCast:        votesCast,
+		noChoice:         noChoice,
+		registeredVoters: registeredVoters,
+		turnout:          turnout,
+	}
+}
+
+func (r *ResultsDTO) GetPollId() int {
+	return r.pollId
+}
+
+func (r *ResultsDTO) GetOptions() []OptionResultDTO {
+	return r.options
+}
+
+func (r *ResultsDTO) GetVotesCast() int {
+	return r.votesCast
+}
+
+// GetNoChoice returns the number of votes that are not for any of the
+// poll's options, such as those recorded before the options were set.
+func (r *ResultsDTO) GetNoChoice() int {
+	return r.noChoice
+}
+
+func (r *ResultsDTO) GetRegisteredVoters() int {
+	return r.registeredVoters
+}
+
+// GetTurnout returns the votes cast as a percentage of the registered
+// voters.
+func (r *ResultsDTO) GetTurnout() float64 {
+	return r.turnout
+}
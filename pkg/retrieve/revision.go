@@ -0,0 +1,70 @@
+package retrieve
+
+import (
+	"time"
+)
+
+type ChangeDTO struct {
+	field string
+	old   string
+	new   string
+}
+
+func NewChangeDTO(field string, old string, new string) ChangeDTO {
+	return ChangeDTO{
+		field: field,
+		old:   old,
+		new:   new,
+	}
+}
+
+func (c *ChangeDTO) GetField() string {
+	return c.field
+}
+
+func (c *ChangeDTO) GetOld() string {
+	return c.old
+}
+
+func (c *ChangeDTO) GetNew() string {
+	return c.new
+}
+
+type RevisionDTO struct {
+	revision int
+	created  time.Time
+	action   string
+	changes  []ChangeDTO
+	voter    VoterDTO
+}
+
+func NewRevisionDTO(revision int, created time.Time, action string, changes []ChangeDTO, voter VoterDTO) RevisionDTO {
+	return RevisionDTO{
+		revision: revision,
+		created:  created,
+		action:   action,
+		changes:  changes,
+		voter:    voter,
+	}
+}
+
+func (r *RevisionDTO) GetRevision() int {
+	return r.revision
+}
+
+func (r *RevisionDTO) GetCreated() time.Time {
+	return r.created
+}
+
+func (r *RevisionDTO) GetAction() string {
+	return r.action
+}
+
+func (r *RevisionDTO) GetChanges() []ChangeDTO {
+	return r.changes
+}
+
+// GetVoter returns the voter and its history as they were after the change.
+func (r *RevisionDTO) GetVoter() VoterDTO {
+	return r.voter
+}
@@ -0,0 +1,195 @@
+package retrieve
+
+// RoundDTO is one round of an instant-runoff count. Tallies are for the
+// options still in the count, in the order the poll lists them, with
+// percentages of the ballots that have not been exhausted.
+type RoundDTO struct {
+	round      int
+	tallies    []OptionResultDTO
+	exhausted  int
+	eliminated string
+}
+
+func NewRoundDTO(round int, tallies []OptionResultDTO, exhausted int, eliminated string) RoundDTO {
+	return RoundDTO{
+		round:      round,
+		tallies:    tallies,
+		exhausted:  exhausted,
+		eliminated: eliminated,
+	}
+}
+
+func (r *RoundDTO) GetRound() int {
+	return r.round
+}
+
+func (r *RoundDTO) GetTallies() []OptionResultDTO {
+	return r.tallies
+}
+
+// GetExhausted returns the number of ballots that rank none of the options
+// still in the count.
+func (r *RoundDTO) GetExhausted() int {
+	return r.exhausted
+}
+
+// GetEliminated returns the option dropped at the end of the round, or
+// blank for the last round.
+func (r *RoundDTO) GetEliminated() string {
+	return r.eliminated
+}
+
+// RunoffResultsDTO is the outcome of an instant-runoff count.
+type RunoffResultsDTO struct {
+	pollId           int
+	rounds           []RoundDTO
+	winner           string
+	ballots          int
+	registeredVoters int
+	turnout          float64
+}
+
+func NewRunoffResultsDTO(pollId int, rounds []RoundDTO, winner string, ballots int, registeredVoters int, turnout float64) RunoffResultsDTO {
+	return RunoffResultsDTO{
+		pollId:           pollId,
+		rounds:           rounds,
+		winner:           winner,
+		ballots:          ballots,
+		registeredVoters: registeredVoters,
+		turnout:          turnout,
+	}
+}
+
+func (r *RunoffResultsDTO) GetPollId() int {
+	return r.pollId
+}
+
+func (r *RunoffResultsDTO) GetRounds() []RoundDTO {
+	return r.rounds
+}
+
+// GetWinner returns the winning option, or blank if every ballot was
+// exhausted before any option won.
+func (r *RunoffResultsDTO) GetWinner() string {
+	return r.winner
+}
+
+func (r *RunoffResultsDTO) GetBallots() int {
+	return r.ballots
+}
+
+func (r *RunoffResultsDTO) GetRegisteredVoters() int {
+	return r.registeredVoters
+}
+
+func (r *RunoffResultsDTO) GetTurnout() float64 {
+	return r.turnout
+}
+
+// instantRunoff counts the ballots in rounds. In each round a ballot counts
+// for its most preferred option still in the count, and a ballot with none
+// left is exhausted. An option with more than half of the ballots that are
+// not exhausted wins. Otherwise the option with the fewest votes is
+// eliminated and the next round is counted.
+//
+// A tie for the fewest votes is broken by the votes in the round before, and
+// so on back to the first round. Options still tied after that are
+// eliminated in reverse of the order the poll lists them, so the count always
+// comes out the same way.
+func instantRunoff(options []string, ballots [][]string) ([]RoundDTO, string) {
+	var rounds []RoundDTO
+	var previous []map[string]int
+
+	remaining := make(map[string]bool, len(options))
+	for _, option := range options {
+		remaining[option] = true
+	}
+
+	for number := 1; len(remaining) > 0; number++ {
+		counts := make(map[string]int, len(remaining))
+		exhausted := 0
+
+		for _, ballot := range ballots {
+			if option, ok := topPreference(ballot, remaining); ok {
+				counts[option]++
+			} else {
+				exhausted++
+			}
+		}
+
+		continuing := len(ballots) - exhausted
+
+		var tallies []OptionResultDTO
+		leader, lead := "", 0
+
+		for _, option := range options {
+			if !remaining[option] {
+				continue
+			}
+
+			tallies = append(tallies, NewOptionResultDTO(option, counts[option], percentage(counts[option], continuing)))
+
+			if counts[option] > lead {
+				leader, lead = option, counts[option]
+			}
+		}
+
+		if continuing == 0 {
+			return append(rounds, NewRoundDTO(number, tallies, exhausted, "")), ""
+		}
+
+		if lead*2 > continuing {
+			return append(rounds, NewRoundDTO(number, tallies, exhausted, "")), leader
+		}
+
+		previous = append(previous, counts)
+		loser := fewestVotes(options, remaining, previous)
+
+		delete(remaining, loser)
+		rounds = append(rounds, NewRoundDTO(number, tallies, exhausted, loser))
+	}
+
+	return rounds, ""
+}
+
+// topPreference returns the first option on the ballot that is still in the
+// count.
+func topPreference(ballot []string, remaining map[string]bool) (string, bool) {
+	for _, option := range ballot {
+		if remaining[option] {
+			return option, true
+		}
+	}
+
+	return "", false
+}
+
+// fewestVotes picks the option to eliminate. rounds holds the counts of every
+// round so far, the current one last.
+func fewestVotes(options []string, remaining map[string]bool, rounds []map[string]int) string {
+	var tied []string
+	for _, option := range options {
+		if remaining[option] {
+			tied = append(tied, option)
+		}
+	}
+
+	for i := len(rounds) - 1; i >= 0 && len(tied) > 1; i-- {
+		fewest := -1
+		for _, option := range tied {
+			if fewest == -1 || rounds[i][option] < fewest {
+				fewest = rounds[i][option]
+			}
+		}
+
+		var next []string
+		for _, option := range tied {
+			if rounds[i][option] == fewest {
+				next = append(next, option)
+			}
+		}
+		tied = next
+	}
+
+	return tied[len(tied)-1]
+}
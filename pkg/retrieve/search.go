@@ -0,0 +1,38 @@
+package retrieve
+
+const (
+	// DefaultSearchLimit is the number of matches returned when a search does
+	// not set a limit.
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// SearchQuery looks voters up by name for SearchVoters. The text is split
+// into words that are matched ignoring case and diacritics, or by how they
+// sound, so misspelled names are still found.
+type SearchQuery struct {
+	Text  string
+	Limit int
+}
+
+// VoterMatchDTO is a voter found by a search, with the edit distance between
+// its name and the search text. The voter's history is left empty.
+type VoterMatchDTO struct {
+	voter    VoterDTO
+	distance int
+}
+
+func NewVoterMatchDTO(voter VoterDTO, distance int) VoterMatchDTO {
+	return VoterMatchDTO{
+		voter:    voter,
+		distance: distance,
+	}
+}
+
+func (m *VoterMatchDTO) GetVoter() VoterDTO {
+	return m.voter
+}
+
+func (m *VoterMatchDTO) GetDistance() int {
+	return m.distance
+}
@@ -1,17 +1,40 @@
 package retrieve
 
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Deleted voters and history are left out of the lists unless includeDeleted
+// is set, and are never returned by the single record lookups.
 type Service interface {
-	GetAllVoters() ([]VoterDTO, error)
-	GetSingleVoter(id int) (VoterDTO, error)
-	GetVoterHistory(id int) ([]VoterHistoryDTO, error)
+	GetAllVoters(includeDeleted bool) ([]VoterDTO, error)
+	ListVoters(query VoterQuery) (VoterPageDTO, error)
+	SearchVoters(query SearchQuery) ([]VoterMatchDTO, error)
+	GetSingleVoter(id int, includeHistory bool) (VoterDTO, error)
+	GetVoterByEmail(email string, includeHistory bool) (VoterDTO, error)
+	GetVoterHistory(id int, includeDeleted bool) ([]VoterHistoryDTO, error)
+	ListVoterHistory(id int, query HistoryQuery) ([]VoterHistoryDTO, error)
 	GetSingleEvent(voterId int, pollId int) (VoterHistoryDTO, error)
+	GetVoterRevisions(voterId int) ([]RevisionDTO, error)
+	GetVoterRevision(voterId int, revision int) (RevisionDTO, error)
 }
 
+// ListVoters filters, sorts and pages the voters itself, so a backend can
+// avoid loading voters that are not on the page. The query has been checked
+// and has its defaults filled in. SearchVoters returns the closest matches
+// first and never returns deleted voters.
 type Repository interface {
-	GetAllVoters() ([]VoterDTO, error)
-	GetSingleVoter(id int) (VoterDTO, error)
-	GetVoterHistory(id int) ([]VoterHistoryDTO, error)
+	GetAllVoters(includeDeleted bool) ([]VoterDTO, error)
+	ListVoters(query VoterQuery) (VoterPageDTO, error)
+	SearchVoters(query SearchQuery) ([]VoterMatchDTO, error)
+	GetSingleVoter(id int, includeHistory bool) (VoterDTO, error)
+	GetVoterByEmail(email string, includeHistory bool) (VoterDTO, error)
+	GetVoterHistory(id int, includeDeleted bool) ([]VoterHistoryDTO, error)
 	GetSingleEvent(voterId int, pollId int) (VoterHistoryDTO, error)
+	GetVoterRevisions(voterId int) ([]RevisionDTO, error)
+	GetVoterRevision(voterId int, revision int) (RevisionDTO, error)
 }
 
 type service struct {
@@ -22,9 +45,9 @@ func NewService(r Repository) Service {
 	return &service{r}
 }
 
-func (s *service) GetAllVoters() ([]VoterDTO, error) {
+func (s *service) GetAllVoters(includeDeleted bool) ([]VoterDTO, error) {
 
-	voters, err := s.r.GetAllVoters()
+	voters, err := s.r.GetAllVoters(includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -32,13 +55,195 @@ func (s *service) GetAllVoters() ([]VoterDTO, error) {
 	return voters, nil
 }
 
-func (s *service) GetSingleVoter(id int) (VoterDTO, error) {
+// ListVoters returns a page of the voters matching the query, sorted by id
+// unless the query sets another sort.
+func (s *service) ListVoters(query VoterQuery) (VoterPageDTO, error) {
+
+	if query.Sort == "" {
+		query.Sort = SortId
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PerPage == 0 {
+		query.PerPage = DefaultPerPage
+	}
+
+	if field, _ := query.SortField(); field != SortId && field != SortName && field != SortCreated && field != SortModified {
+		return VoterPageDTO{}, ErrInvalidSort.Error()
+	}
+	if query.PerPage < 1 || query.PerPage > MaxPerPage {
+		return VoterPageDTO{}, ErrInvalidPerPage.Error()
+	}
+	// the offset of the page must fit in an int
+	if query.Page < 1 || query.Page > math.MaxInt/query.PerPage {
+		return VoterPageDTO{}, ErrInvalidPage.Error()
+	}
+	if query.VotedInPoll < 0 {
+		return VoterPageDTO{}, ErrInvalidId.Error()
+	}
+
+	query.NamePrefix = strings.TrimSpace(query.NamePrefix)
+	query.EmailDomain = strings.TrimPrefix(strings.TrimSpace(query.EmailDomain), "@")
+
+	if strings.TrimSpace(query.Filter) != "" {
+		return s.listFilteredVoters(query)
+	}
+
+	page, err := s.r.ListVoters(query)
+	if err != nil {
+		return VoterPageDTO{}, err
+	}
+
+	return page, nil
+}
+
+// listFilteredVoters applies a filter expression, which the repositories do
+// not understand. Every voter matching the other filters, and the parts of
+// the filter that narrowVoterQuery can hand to the repository, is loaded in
+// order so the filter is evaluated before the page is cut. History is only
+// loaded if the filter or the query needs it.
+func (s *service) listFilteredVoters(query VoterQuery) (VoterPageDTO, error) {
+
+	filter, err := ParseVoterFilter(query.Filter)
+	if err != nil {
+		return VoterPageDTO{}, err
+	}
+
+	all := narrowVoterQuery(query, filter.hints)
+	all.Page = 1
+	all.PerPage = math.MaxInt
+	all.IncludeHistory = query.IncludeHistory || filter.multiValued
+
+	page, err := s.r.ListVoters(all)
+	if err != nil {
+		return VoterPageDTO{}, err
+	}
+
+	var matched []VoterDTO
+
+	for _, voter := range page.GetVoters() {
+		if !filter.Matches(voter) {
+			continue
+		}
+
+		if !query.IncludeHistory {
+			voter.history = make(HistoryMap)
+		}
+
+		matched = append(matched, voter)
+	}
+
+	start := min(max(query.Offset(), 0), len(matched))
+	end := start + min(query.PerPage, len(matched)-start)
+
+	return NewVoterPageDTO(matched[start:end], len(matched)), nil
+}
+
+// narrowVoterQuery adds the filter's hints on names and times to the query,
+// so the repository leaves out voters that cannot pass the filter. The query
+// may still let through voters the filter does not, it is evaluated on what
+// is loaded regardless.
+func narrowVoterQuery(query VoterQuery, hints []filterHint) VoterQuery {
+
+	for _, hint := range hints {
+		var after, before *time.Time
+
+		switch hint.path {
+		case "created":
+			after, before = &query.CreatedAfter, &query.CreatedBefore
+		case "modified":
+			after, before = &query.ModifiedAfter, &query.ModifiedBefore
+		case "name":
+			if hint.op == "sw" && query.NamePrefix == "" {
+				query.NamePrefix = hint.value.text
+			}
+			continue
+		default:
+			continue
+		}
+
+		// the After times are inclusive and the Before times exclusive
+		switch hint.op {
+		case "eq":
+			*after = later(*after, hint.value.time)
+			*before = earlier(*before, hint.value.time.Add(time.Nanosecond))
+		case "gt", "ge":
+			*after = later(*after, hint.value.time)
+		case "lt":
+			*before = earlier(*before, hint.value.time)
+		case "le":
+			*before = earlier(*before, hint.value.time.Add(time.Nanosecond))
+		}
+	}
+
+	return query
+}
+
+// later and earlier treat the zero time as no bound.
+func later(a time.Time, b time.Time) time.Time {
+	if a.IsZero() || b.After(a) {
+		return b
+	}
+	return a
+}
+
+func earlier(a time.Time, b time.Time) time.Time {
+	if a.IsZero() || b.Before(a) {
+		return b
+	}
+	return a
+}
+
+// SearchVoters returns the voters whose names best match the text, closest
+// first.
+func (s *service) SearchVoters(query SearchQuery) ([]VoterMatchDTO, error) {
+
+	query.Text = strings.TrimSpace(query.Text)
+	if query.Text == "" {
+		return nil, ErrInvalidSearch.Error()
+	}
+
+	if query.Limit == 0 {
+		query.Limit = DefaultSearchLimit
+	}
+	if query.Limit < 1 || query.Limit > MaxSearchLimit {
+		return nil, ErrInvalidSearchLimit.Error()
+	}
+
+	matches, err := s.r.SearchVoters(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// GetSingleVoter looks up a voter by id. Its history is only copied if
+// includeHistory is set.
+func (s *service) GetSingleVoter(id int, includeHistory bool) (VoterDTO, error) {
 
 	if id < 1 {
 		return VoterDTO{}, ErrInvalidId.Error()
 	}
 
-	voter, err := s.r.GetSingleVoter(id)
+	voter, err := s.r.GetSingleVoter(id, includeHistory)
+	if err != nil {
+		return VoterDTO{}, err
+	}
+
+	return voter, nil
+}
+
+// GetVoterByEmail looks up a voter by email address, ignoring case. Its
+// history is only copied if includeHistory is set.
+func (s *service) GetVoterByEmail(email string, includeHistory bool) (VoterDTO, error) {
+
+	if strings.TrimSpace(email) == "" {
+		return VoterDTO{}, ErrInvalidEmail.Error()
+	}
+
+	voter, err := s.r.GetVoterByEmail(email, includeHistory)
 	if err != nil {
 		return VoterDTO{}, err
 	}
@@ -46,13 +251,13 @@ func (s *service) GetSingleVoter(id int) (VoterDTO, error) {
 	return voter, nil
 }
 
-func (s *service) GetVoterHistory(id int) ([]VoterHistoryDTO, error) {
+func (s *service) GetVoterHistory(id int, includeDeleted bool) ([]VoterHistoryDTO, error) {
 
 	if id < 1 {
 		return nil, ErrInvalidId.Error()
 	}
 
-	history, err := s.r.GetVoterHistory(id)
+	history, err := s.r.GetVoterHistory(id, includeDeleted)
 	if err != nil {
 		return nil, err
 	}
@@ -60,6 +265,39 @@ func (s *service) GetVoterHistory(id int) ([]VoterHistoryDTO, error) {
 	return history, nil
 }
 
+// ListVoterHistory returns the voter's history that passes the query's
+// filter expression, if it has one.
+func (s *service) ListVoterHistory(id int, query HistoryQuery) ([]VoterHistoryDTO, error) {
+
+	var filter *Filter[VoterHistoryDTO]
+
+	if strings.TrimSpace(query.Filter) != "" {
+		var err error
+		if filter, err = ParseHistoryFilter(query.Filter); err != nil {
+			return nil, err
+		}
+	}
+
+	history, err := s.GetVoterHistory(id, query.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	if filter == nil {
+		return history, nil
+	}
+
+	var matched []VoterHistoryDTO
+
+	for _, item := range history {
+		if filter.Matches(item) {
+			matched = append(matched, item)
+		}
+	}
+
+	return matched, nil
+}
+
 func (s *service) GetSingleEvent(voterId int, pollId int) (VoterHistoryDTO, error) {
 
 	if voterId < 1 || pollId < 1 {
@@ -73,3 +311,33 @@ func (s *service) GetSingleEvent(voterId int, pollId int) (VoterHistoryDTO, erro
 
 	return history, nil
 }
+
+// GetVoterRevisions lists every stored version of the voter, oldest first.
+// Revisions are kept for deleted voters as well.
+func (s *service) GetVoterRevisions(voterId int) ([]RevisionDTO, error) {
+
+	if voterId < 1 {
+		return nil, ErrInvalidId.Error()
+	}
+
+	revisions, err := s.r.GetVoterRevisions(voterId)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+func (s *service) GetVoterRevision(voterId int, revision int) (RevisionDTO, error) {
+
+	if voterId < 1 || revision < 1 {
+		return RevisionDTO{}, ErrInvalidId.Error()
+	}
+
+	item, err := s.r.GetVoterRevision(voterId, revision)
+	if err != nil {
+		return RevisionDTO{}, err
+	}
+
+	return item, nil
+}
@@ -1,7 +1,9 @@
 package retrieve
 
 import (
+	"math"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -13,7 +15,7 @@ func init() {
 }
 
 func TestGetAllVoters(t *testing.T) {
-	voters, err := testService.GetAllVoters()
+	voters, err := testService.GetAllVoters(false)
 	assert.NoError(t, err)
 
 	for _, item := range voters {
@@ -21,38 +23,144 @@ func TestGetAllVoters(t *testing.T) {
 	}
 }
 
+func TestListVoters(t *testing.T) {
+	page, err := testService.ListVoters(VoterQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, MockVoterTotal, page.GetTotal())
+	assert.Equal(t, MockVoterTotal, len(page.GetVoters()))
+
+	page, err = testService.ListVoters(VoterQuery{Sort: "-created", Page: 2, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page.GetVoters()))
+
+	_, err = testService.ListVoters(VoterQuery{Sort: "email"})
+	assert.Equal(t, ErrInvalidSort.Error(), err)
+
+	_, err = testService.ListVoters(VoterQuery{Page: -1})
+	assert.Equal(t, ErrInvalidPage.Error(), err)
+
+	_, err = testService.ListVoters(VoterQuery{PerPage: MaxPerPage + 1})
+	assert.Equal(t, ErrInvalidPerPage.Error(), err)
+
+	//the offset of the page would overflow
+	_, err = testService.ListVoters(VoterQuery{Page: math.MaxInt, PerPage: 2})
+	assert.Equal(t, ErrInvalidPage.Error(), err)
+}
+
+func TestListVotersFilter(t *testing.T) {
+	page, err := testService.ListVoters(VoterQuery{Filter: `name eq "TEST" and jurisdiction sw "phila"`, PerPage: 2, Page: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, MockVoterTotal, page.GetTotal())
+	assert.Equal(t, 1, len(page.GetVoters()))
+
+	page, err = testService.ListVoters(VoterQuery{Filter: `name ne "test"`})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, page.GetTotal())
+	assert.Empty(t, page.GetVoters())
+
+	_, err = testService.ListVoters(VoterQuery{Filter: `name eq`})
+	assert.ErrorIs(t, err, ErrInvalidFilter.Error())
+}
+
+// recordingRepository keeps the queries ListVoters is called with.
+type recordingRepository struct {
+	MockRepository
+	queries []VoterQuery
+}
+
+func (r *recordingRepository) ListVoters(query VoterQuery) (VoterPageDTO, error) {
+	r.queries = append(r.queries, query)
+	return r.MockRepository.ListVoters(query)
+}
+
+func TestListVotersFilterNarrowsQuery(t *testing.T) {
+	repository := &recordingRepository{}
+	filtered := &service{repository}
+
+	_, err := filtered.ListVoters(VoterQuery{Filter: `name sw "te" and created ge "2024-01-01T00:00:00Z" and created lt "2024-03-01T00:00:00Z" and id gt 0`})
+	assert.NoError(t, err)
+
+	query := repository.queries[0]
+	assert.False(t, query.IncludeHistory)
+	assert.Equal(t, "te", query.NamePrefix)
+	assert.Equal(t, time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC), query.CreatedAfter)
+	assert.Equal(t, time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), query.CreatedBefore)
+
+	//nothing can be handed down when the filter has an or at the top
+	_, err = filtered.ListVoters(VoterQuery{Filter: `name sw "te" or history.poll_id eq 1`})
+	assert.NoError(t, err)
+
+	query = repository.queries[1]
+	assert.True(t, query.IncludeHistory)
+	assert.Equal(t, "", query.NamePrefix)
+
+	//an offset past the end is an empty page
+	page, err := filtered.listFilteredVoters(VoterQuery{Filter: "id pr", Page: math.MaxInt, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Empty(t, page.GetVoters())
+	assert.Equal(t, MockVoterTotal, page.GetTotal())
+
+	_, err = filtered.ListVoters(VoterQuery{Filter: "id pr", Page: math.MaxInt, PerPage: 2})
+	assert.Equal(t, ErrInvalidPage.Error(), err)
+}
+
+func TestSearchVoters(t *testing.T) {
+	matches, err := testService.SearchVoters(SearchQuery{Text: " test "})
+	assert.NoError(t, err)
+	assert.Equal(t, []VoterMatchDTO{NewVoterMatchDTO(SampleVoterDTO, 0)}, matches)
+
+	_, err = testService.SearchVoters(SearchQuery{Text: "  "})
+	assert.Equal(t, ErrInvalidSearch.Error(), err)
+
+	_, err = testService.SearchVoters(SearchQuery{Text: "test", Limit: MaxSearchLimit + 1})
+	assert.Equal(t, ErrInvalidSearchLimit.Error(), err)
+}
+
 func TestGetSingleVoter(t *testing.T) {
-	voter, err := testService.GetSingleVoter(SampleVoterDTO.id)
+	voter, err := testService.GetSingleVoter(SampleVoterDTO.id, false)
 	assert.NoError(t, err)
 	assert.Equal(t, SampleVoterDTO, voter)
+
+	voter, err = testService.GetSingleVoter(SampleVoterDTO.id, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(voter.GetHistory()))
+}
+
+func TestGetVoterByEmail(t *testing.T) {
+	voter, err := testService.GetVoterByEmail(SampleVoterDTO.email, false)
+	assert.NoError(t, err)
+	assert.Equal(t, SampleVoterDTO, voter)
+
+	_, err = testService.GetVoterByEmail(" ", true)
+	assert.Equal(t, ErrInvalidEmail.Error(), err)
 }
 
 func TestErrorOnZeroValueId(t *testing.T) {
-	_, err := testService.GetSingleVoter(0)
+	_, err := testService.GetSingleVoter(0, true)
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 }
 
 func TestErrorOnNegativeValueId(t *testing.T) {
-	_, err := testService.GetSingleVoter(-1)
+	_, err := testService.GetSingleVoter(-1, true)
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 }
 
 func TestErrorOnZeroValueIdVoterHistory(t *testing.T) {
-	_, err := testService.GetVoterHistory(0)
+	_, err := testService.GetVoterHistory(0, false)
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 }
 
 func TestErrorOnNegativeValueIdGetVoterHistory(t *testing.T) {
-	_, err := testService.GetVoterHistory(-1)
+	_, err := testService.GetVoterHistory(-1, false)
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 }
 
 func TestGetVoterHistory(t *testing.T) {
-	history, err := testService.GetVoterHistory(SampleVoterDTO.id)
+	history, err := testService.GetVoterHistory(SampleVoterDTO.id, false)
 	assert.NoError(t, err)
 
 	for _, item := range history {
@@ -60,14 +168,27 @@ func TestGetVoterHistory(t *testing.T) {
 	}
 }
 
+func TestListVoterHistory(t *testing.T) {
+	history, err := testService.ListVoterHistory(SampleVoterDTO.id, HistoryQuery{Filter: "poll_id eq 1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(history))
+
+	history, err = testService.ListVoterHistory(SampleVoterDTO.id, HistoryQuery{Filter: "poll_id gt 1"})
+	assert.NoError(t, err)
+	assert.Empty(t, history)
+
+	_, err = testService.ListVoterHistory(SampleVoterDTO.id, HistoryQuery{Filter: "poll eq 1"})
+	assert.ErrorIs(t, err, ErrInvalidFilter.Error())
+}
+
 func TestErroOnZeroValueIdGetVoterHistory(t *testing.T) {
-	_, err := testService.GetVoterHistory(0)
+	_, err := testService.GetVoterHistory(0, false)
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 }
 
 func TestErrorOnNegativeValueGetVoterHistory(t *testing.T) {
-	_, err := testService.GetVoterHistory(-1)
+	_, err := testService.GetVoterHistory(-1, false)
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 }
@@ -97,3 +218,24 @@ func TestErrorOnNegativeIdGetSingleEvent(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, ErrInvalidId.Error(), err)
 }
+
+func TestGetVoterRevisions(t *testing.T) {
+	revisions, err := testService.GetVoterRevisions(SampleVoterDTO.id)
+	assert.NoError(t, err)
+	assert.Equal(t, []RevisionDTO{SampleRevisionDTO}, revisions)
+
+	_, err = testService.GetVoterRevisions(0)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+}
+
+func TestGetVoterRevision(t *testing.T) {
+	revision, err := testService.GetVoterRevision(SampleVoterDTO.id, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, SampleRevisionDTO, revision)
+
+	_, err = testService.GetVoterRevision(SampleVoterDTO.id, 0)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+
+	_, err = testService.GetVoterRevision(-1, 1)
+	assert.Equal(t, ErrInvalidId.Error(), err)
+}
@@ -13,6 +13,25 @@ type VoterDTO struct {
 	history  HistoryMap
 	created  time.Time
 	modified time.Time
+
+	deleted      time.Time
+	deleteReason string
+
+	version int
+
+	dateOfBirth        time.Time
+	residentialAddress AddressDTO
+	mailingAddress     AddressDTO
+	jurisdiction       string
+}
+
+// AddressDTO is a postal address. The zero AddressDTO means the voter has no
+// address of that kind.
+type AddressDTO struct {
+	street     string
+	city       string
+	state      string
+	postalCode string
 }
 
 func NewVoterDTO(id int, name string, email string, history HistoryMap, created time.Time, modified time.Time) VoterDTO {
@@ -26,6 +45,15 @@ func NewVoterDTO(id int, name string, email string, history HistoryMap, created
 	}
 }
 
+func NewAddressDTO(street string, city string, state string, postalCode string) AddressDTO {
+	return AddressDTO{
+		street:     street,
+		city:       city,
+		state:      state,
+		postalCode: postalCode,
+	}
+}
+
 func (v *VoterDTO) GetId() int {
 	return v.id
 }
@@ -48,3 +76,81 @@ func (v *VoterDTO) GetCreated() time.Time {
 func (v *VoterDTO) GetModified() time.Time {
 	return v.modified
 }
+
+// WithDeleted returns a copy of the voter marked as deleted at the given time.
+func (v VoterDTO) WithDeleted(deleted time.Time, reason string) VoterDTO {
+	v.deleted = deleted
+	v.deleteReason = reason
+	return v
+}
+
+func (v *VoterDTO) IsDeleted() bool {
+	return !v.deleted.IsZero()
+}
+
+func (v *VoterDTO) GetDeleted() time.Time {
+	return v.deleted
+}
+
+func (v *VoterDTO) GetDeleteReason() string {
+	return v.deleteReason
+}
+
+// WithVersion returns a copy of the voter with the given version.
+func (v VoterDTO) WithVersion(version int) VoterDTO {
+	v.version = version
+	return v
+}
+
+// GetVersion returns the version of the voter, starting at 1. It goes up
+// whenever the voter or any of its history changes.
+func (v *VoterDTO) GetVersion() int {
+	return v.version
+}
+
+// WithProfile returns a copy of the voter with the given date of birth,
+// addresses and jurisdiction.
+func (v VoterDTO) WithProfile(dateOfBirth time.Time, residentialAddress AddressDTO, mailingAddress AddressDTO, jurisdiction string) VoterDTO {
+	v.dateOfBirth = dateOfBirth
+	v.residentialAddress = residentialAddress
+	v.mailingAddress = mailingAddress
+	v.jurisdiction = jurisdiction
+	return v
+}
+
+// GetDateOfBirth returns the zero time if the voter has no date of birth.
+func (v *VoterDTO) GetDateOfBirth() time.Time {
+	return v.dateOfBirth
+}
+
+func (v *VoterDTO) GetResidentialAddress() AddressDTO {
+	return v.residentialAddress
+}
+
+func (v *VoterDTO) GetMailingAddress() AddressDTO {
+	return v.mailingAddress
+}
+
+func (v *VoterDTO) GetJurisdiction() string {
+	return v.jurisdiction
+}
+
+func (a *AddressDTO) GetStreet() string {
+	return a.street
+}
+
+func (a *AddressDTO) GetCity() string {
+	return a.city
+}
+
+func (a *AddressDTO) GetState() string {
+	return a.state
+}
+
+func (a *AddressDTO) GetPostalCode() string {
+	return a.postalCode
+}
+
+func (a *AddressDTO) IsZero() bool {
+	return *a == AddressDTO{}
+}
@@ -8,8 +8,15 @@ type VoterHistoryDTO struct {
 	pollId   int
 	voteId   int
 	voteDate time.Time
+	choice   string
+	ranking  []string
 	created  time.Time
 	modified time.Time
+
+	deleted      time.Time
+	deleteReason string
+
+	version int
 }
 
 func NewVoterHistoryDTO(id int, voteId int, voteDate time.Time, created time.Time, modified time.Time) VoterHistoryDTO {
@@ -41,3 +48,70 @@ func (v *VoterHistoryDTO) GetCreated() time.Time {
 func (v *VoterHistoryDTO) GetModified() time.Time {
 	return v.modified
 }
+
+// WithDeleted returns a copy of the history marked as deleted at the given
+// time.
+func (v VoterHistoryDTO) WithDeleted(deleted time.Time, reason string) VoterHistoryDTO {
+	v.deleted = deleted
+	v.deleteReason = reason
+	return v
+}
+
+func (v *VoterHistoryDTO) IsDeleted() bool {
+	return !v.deleted.IsZero()
+}
+
+func (v *VoterHistoryDTO) GetDeleted() time.Time {
+	return v.deleted
+}
+
+func (v *VoterHistoryDTO) GetDeleteReason() string {
+	return v.deleteReason
+}
+
+// WithVersion returns a copy of the history with the given version.
+func (v VoterHistoryDTO) WithVersion(version int) VoterHistoryDTO {
+	v.version = version
+	return v
+}
+
+// GetVersion returns the number of times the history has been changed,
+// starting at 1.
+func (v *VoterHistoryDTO) GetVersion() int {
+	return v.version
+}
+
+// WithChoice returns a copy of the history with the option the voter chose.
+func (v VoterHistoryDTO) WithChoice(choice string) VoterHistoryDTO {
+	v.choice = choice
+	return v
+}
+
+func (v *VoterHistoryDTO) GetChoice() string {
+	return v.choice
+}
+
+// WithRanking returns a copy of the history with the voter's ranking of the
+// options of a ranked-choice poll, most preferred first.
+func (v VoterHistoryDTO) WithRanking(ranking []string) VoterHistoryDTO {
+	v.ranking = ranking
+	return v
+}
+
+func (v *VoterHistoryDTO) GetRanking() []string {
+	return v.ranking
+}
+
+// ballot returns the options the vote is for in order of preference: the
+// ranking of a ranked-choice vote, otherwise just the choice.
+func (v *VoterHistoryDTO) ballot() []string {
+	if len(v.ranking) > 0 {
+		return v.ranking
+	}
+
+	if v.choice != "" {
+		return []string{v.choice}
+	}
+
+	return nil
+}
@@ -0,0 +1,269 @@
+package chain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"drexel.edu/voter-api/pkg/storage/revision"
+)
+
+// ActionRestoreBackup marks the entries written when the database is
+// restored from a backup, one for each record the backup changed.
+const ActionRestoreBackup revision.Action = "restore_backup"
+
+// Key identifies a single voter history record.
+type Key struct {
+	VoterId int
+	PollId  int
+}
+
+// Entry is a link in the audit chain. Each entry holds the state of one
+// voter history record after a change and the hash of the entry before it,
+// so editing, removing or reordering an entry breaks every hash after it.
+// The hashes are not keyed, so anyone who can write the chain can also
+// recompute them: only a head kept outside of the database shows that the
+// chain was rebuilt. Removed is only set when a restore took the record away.
+type Entry struct {
+	Seq     int                      `json:"seq"`
+	Created time.Time                `json:"created"`
+	Action  revision.Action          `json:"action"`
+	VoterId int                      `json:"voter_id"`
+	PollId  int                      `json:"poll_id"`
+	Record  revision.HistorySnapshot `json:"record"`
+	Removed bool                     `json:"removed,omitempty"`
+	Prev    string                   `json:"prev"`
+	Hash    string                   `json:"hash"`
+}
+
+// Report is the outcome of Verify. Head is the hash of the last entry, which
+// can be kept outside of the database and checked later to prove the chain
+// was not rewritten from that point on.
+type Report struct {
+	Entries  int
+	Head     string
+	Problems []string
+}
+
+// Next returns the entries to append after a change to a voter's history,
+// one for every record that differs between before and after, ordered by
+// poll id. head is the last entry in the chain, the zero Entry if it is
+// empty.
+func Next(head Entry, voterId int, before map[int]revision.HistorySnapshot, after map[int]revision.HistorySnapshot, action revision.Action, now time.Time) []Entry {
+	beforeRecords := make(map[Key]revision.HistorySnapshot)
+	afterRecords := make(map[Key]revision.HistorySnapshot)
+
+	AddRecords(beforeRecords, voterId, before)
+	AddRecords(afterRecords, voterId, after)
+
+	return Changes(head, beforeRecords, afterRecords, action, now)
+}
+
+// Changes returns the entries to append for every record that differs
+// between before and after, ordered by voter and then poll id.
+func Changes(head Entry, before map[Key]revision.HistorySnapshot, after map[Key]revision.HistorySnapshot, action revision.Action, now time.Time) []Entry {
+	var entries []Entry
+
+	for _, key := range sortedKeys(before, after) {
+		_, existed := before[key]
+		record, exists := after[key]
+
+		if exists == existed && len(recordChanges(before[key], record)) == 0 {
+			continue
+		}
+
+		head = link(head, Entry{
+			Created: now,
+			Action:  action,
+			VoterId: key.VoterId,
+			PollId:  key.PollId,
+			Record:  record,
+			Removed: !exists,
+		})
+		entries = append(entries, head)
+	}
+
+	return entries
+}
+
+// Baseline returns the entries that start a chain over records that existed
+// before the chain was kept, ordered by voter and then poll id.
+func Baseline(head Entry, records map[Key]revision.HistorySnapshot, now time.Time) []Entry {
+	var entries []Entry
+
+	for _, key := range sortedKeys(records, nil) {
+		head = link(head, Entry{
+			Created: now,
+			Action:  revision.ActionBaseline,
+			VoterId: key.VoterId,
+			PollId:  key.PollId,
+			Record:  records[key],
+		})
+		entries = append(entries, head)
+	}
+
+	return entries
+}
+
+// Verify checks that every entry follows the one before it and still matches
+// its hash, then replays the chain and compares the result with the records
+// currently stored. A record that differs was changed without going through
+// the API. If head is not blank the chain must contain an entry with that
+// hash, which catches entries cut from the end of the chain and a chain
+// rebuilt with new hashes. Without a head, Verify cannot tell a rebuilt
+// chain from the original.
+func Verify(entries []Entry, current map[Key]revision.HistorySnapshot, head string) Report {
+	report := Report{Entries: len(entries), Problems: []string{}}
+
+	replayed := make(map[Key]revision.HistorySnapshot)
+	prev := ""
+	headFound := false
+
+	for i, entry := range entries {
+		if entry.Seq != i+1 {
+			report.Problems = append(report.Problems, fmt.Sprintf("entry %d is out of sequence, expected entry %d", entry.Seq, i+1))
+		}
+
+		if entry.Prev != prev {
+			report.Problems = append(report.Problems, fmt.Sprintf("entry %d does not follow the entry before it", entry.Seq))
+		}
+
+		if hashOf(entry) != entry.Hash {
+			report.Problems = append(report.Problems, fmt.Sprintf("entry %d does not match its hash", entry.Seq))
+		}
+
+		if entry.Hash == head {
+			headFound = true
+		}
+
+		key := Key{VoterId: entry.VoterId, PollId: entry.PollId}
+		if entry.Removed {
+			delete(replayed, key)
+		} else {
+			replayed[key] = entry.Record
+		}
+
+		prev = entry.Hash
+	}
+
+	report.Head = prev
+
+	if head != "" && !headFound {
+		report.Problems = append(report.Problems, fmt.Sprintf("the chain does not contain head %s", head))
+	}
+
+	for _, key := range sortedKeys(replayed, current) {
+		expected, inChain := replayed[key]
+		actual, stored := current[key]
+
+		switch {
+		case !inChain:
+			report.Problems = append(report.Problems, fmt.Sprintf("voter %d poll %d: history was added outside the API", key.VoterId, key.PollId))
+		case !stored:
+			report.Problems = append(report.Problems, fmt.Sprintf("voter %d poll %d: history was removed outside the API", key.VoterId, key.PollId))
+		default:
+			changes := recordChanges(expected, actual)
+			if len(changes) > 0 {
+				report.Problems = append(report.Problems, fmt.Sprintf("voter %d poll %d: %s changed outside the API", key.VoterId, key.PollId, strings.Join(changes, ", ")))
+			}
+		}
+	}
+
+	return report
+}
+
+// Replay returns the records the chain holds once every entry is applied in
+// order, without checking the hashes.
+func Replay(entries []Entry) map[Key]revision.HistorySnapshot {
+	records := make(map[Key]revision.HistorySnapshot)
+
+	for _, entry := range entries {
+		key := Key{VoterId: entry.VoterId, PollId: entry.PollId}
+		if entry.Removed {
+			delete(records, key)
+		} else {
+			records[key] = entry.Record
+		}
+	}
+
+	return records
+}
+
+// Valid reports whether Verify found no problems.
+func (r Report) Valid() bool {
+	return len(r.Problems) == 0
+}
+
+// AddRecords adds a voter's history to the records keyed for Baseline and
+// Verify.
+func AddRecords(records map[Key]revision.HistorySnapshot, voterId int, history map[int]revision.HistorySnapshot) {
+	for pollId, item := range history {
+		records[Key{VoterId: voterId, PollId: pollId}] = item
+	}
+}
+
+// link fills in the sequence number and hashes of entry so it follows head.
+func link(head Entry, entry Entry) Entry {
+	entry.Seq = head.Seq + 1
+	entry.Prev = head.Hash
+	entry.Created = entry.Created.UTC()
+	entry.Hash = hashOf(entry)
+
+	return entry
+}
+
+// hashOf is the sha256 of the entry encoded as json without its own hash.
+// The previous hash is part of the encoding, which is what links the chain.
+// There is no key, so the hash catches edits to an entry but not an entry
+// whose hash was recomputed along with every one after it.
+func hashOf(entry Entry) string {
+	entry.Hash = ""
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+// recordChanges lists the fields that differ between two versions of a
+// record, using the same fields as the revision history.
+func recordChanges(old revision.HistorySnapshot, new revision.HistorySnapshot) []string {
+	var fields []string
+
+	for _, change := range revision.Diff(revision.Snapshot{History: map[int]revision.HistorySnapshot{0: old}}, revision.Snapshot{History: map[int]revision.HistorySnapshot{0: new}}) {
+		fields = append(fields, strings.TrimPrefix(change.Field, "history.0."))
+	}
+
+	return fields
+}
+
+func sortedKeys(a map[Key]revision.HistorySnapshot, b map[Key]revision.HistorySnapshot) []Key {
+	seen := make(map[Key]bool)
+	var keys []Key
+
+	for _, records := range []map[Key]revision.HistorySnapshot{a, b} {
+		for key := range records {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].VoterId != keys[j].VoterId {
+			return keys[i].VoterId < keys[j].VoterId
+		}
+		return keys[i].PollId < keys[j].PollId
+	})
+
+	return keys
+}
@@ -0,0 +1,121 @@
+package chain
+
+import (
+	"testing"
+	"time"
+
+	"drexel.edu/voter-api/pkg/storage/revision"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNext(t *testing.T) {
+	now := time.Now()
+	voteDate := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+
+	before := map[int]revision.HistorySnapshot{
+		1: {VoteId: 1, VoteDate: voteDate},
+	}
+	after := map[int]revision.HistorySnapshot{
+		1: {VoteId: 1, VoteDate: voteDate},
+		2: {VoteId: 2, VoteDate: voteDate, Choice: "yes"},
+	}
+
+	//only the record that changed is chained
+	entries := Next(Entry{}, 7, before, after, revision.ActionCreateHistory, now)
+	assert.Equal(t, 1, len(entries))
+	assert.Equal(t, 1, entries[0].Seq)
+	assert.Equal(t, 2, entries[0].PollId)
+	assert.Equal(t, "", entries[0].Prev)
+	assert.Equal(t, 64, len(entries[0].Hash))
+
+	next := Next(entries[0], 7, after, after, revision.ActionUpdate, now)
+	assert.Equal(t, 0, len(next))
+
+	deleted := map[int]revision.HistorySnapshot{
+		1: {VoteId: 1, VoteDate: voteDate, Deleted: true},
+		2: after[2],
+	}
+
+	next = Next(entries[0], 7, after, deleted, revision.ActionDeleteHistory, now)
+	assert.Equal(t, 1, len(next))
+	assert.Equal(t, 2, next[0].Seq)
+	assert.Equal(t, entries[0].Hash, next[0].Prev)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Now()
+	voteDate := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+
+	current := map[Key]revision.HistorySnapshot{
+		{VoterId: 1, PollId: 1}: {VoteId: 1, VoteDate: voteDate, Choice: "yes"},
+	}
+
+	entries := Baseline(Entry{}, current, now)
+	entries = append(entries, Next(entries[0], 2, nil, map[int]revision.HistorySnapshot{
+		1: {VoteId: 2, VoteDate: voteDate, Choice: "no"},
+	}, revision.ActionCreateHistory, now)...)
+	current[Key{VoterId: 2, PollId: 1}] = revision.HistorySnapshot{VoteId: 2, VoteDate: voteDate, Choice: "no"}
+
+	report := Verify(entries, current, "")
+	assert.True(t, report.Valid())
+	assert.Equal(t, 2, report.Entries)
+	assert.Equal(t, entries[1].Hash, report.Head)
+
+	report = Verify(entries, current, entries[0].Hash)
+	assert.True(t, report.Valid())
+
+	//a record edited in the data
+	edited := map[Key]revision.HistorySnapshot{
+		{VoterId: 1, PollId: 1}: {VoteId: 1, VoteDate: voteDate, Choice: "no"},
+		{VoterId: 2, PollId: 1}: current[Key{VoterId: 2, PollId: 1}],
+		{VoterId: 3, PollId: 1}: {VoteId: 3, VoteDate: voteDate},
+	}
+
+	report = Verify(entries, edited, "")
+	assert.Equal(t, []string{
+		"voter 1 poll 1: choice changed outside the API",
+		"voter 3 poll 1: history was added outside the API",
+	}, report.Problems)
+
+	//an entry edited to match
+	tampered := append([]Entry{}, entries...)
+	tampered[0].Record.Choice = "no"
+
+	report = Verify(tampered, edited, "")
+	assert.Contains(t, report.Problems, "entry 1 does not match its hash")
+
+	//an entry cut from the end only shows against a head kept elsewhere
+	report = Verify(entries[:1], map[Key]revision.HistorySnapshot{
+		{VoterId: 1, PollId: 1}: current[Key{VoterId: 1, PollId: 1}],
+	}, entries[1].Hash)
+	assert.Equal(t, []string{"the chain does not contain head " + entries[1].Hash}, report.Problems)
+
+	report = Verify(entries[1:], current, "")
+	assert.Contains(t, report.Problems, "entry 2 is out of sequence, expected entry 1")
+	assert.Contains(t, report.Problems, "entry 2 does not follow the entry before it")
+}
+
+func TestChanges(t *testing.T) {
+	now := time.Now()
+	voteDate := time.Date(2024, 11, 5, 10, 0, 0, 0, time.UTC)
+
+	before := map[Key]revision.HistorySnapshot{
+		{VoterId: 1, PollId: 1}: {VoteId: 1, VoteDate: voteDate},
+		{VoterId: 2, PollId: 1}: {VoteId: 2, VoteDate: voteDate},
+	}
+	after := map[Key]revision.HistorySnapshot{
+		{VoterId: 1, PollId: 1}: {VoteId: 1, VoteDate: voteDate},
+	}
+
+	entries := Baseline(Entry{}, before, now)
+
+	//a restore that takes a record away chains its removal
+	restored := Changes(entries[1], before, after, ActionRestoreBackup, now)
+	assert.Equal(t, 1, len(restored))
+	assert.Equal(t, 2, restored[0].VoterId)
+	assert.True(t, restored[0].Removed)
+
+	report := Verify(append(entries, restored...), after, "")
+	assert.True(t, report.Valid())
+	assert.Equal(t, 3, report.Entries)
+}
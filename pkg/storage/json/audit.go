@@ -0,0 +1,244 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/chain"
+	"drexel.edu/voter-api/pkg/storage/state"
+)
+
+const chainSuffix = ".chain"
+
+func chainFileName(dbFileName string) string {
+	return dbFileName + chainSuffix
+}
+
+// EnableChain starts keeping the audit chain in a file next to the Json DB.
+// The first time, history that already exists is chained as a baseline. From
+// then on the chain is opened every time the database is.
+func (v *VoterDB) EnableChain() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.chainFile != nil {
+		return nil
+	}
+
+	chained := v.chained
+
+	if err := v.openChain(); err != nil {
+		return err
+	}
+
+	if chained {
+		return nil
+	}
+
+	// the database records that it is chained, so a chain file that is
+	// later removed is reported rather than started again
+	if err := v.compact(); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	return nil
+}
+
+func (v *VoterDB) VerifyChain(head string) (retrieve.ChainReportDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if v.chainFile == nil {
+		return retrieve.ChainReportDTO{}, process.ErrChainDisabled.Error()
+	}
+
+	return v.verifyChain(head)
+}
+
+// VerifyFile checks the audit chain of the Json DB in dbFile without writing
+// to it, so it is safe to run while the server is using the file. A journal
+// left by a running server is read as well.
+func VerifyFile(dbFile string, head string) (retrieve.ChainReportDTO, error) {
+
+	db, err := openReadOnly(dbFile)
+	if err != nil {
+		return retrieve.ChainReportDTO{}, err
+	}
+
+	if _, err := os.Stat(chainFileName(dbFile)); err != nil && !db.chained {
+		return retrieve.ChainReportDTO{}, process.ErrChainDisabled.Error()
+	}
+
+	return db.verifyChain(head)
+}
+
+// openChain opens the chain file for appending. A chain that has never been
+// started begins with a baseline of the history already stored. Once started,
+// an empty or missing chain file means entries were lost, so it is left for
+// verifyChain to report rather than started again. The caller must hold the
+// write lock.
+func (v *VoterDB) openChain() error {
+	entries, _, err := readChain(chainFileName(v.dbFileName))
+	missing := errors.Is(err, os.ErrNotExist)
+	if err != nil && !missing {
+		return ErrFailedToLoadDB.Error()
+	}
+
+	file, err := os.OpenFile(chainFileName(v.dbFileName), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	v.chainFile = file
+
+	started := v.chained || !missing
+	v.chained = true
+
+	if len(entries) > 0 {
+		v.chainHead = entries[len(entries)-1]
+		return nil
+	}
+
+	v.chainHead = chain.Entry{}
+
+	if started {
+		return nil
+	}
+
+	v.pendingChain = chain.Baseline(v.chainHead, v.store.HistoryRecords(), time.Now())
+	v.flushChain()
+
+	return nil
+}
+
+// stageChain queues the entries for the history the change made different,
+// to be written by flushChain once the change is persisted. The caller must
+// hold the write lock.
+func (v *VoterDB) stageChain(change state.Change) {
+	if v.chainFile == nil {
+		return
+	}
+
+	v.pendingChain = append(v.pendingChain, chain.Next(v.lastChainEntry(), change.VoterId, change.Before, change.After, change.Action, change.Time)...)
+}
+
+// lastChainEntry returns the entry the next one follows, which may not have
+// been written yet. The caller must hold the lock.
+func (v *VoterDB) lastChainEntry() chain.Entry {
+	switch {
+	case len(v.pendingChain) > 0:
+		return v.pendingChain[len(v.pendingChain)-1]
+	case len(v.unwrittenChain) > 0:
+		return v.unwrittenChain[len(v.unwrittenChain)-1]
+	default:
+		return v.chainHead
+	}
+}
+
+// flushChain appends the staged entries to the chain file. It is only called
+// once the change is saved, so a failed write does not fail the change: the
+// entries are kept and written ahead of the next change's, and verifyChain
+// reports them until then. The caller must hold the write lock.
+func (v *VoterDB) flushChain() {
+	entries := append(slices.Clone(v.unwrittenChain), v.pendingChain...)
+	v.pendingChain = nil
+
+	if v.chainFile == nil || len(entries) == 0 {
+		return
+	}
+
+	if err := writeChain(v.chainFile, entries); err != nil {
+		v.unwrittenChain = entries
+		log.Printf("failed to write %d entries to %s, they are tried again with the next change: %v", len(entries), v.chainFile.Name(), err)
+		return
+	}
+
+	v.unwrittenChain = nil
+	v.chainHead = entries[len(entries)-1]
+}
+
+// writeChain appends entries to the chain file, or nothing if any of them
+// cannot be written.
+func writeChain(file *os.File, entries []chain.Entry) error {
+	var buf bytes.Buffer
+
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	return appendFile(file, buf.Bytes())
+}
+
+// verifyChain reads the chain file back and checks it against the history
+// in memory. A line that cannot be read, or a chain that is empty or missing
+// while there is history, is reported rather than failing the check. Entries
+// that are still waiting to be written are checked along with the file, and
+// reported as well. The caller must hold the lock.
+func (v *VoterDB) verifyChain(head string) (retrieve.ChainReportDTO, error) {
+	fileName := chainFileName(v.dbFileName)
+
+	entries, problems, err := readChain(fileName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return retrieve.ChainReportDTO{}, ErrFailedToLoadDB.Error()
+	}
+
+	records := v.store.HistoryRecords()
+
+	switch {
+	case err != nil:
+		problems = []string{fmt.Sprintf("%s is missing", fileName)}
+	case len(entries) == 0 && len(v.unwrittenChain) == 0 && len(records) > 0:
+		problems = append(problems, fmt.Sprintf("%s is empty but the database has history", fileName))
+	}
+
+	if len(v.unwrittenChain) > 0 {
+		problems = append(problems, fmt.Sprintf("%d entries could not be written to %s yet", len(v.unwrittenChain), fileName))
+		entries = append(entries, v.unwrittenChain...)
+	}
+
+	report := chain.Verify(entries, records, head)
+
+	return retrieve.NewChainReportDTO(report.Entries, report.Head, append(problems, report.Problems...)), nil
+}
+
+// readChain returns the entries in the chain file and a problem for every
+// line that is not an entry.
+func readChain(fileName string) ([]chain.Entry, []string, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var entries []chain.Entry
+	problems := []string{}
+
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var entry chain.Entry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d of %s is not a chain entry", i+1, fileName))
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, problems, nil
+}
@@ -0,0 +1,323 @@
+package json
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"drexel.edu/voter-api/pkg/storage/chain"
+	"drexel.edu/voter-api/pkg/storage/signature"
+)
+
+const (
+	snapshotPrefix     = "voters-"
+	snapshotSuffix     = ".json"
+	checksumSuffix     = ".sha256"
+	snapshotTimeFormat = "20060102T150405.000000000Z"
+)
+
+// Snapshot is a single backup of the database written by Backup or
+// BackupFile. Every snapshot has a sha256 checksum file next to it, and a
+// detached signature if it was signed.
+type Snapshot struct {
+	Name     string
+	Path     string
+	Created  time.Time
+	Checksum string
+	Size     int64
+}
+
+// Backup writes the current state of the database to a new snapshot in dir.
+// The snapshot is taken from memory, so it includes changes that are still
+// only in the journal.
+func (v *VoterDB) Backup(dir string) (Snapshot, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	data, err := encodeDB(v.createdBy, v.chained, v.store.SortedVoters(), v.store.SortedPolls(), v.store.SortedBallots())
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	return writeSnapshot(dir, data, time.Now())
+}
+
+// BackupFile writes the database in dbFileName to a new snapshot in dir. A
+// journal left by a server running in journal mode is replayed first, without
+// writing to either file, so the snapshot has every change the server has
+// saved. A corrupt database is never backed up.
+func BackupFile(dbFileName string, dir string) (Snapshot, error) {
+	db, err := openReadOnly(dbFileName)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	data, err := encodeDB(db.createdBy, db.chained, db.store.SortedVoters(), db.store.SortedPolls(), db.store.SortedBallots())
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	return writeSnapshot(dir, data, time.Now())
+}
+
+// RestoreFile replaces the database file dbFileName with the backup in
+// backupFileName without loading the database, so it also recovers one that
+// is corrupt. The backup is validated before anything is written, and a
+// journal left next to the database is dropped. If the audit chain is kept
+// the restore is chained against the records the chain holds, before the
+// database is written so that the two are either both changed or neither.
+func RestoreFile(dbFileName string, backupFileName string) error {
+	data, err := os.ReadFile(backupFileName)
+	if err != nil {
+		msg := fmt.Sprintf("failed to open %s", backupFileName)
+		return errors.New(msg)
+	}
+
+	if _, err := parseDB(backupFileName, data); err != nil {
+		return err
+	}
+
+	entries, _, err := readChain(chainFileName(dbFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return ErrFailedToLoadDB.Error()
+	}
+	chained := err == nil
+
+	var chainFile *os.File
+	var chainEnd int64
+
+	if chained {
+		restored := &VoterDB{dbFileName: dbFileName}
+		if err := restored.loadData(data); err != nil {
+			return err
+		}
+
+		head := chain.Entry{}
+		if len(entries) > 0 {
+			head = entries[len(entries)-1]
+		}
+
+		chainFile, err = os.OpenFile(chainFileName(dbFileName), os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+		defer chainFile.Close()
+
+		chainEnd, err = chainFile.Seek(0, io.SeekEnd)
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		if err := writeChain(chainFile, chain.Changes(head, chain.Replay(entries), restored.store.HistoryRecords(), chain.ActionRestoreBackup, time.Now())); err != nil {
+			return ErrSaveFailed.Error()
+		}
+	}
+
+	if err := writeFileAtomic(dbFileName, data, 0644); err != nil {
+		// the restore did not happen, so neither did its entries
+		if chainFile != nil {
+			if truncErr := chainFile.Truncate(chainEnd); truncErr != nil {
+				log.Printf("failed to remove the entries for the restore from %s: %v", chainFile.Name(), truncErr)
+			}
+		}
+
+		msg := fmt.Sprintf("failed to write to %s", dbFileName)
+		return errors.New(msg)
+	}
+
+	if err := os.Remove(journalFileName(dbFileName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return ErrSaveFailed.Error()
+	}
+
+	return nil
+}
+
+// ListSnapshots returns the snapshots in dir, newest first.
+func ListSnapshots(dir string) ([]Snapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+
+	for _, entry := range entries {
+		created, ok := parseSnapshotName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		checksum, _ := readChecksum(path)
+
+		snapshots = append(snapshots, Snapshot{
+			Name:     entry.Name(),
+			Path:     path,
+			Created:  created,
+			Checksum: checksum,
+			Size:     info.Size(),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Created.After(snapshots[j].Created)
+	})
+
+	return snapshots, nil
+}
+
+// VerifySnapshot checks that the snapshot matches its checksum and holds
+// voter data that can be loaded.
+func VerifySnapshot(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	expected, err := readChecksum(path)
+	if err != nil {
+		return fmt.Errorf("%w %s: %v", ErrBadSnapshot.Error(), path, err)
+	}
+
+	if actual := checksum(data); actual != expected {
+		return fmt.Errorf("%w %s: checksum is %s, expected %s", ErrBadSnapshot.Error(), path, actual, expected)
+	}
+
+	if _, err := parseDB(path, data); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// PruneSnapshots applies the retention policy to dir. The newest snapshot of
+// each of the last keepDaily days and of each of the last keepWeekly weeks is
+// kept, along with the newest snapshot overall. The removed snapshots are
+// returned.
+func PruneSnapshots(dir string, keepDaily int, keepWeekly int) ([]Snapshot, error) {
+	snapshots, err := ListSnapshots(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := make(map[string]bool)
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+
+	for i, snapshot := range snapshots {
+		if i == 0 {
+			keep[snapshot.Name] = true
+		}
+
+		day := snapshot.Created.Format("2006-01-02")
+		if !days[day] && len(days) < keepDaily {
+			days[day] = true
+			keep[snapshot.Name] = true
+		}
+
+		year, week := snapshot.Created.ISOWeek()
+		weekKey := fmt.Sprintf("%d-%02d", year, week)
+		if !weeks[weekKey] && len(weeks) < keepWeekly {
+			weeks[weekKey] = true
+			keep[snapshot.Name] = true
+		}
+	}
+
+	var removed []Snapshot
+
+	for _, snapshot := range snapshots {
+		if keep[snapshot.Name] {
+			continue
+		}
+
+		if err := os.Remove(snapshot.Path); err != nil {
+			return removed, err
+		}
+		os.Remove(snapshot.Path + checksumSuffix)
+		os.Remove(snapshot.Path + signature.Suffix)
+
+		removed = append(removed, snapshot)
+	}
+
+	return removed, nil
+}
+
+func writeSnapshot(dir string, data []byte, created time.Time) (Snapshot, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return Snapshot{}, err
+	}
+
+	created = created.UTC()
+	name := snapshotPrefix + created.Format(snapshotTimeFormat) + snapshotSuffix
+	path := filepath.Join(dir, name)
+	sum := checksum(data)
+
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		return Snapshot{}, err
+	}
+
+	// same layout as sha256sum so snapshots can also be checked by hand
+	line := fmt.Sprintf("%s  %s\n", sum, name)
+	if err := writeFileAtomic(path+checksumSuffix, []byte(line), 0644); err != nil {
+		return Snapshot{}, err
+	}
+
+	return Snapshot{
+		Name:     name,
+		Path:     path,
+		Created:  created,
+		Checksum: sum,
+		Size:     int64(len(data)),
+	}, nil
+}
+
+func parseSnapshotName(name string) (time.Time, bool) {
+	if !strings.HasPrefix(name, snapshotPrefix) || !strings.HasSuffix(name, snapshotSuffix) {
+		return time.Time{}, false
+	}
+
+	stamp := strings.TrimSuffix(strings.TrimPrefix(name, snapshotPrefix), snapshotSuffix)
+
+	created, err := time.Parse(snapshotTimeFormat, stamp)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return created, true
+}
+
+func readChecksum(path string) (string, error) {
+	data, err := os.ReadFile(path + checksumSuffix)
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return "", errors.New("empty checksum file")
+	}
+
+	return fields[0], nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
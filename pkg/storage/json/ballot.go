@@ -0,0 +1,52 @@
+package json
+
+import (
+	"fmt"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/state"
+)
+
+type Ballot = state.Ballot
+
+// CastSecretBallot records the history and stores the ballot together. It is
+// never journaled, see persistSecret.
+func (v *VoterDB) CastSecretBallot(voterId int, pollId int, history process.VoterHistoryDTO, ballot process.BallotDTO) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	undo := v.store.UndoVoter(voterId)
+
+	change, err := v.store.CastSecretBallot(voterId, pollId, history, ballot)
+	if err != nil {
+		return err
+	}
+
+	v.stageChain(change)
+
+	if err := v.persistSecret(func() {
+		undo()
+		delete(v.store.Ballots[pollId], ballot.GetReceipt())
+	}); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	fmt.Println("The secret ballot was successfully cast.")
+
+	return nil
+}
+
+func (v *VoterDB) GetPollBallots(pollId int) ([]retrieve.BallotDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetPollBallots(pollId)
+}
+
+func (v *VoterDB) GetBallot(pollId int, receipt string) (retrieve.BallotDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetBallot(pollId, receipt)
+}
@@ -1,20 +1,46 @@
 package json
 
-import "errors"
+import (
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/storage/state"
+)
 
 type RepositoryError string
 
 const (
-	ErrFailedToLoadDB       RepositoryError = "Failed to load the database."
-	ErrGettingVoter         RepositoryError = "Unhandled Exception Occured While attempting to retrieve a Voter."
-	ErrVoterAlreadyExists   RepositoryError = "Attempted to create a voter but the id already exists."
-	ErrVoterNotFound        RepositoryError = "The Voter Id was not found."
-	ErrSaveFailed           RepositoryError = "Error saving to the database."
-	ErrHistoryNotFound      RepositoryError = "The History Id for the Voter was not found"
-	ErrHistoryAlreadyExists RepositoryError = "Attempted to create new history for the voter but the poll Id already exists"
-	ErrNoVoterHistory       RepositoryError = "No history was found for the voter Id"
+	ErrFailedToLoadDB     RepositoryError = "Failed to load the database."
+	ErrGettingVoter       RepositoryError = "Unhandled Exception Occured While attempting to retrieve a Voter."
+	ErrSaveFailed         RepositoryError = "Error saving to the database."
+	ErrCorruptDB          RepositoryError = "The database file is truncated or corrupt and was not loaded."
+	ErrBadSnapshot        RepositoryError = "The backup snapshot failed verification."
+	ErrUnsupportedVersion RepositoryError = "The database file was written by a newer version and cannot be loaded."
+)
+
+// The checks on the records themselves are made by the state shared with the
+// memory repository.
+const (
+	ErrVoterAlreadyExists   = state.ErrVoterAlreadyExists
+	ErrVoterNotFound        = state.ErrVoterNotFound
+	ErrHistoryNotFound      = state.ErrHistoryNotFound
+	ErrHistoryAlreadyExists = state.ErrHistoryAlreadyExists
+	ErrNoVoterHistory       = state.ErrNoVoterHistory
+	ErrVoterNotDeleted      = state.ErrVoterNotDeleted
+	ErrHistoryNotDeleted    = state.ErrHistoryNotDeleted
+	ErrRevisionNotFound     = state.ErrRevisionNotFound
+	ErrPollAlreadyExists    = state.ErrPollAlreadyExists
+	ErrPollNotFound         = state.ErrPollNotFound
+	ErrBallotAlreadyExists  = state.ErrBallotAlreadyExists
 )
 
+var repositoryErrors = map[RepositoryError]process.Error{
+	ErrFailedToLoadDB:     {Kind: process.KindInternal, Code: "load_failed"},
+	ErrGettingVoter:       {Kind: process.KindInternal, Code: "get_voter_failed"},
+	ErrSaveFailed:         {Kind: process.KindInternal, Code: "save_failed"},
+	ErrCorruptDB:          {Kind: process.KindInternal, Code: "corrupt_db"},
+	ErrBadSnapshot:        {Kind: process.KindInternal, Code: "bad_snapshot"},
+	ErrUnsupportedVersion: {Kind: process.KindInternal, Code: "unsupported_version"},
+}
+
 func (e RepositoryError) Error() error {
-	return errors.New(string(e))
+	return repositoryErrors[e].WithMessage(string(e))
 }
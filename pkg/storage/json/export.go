@@ -0,0 +1,61 @@
+package json
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// exportEnvelope is the voter roll written by ExportFile.
+type exportEnvelope struct {
+	CreatedBy   string    `json:"created_by"`
+	Exported    time.Time `json:"exported"`
+	RecordCount int       `json:"record_count"`
+	Voters      []Voter   `json:"voters"`
+}
+
+// ExportFile writes the voter roll of the Json DB in dbFile to
+// exportFileName and returns the number of voters in it. The roll holds the
+// voters and history that are not deleted, without their revisions, and never
+// the secret ballots. Like VerifyFile it does not write to the database, so
+// it is safe to run while the server is using the file.
+func ExportFile(dbFile string, exportFileName string) (int, error) {
+	db, err := openReadOnly(dbFile)
+	if err != nil {
+		return 0, err
+	}
+
+	voters := []Voter{}
+
+	for _, voter := range db.store.SortedVoters() {
+		if voter.Deleted != nil {
+			continue
+		}
+
+		history := make(HistoryMap)
+		for pollId, item := range voter.VoterHistory {
+			if item.Deleted == nil {
+				history[pollId] = item
+			}
+		}
+
+		voter.VoterHistory = history
+		voter.Revisions = nil
+		voters = append(voters, voter)
+	}
+
+	data, err := json.MarshalIndent(exportEnvelope{
+		CreatedBy:   AppVersion,
+		Exported:    time.Now().UTC(),
+		RecordCount: len(voters),
+		Voters:      voters,
+	}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	if err := writeFileAtomic(exportFileName, data, 0644); err != nil {
+		return 0, err
+	}
+
+	return len(voters), nil
+}
@@ -0,0 +1,62 @@
+package json
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic replaces fileName with data without ever exposing a partly
+// written file. The data is written to a temporary file in the same
+// directory, flushed to disk and then renamed over the original.
+func writeFileAtomic(fileName string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(fileName)
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(fileName)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	tmpName := tmp.Name()
+
+	// the temporary file is only left behind if something failed
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmpName, fileName); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// syncDir flushes the directory entry so a completed rename survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	// some platforms do not support syncing a directory, the rename itself
+	// has still happened so this is not treated as a failure
+	d.Sync()
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// LegacySchemaVersion is the original format, a bare array of voters.
+	LegacySchemaVersion = 0
+	// CurrentSchemaVersion is the format written by this version. Version 2
+	// added the polls.
+	CurrentSchemaVersion = 2
+)
+
+// AppVersion is recorded in new database files as created_by. It is set by
+// the command line on start up.
+var AppVersion = "unknown"
+
+// FormatInfo describes the envelope of a database file.
+type FormatInfo struct {
+	SchemaVersion int
+	CreatedBy     string
+	RecordCount   int
+	Chained       bool
+}
+
+type dbEnvelope struct {
+	SchemaVersion int     `json:"schema_version"`
+	CreatedBy     string  `json:"created_by"`
+	RecordCount   int     `json:"record_count"`
+	Voters        []Voter `json:"voters"`
+	Polls         []Poll  `json:"polls"`
+
+	// Chained is set once the audit chain is started, so a chain file that
+	// goes missing is noticed
+	Chained bool `json:"chained,omitempty"`
+
+	// Ballots of secret polls, ordered by poll and receipt
+	Ballots []Ballot `json:"ballots,omitempty"`
+}
+
+// ReadFormat reports the format of an existing database file without
+// loading it into a VoterDB.
+func ReadFormat(fileName string) (FormatInfo, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return FormatInfo{}, err
+	}
+
+	info, _, _, _, err := decodeDB(fileName, data)
+
+	return info, err
+}
+
+// MigrateDB upgrades fileName to the current format in place. The original
+// contents are first written to backupFileName. The format the file was in
+// before the migration is returned.
+func MigrateDB(fileName string, backupFileName string) (FormatInfo, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return FormatInfo{}, err
+	}
+
+	info, voterList, pollList, ballotList, err := decodeDB(fileName, data)
+	if err != nil {
+		return info, err
+	}
+
+	if info.SchemaVersion == CurrentSchemaVersion {
+		return info, nil
+	}
+
+	if err := writeFileAtomic(backupFileName, data, 0644); err != nil {
+		return info, err
+	}
+
+	migrated, err := encodeDB(info.CreatedBy, info.Chained, voterList, pollList, ballotList)
+	if err != nil {
+		return info, err
+	}
+
+	if err := writeFileAtomic(fileName, migrated, 0644); err != nil {
+		return info, err
+	}
+
+	return info, nil
+}
+
+// encodeDB wraps the voters, polls and ballots in the current envelope.
+// createdBy is kept from the file being rewritten so it always names the
+// version that created it.
+func encodeDB(createdBy string, chained bool, voterList []Voter, pollList []Poll, ballotList []Ballot) ([]byte, error) {
+	if createdBy == "" {
+		createdBy = AppVersion
+	}
+
+	if voterList == nil {
+		voterList = []Voter{}
+	}
+
+	if pollList == nil {
+		pollList = []Poll{}
+	}
+
+	return json.MarshalIndent(dbEnvelope{
+		SchemaVersion: CurrentSchemaVersion,
+		CreatedBy:     createdBy,
+		RecordCount:   len(voterList),
+		Voters:        voterList,
+		Polls:         pollList,
+		Ballots:       ballotList,
+		Chained:       chained,
+	}, "", "  ")
+}
+
+// parseDB decodes the voters from any supported format.
+func parseDB(fileName string, data []byte) ([]Voter, error) {
+	_, voterList, _, _, err := decodeDB(fileName, data)
+
+	return voterList, err
+}
+
+// decodeDB decodes the contents of a database file. A file that was cut short
+// or otherwise fails to decode is reported as corrupt rather than being
+// treated as an empty database, and a file written by a newer version is
+// refused. Files from before version 2 have no polls.
+func decodeDB(fileName string, data []byte) (FormatInfo, []Voter, []Poll, []Ballot, error) {
+	trimmed := bytes.TrimSpace(data)
+
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var voterList []Voter
+
+		if err := json.Unmarshal(trimmed, &voterList); err != nil {
+			return FormatInfo{}, nil, nil, nil, corruptError(fileName, err)
+		}
+
+		return FormatInfo{
+			SchemaVersion: LegacySchemaVersion,
+			RecordCount:   len(voterList),
+		}, voterList, nil, nil, nil
+	}
+
+	var envelope dbEnvelope
+
+	if err := json.Unmarshal(trimmed, &envelope); err != nil {
+		return FormatInfo{}, nil, nil, nil, corruptError(fileName, err)
+	}
+
+	info := FormatInfo{
+		SchemaVersion: envelope.SchemaVersion,
+		CreatedBy:     envelope.CreatedBy,
+		RecordCount:   envelope.RecordCount,
+		Chained:       envelope.Chained,
+	}
+
+	if envelope.SchemaVersion > CurrentSchemaVersion {
+		err := fmt.Errorf("%w %s has schema version %d, this version supports up to %d",
+			ErrUnsupportedVersion.Error(), fileName, envelope.SchemaVersion, CurrentSchemaVersion)
+		return info, nil, nil, nil, err
+	}
+
+	if envelope.SchemaVersion < 1 {
+		return info, nil, nil, nil, corruptError(fileName, errors.New("missing schema_version"))
+	}
+
+	if envelope.RecordCount != len(envelope.Voters) {
+		err := fmt.Errorf("record_count is %d but %d voters were found", envelope.RecordCount, len(envelope.Voters))
+		return info, nil, nil, nil, corruptError(fileName, err)
+	}
+
+	return info, envelope.Voters, envelope.Polls, envelope.Ballots, nil
+}
+
+func corruptError(fileName string, err error) error {
+	return fmt.Errorf("%w %s: %v", ErrCorruptDB.Error(), fileName, err)
+}
@@ -0,0 +1,261 @@
+package json
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+)
+
+type ProblemKind string
+
+const (
+	ProblemParse            ProblemKind = "parse"
+	ProblemDuplicateVoter   ProblemKind = "duplicate_voter"
+	ProblemHistoryKey       ProblemKind = "history_key"
+	ProblemVoteId           ProblemKind = "vote_id"
+	ProblemMissingTimestamp ProblemKind = "missing_timestamp"
+	ProblemModifiedBefore   ProblemKind = "modified_before_created"
+	ProblemInvalidEmail     ProblemKind = "invalid_email"
+	ProblemDuplicateEmail   ProblemKind = "duplicate_email"
+)
+
+// Problem is a single inconsistency found by CheckFile. PollId is only set
+// for problems with a history record. Fixable problems are corrected by
+// RepairVoters.
+type Problem struct {
+	Kind    ProblemKind
+	VoterId int
+	PollId  int
+	Message string
+	Fixable bool
+}
+
+func (p Problem) String() string {
+	location := "file"
+	if p.VoterId != 0 {
+		location = fmt.Sprintf("voter %d", p.VoterId)
+	}
+	if p.PollId != 0 {
+		location = fmt.Sprintf("voter %d poll %d", p.VoterId, p.PollId)
+	}
+
+	fixable := ""
+	if !p.Fixable {
+		fixable = " (manual fix required)"
+	}
+
+	return fmt.Sprintf("%s: %s: %s%s", p.Kind, location, p.Message, fixable)
+}
+
+// CheckFile reads a database file and reports every problem found in it. A
+// file that cannot be decoded at all is reported as a single parse problem
+// and no voters are returned. The polls and ballots are returned as they are
+// so a repaired copy keeps them.
+func CheckFile(fileName string) ([]Problem, []Voter, []Poll, []Ballot, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	_, voterList, pollList, ballotList, err := decodeDB(fileName, data)
+	if err != nil {
+		return []Problem{{Kind: ProblemParse, Message: err.Error()}}, nil, nil, nil, nil
+	}
+
+	return CheckVoters(voterList), voterList, pollList, ballotList, nil
+}
+
+// CheckVoters reports the problems in a list of voters as read from a
+// database file, duplicates included.
+func CheckVoters(voterList []Voter) []Problem {
+	var problems []Problem
+
+	seen := make(map[int]int)
+	emails := make(map[string]int)
+
+	for _, voter := range voterList {
+		seen[voter.Id]++
+		if seen[voter.Id] == 2 {
+			problems = append(problems, Problem{
+				Kind:    ProblemDuplicateVoter,
+				VoterId: voter.Id,
+				Message: "the voter id appears more than once",
+				Fixable: true,
+			})
+		}
+
+		if !process.IsValidEmail(voter.Email) {
+			problems = append(problems, Problem{
+				Kind:    ProblemInvalidEmail,
+				VoterId: voter.Id,
+				Message: fmt.Sprintf("%q is not a valid email", voter.Email),
+			})
+		}
+
+		key := process.EmailKey(voter.Email)
+		if owner, exists := emails[key]; exists && owner != voter.Id {
+			problems = append(problems, Problem{
+				Kind:    ProblemDuplicateEmail,
+				VoterId: voter.Id,
+				Message: fmt.Sprintf("%q is also the email of voter %d", voter.Email, owner),
+			})
+		} else if !exists {
+			emails[key] = voter.Id
+		}
+
+		problems = append(problems, checkTimestamps(voter.Id, 0, voter.Created, voter.Modified)...)
+
+		for _, key := range sortedPollIds(voter.VoterHistory) {
+			history := voter.VoterHistory[key]
+
+			if history.PollId != key {
+				problems = append(problems, Problem{
+					Kind:    ProblemHistoryKey,
+					VoterId: voter.Id,
+					PollId:  key,
+					Message: fmt.Sprintf("stored under poll %d but poll_id is %d", key, history.PollId),
+					Fixable: true,
+				})
+			}
+
+			if history.VoteId != key {
+				problems = append(problems, Problem{
+					Kind:    ProblemVoteId,
+					VoterId: voter.Id,
+					PollId:  key,
+					Message: fmt.Sprintf("vote_id is %d, expected %d", history.VoteId, key),
+					Fixable: true,
+				})
+			}
+
+			if history.VoteDate.IsZero() {
+				problems = append(problems, Problem{
+					Kind:    ProblemMissingTimestamp,
+					VoterId: voter.Id,
+					PollId:  key,
+					Message: "vote_date is missing",
+				})
+			}
+
+			problems = append(problems, checkTimestamps(voter.Id, key, history.Created, history.Modified)...)
+		}
+	}
+
+	return problems
+}
+
+// RepairVoters returns a copy of voterList with every fixable problem
+// corrected. Of duplicate voters the most recently modified is kept, history
+// is re-keyed to the poll it is stored under and missing timestamps are
+// filled in from the other timestamp, or now if both are missing.
+func RepairVoters(voterList []Voter, now time.Time) []Voter {
+	byId := make(map[int]Voter)
+
+	for _, voter := range voterList {
+		if existing, exists := byId[voter.Id]; exists && existing.Modified.After(voter.Modified) {
+			continue
+		}
+		byId[voter.Id] = voter
+	}
+
+	repaired := make([]Voter, 0, len(byId))
+
+	for _, voter := range byId {
+		voter.Created, voter.Modified = repairTimestamps(voter.Created, voter.Modified, now)
+
+		if voter.VoterHistory != nil {
+			history := make(HistoryMap, len(voter.VoterHistory))
+
+			for key, item := range voter.VoterHistory {
+				item.PollId = key
+				item.VoteId = key
+				item.Created, item.Modified = repairTimestamps(item.Created, item.Modified, now)
+				history[key] = item
+			}
+
+			voter.VoterHistory = history
+		}
+
+		repaired = append(repaired, voter)
+	}
+
+	sort.Slice(repaired, func(i, j int) bool {
+		return repaired[i].Id < repaired[j].Id
+	})
+
+	return repaired
+}
+
+// WriteRepaired writes voterList, pollList and ballotList to fileName in the
+// current format.
+func WriteRepaired(fileName string, voterList []Voter, pollList []Poll, ballotList []Ballot) error {
+	data, err := encodeDB(AppVersion, false, voterList, pollList, ballotList)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(fileName, data, 0644)
+}
+
+func checkTimestamps(voterId int, pollId int, created time.Time, modified time.Time) []Problem {
+	var problems []Problem
+
+	if created.IsZero() {
+		problems = append(problems, Problem{
+			Kind:    ProblemMissingTimestamp,
+			VoterId: voterId,
+			PollId:  pollId,
+			Message: "created is missing",
+			Fixable: true,
+		})
+	}
+
+	if modified.IsZero() {
+		problems = append(problems, Problem{
+			Kind:    ProblemMissingTimestamp,
+			VoterId: voterId,
+			PollId:  pollId,
+			Message: "modified is missing",
+			Fixable: true,
+		})
+	}
+
+	if !created.IsZero() && !modified.IsZero() && modified.Before(created) {
+		problems = append(problems, Problem{
+			Kind:    ProblemModifiedBefore,
+			VoterId: voterId,
+			PollId:  pollId,
+			Message: fmt.Sprintf("modified %s is before created %s", modified.Format(time.RFC3339), created.Format(time.RFC3339)),
+			Fixable: true,
+		})
+	}
+
+	return problems
+}
+
+func repairTimestamps(created time.Time, modified time.Time, now time.Time) (time.Time, time.Time) {
+	switch {
+	case created.IsZero() && modified.IsZero():
+		return now, now
+	case created.IsZero():
+		return modified, modified
+	case modified.IsZero() || modified.Before(created):
+		return created, created
+	}
+
+	return created, modified
+}
+
+func sortedPollIds(history HistoryMap) []int {
+	keys := make([]int, 0, len(history))
+	for key := range history {
+		keys = append(keys, key)
+	}
+
+	sort.Ints(keys)
+
+	return keys
+}
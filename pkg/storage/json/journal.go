@@ -0,0 +1,387 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"drexel.edu/voter-api/pkg/storage/revision"
+	"drexel.edu/voter-api/pkg/storage/state"
+)
+
+const (
+	journalSuffix       = ".journal"
+	DefaultCompactAfter = 1000
+)
+
+type journalOp string
+
+const (
+	opPutVoter      journalOp = "put_voter"
+	opDeleteVoter   journalOp = "delete_voter"
+	opPutHistory    journalOp = "put_history"
+	opDeleteHistory journalOp = "delete_history"
+	opReplaceVoter  journalOp = "replace_voter"
+	opPutPoll       journalOp = "put_poll"
+	opDeletePoll    journalOp = "delete_poll"
+)
+
+// journalEntry is a single mutation appended to the journal. Every entry
+// records the resulting state rather than the request, so replaying an entry
+// that is already part of the snapshot is harmless.
+type journalEntry struct {
+	Op      journalOp     `json:"op"`
+	VoterId int           `json:"voter_id"`
+	PollId  int           `json:"poll_id,omitempty"`
+	Voter   *Voter        `json:"voter,omitempty"`
+	History *VoterHistory `json:"history,omitempty"`
+	Poll    *Poll         `json:"poll,omitempty"`
+
+	// VoterVersion is the version of the voter after a history change.
+	VoterVersion int `json:"voter_version,omitempty"`
+
+	// Revisions are appended to the voter's revisions after the entry is
+	// applied.
+	Revisions []revision.Revision `json:"revisions,omitempty"`
+}
+
+// putVoterEntry records the voter fields only, history has its own entries.
+func putVoterEntry(voter Voter, revisions []revision.Revision) journalEntry {
+	voter.VoterHistory = nil
+	voter.Revisions = nil
+
+	return journalEntry{Op: opPutVoter, VoterId: voter.Id, Voter: &voter, Revisions: revisions}
+}
+
+func putHistoryEntry(voterId int, voterVersion int, history VoterHistory, revisions []revision.Revision) journalEntry {
+	return journalEntry{Op: opPutHistory, VoterId: voterId, PollId: history.PollId, History: &history, VoterVersion: voterVersion, Revisions: revisions}
+}
+
+// replaceVoterEntry records the voter fields and its whole history.
+func replaceVoterEntry(voter Voter, revisions []revision.Revision) journalEntry {
+	voter.Revisions = nil
+
+	return journalEntry{Op: opReplaceVoter, VoterId: voter.Id, Voter: &voter, Revisions: revisions}
+}
+
+func putPollEntry(poll Poll) journalEntry {
+	return journalEntry{Op: opPutPoll, PollId: poll.Id, Poll: &poll}
+}
+
+func deletePollEntry(pollId int) journalEntry {
+	return journalEntry{Op: opDeletePoll, PollId: pollId}
+}
+
+// NewJournaledJsonDB opens the database in journal mode. Mutations are
+// appended to <dbFile>.journal and the snapshot in dbFile is only rewritten
+// once compactAfter entries have been written.
+func NewJournaledJsonDB(dbFile string, compactAfter int) (*VoterDB, error) {
+
+	if compactAfter < 1 {
+		compactAfter = DefaultCompactAfter
+	}
+
+	db, err := NewJsonDB(dbFile)
+	if err != nil {
+		return nil, err
+	}
+
+	journal, err := os.OpenFile(journalFileName(dbFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	db.journal = journal
+	db.compactAfter = compactAfter
+
+	return db, nil
+}
+
+// Compact writes the current state to the snapshot and empties the journal.
+func (v *VoterDB) Compact() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.compact()
+}
+
+// Close compacts the journal, if any, and releases the journal and audit
+// chain files.
+func (v *VoterDB) Close() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.chainFile != nil {
+		v.flushChain()
+		v.chainFile.Close()
+		v.chainFile = nil
+	}
+
+	if v.journal == nil {
+		return nil
+	}
+
+	err := v.compact()
+
+	if closeErr := v.journal.Close(); err == nil {
+		err = closeErr
+	}
+
+	v.journal = nil
+
+	return err
+}
+
+func journalFileName(dbFileName string) string {
+	return dbFileName + journalSuffix
+}
+
+// persist makes a mutation that has already been applied to the store
+// durable and then appends its audit chain entries, if any. If the mutation
+// could not be written undo takes it back out of memory, so what is served
+// never runs ahead of the file. Once it is written the mutation stands even
+// if the chain cannot be, see flushChain. The caller must hold the write
+// lock.
+func (v *VoterDB) persist(entry journalEntry, undo func()) error {
+	if err := v.writeEntry(entry); err != nil {
+		v.pendingChain = nil
+		undo()
+		return err
+	}
+
+	v.flushChain()
+
+	return nil
+}
+
+// writeEntry persists a single mutation. Without a journal the whole
+// snapshot is rewritten.
+func (v *VoterDB) writeEntry(entry journalEntry) error {
+	if v.journal == nil {
+		return v.saveDB()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if err := appendFile(v.journal, append(data, '\n')); err != nil {
+		return err
+	}
+
+	v.journalEntries++
+
+	// the entry is durable once it is in the journal, a compaction that
+	// fails is tried again after the next entry
+	if v.journalEntries >= v.compactAfter {
+		if err := v.compact(); err != nil {
+			log.Printf("failed to compact %s: %v", journalFileName(v.dbFileName), err)
+		}
+	}
+
+	return nil
+}
+
+// appendFile writes data to the end of file and syncs it. If either fails
+// the file is cut back to where it ended, so a partial line is never left
+// for the next append to run on from.
+func appendFile(file *os.File, data []byte) error {
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+
+	if err != nil {
+		if truncErr := file.Truncate(offset); truncErr != nil {
+			log.Printf("failed to cut %s back after a failed write: %v", file.Name(), truncErr)
+		} else {
+			file.Seek(offset, io.SeekStart)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// persistSecret writes a change that must not be journaled. The journal is
+// in the order changes were made, which would match a secret ballot to the
+// history written just before it, so the file is rewritten instead and any
+// journal folded into it. The ballot itself is never chained.
+func (v *VoterDB) persistSecret(undo func()) error {
+	if err := v.saveDB(); err != nil {
+		v.pendingChain = nil
+		undo()
+		return err
+	}
+
+	// the snapshot already holds everything in the journal, see compact
+	if v.journal != nil {
+		if err := v.truncateJournal(); err != nil {
+			log.Printf("failed to truncate %s: %v", journalFileName(v.dbFileName), err)
+		}
+	}
+
+	v.flushChain()
+
+	return nil
+}
+
+// compact rewrites the snapshot and then truncates the journal. A crash
+// between the two steps only leaves entries that replay to the same state.
+func (v *VoterDB) compact() error {
+	if err := v.saveDB(); err != nil {
+		return err
+	}
+
+	if err := v.truncateJournal(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (v *VoterDB) truncateJournal() error {
+	v.journalEntries = 0
+
+	if v.journal != nil {
+		if err := v.journal.Truncate(0); err != nil {
+			return err
+		}
+		return v.journal.Sync()
+	}
+
+	err := os.Remove(journalFileName(v.dbFileName))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+// replayJournal applies any journal entries left over from the last run on
+// top of the loaded snapshot and reports whether a journal was found. A
+// partial entry at the very end of the journal is the result of a crash
+// during an append and is ignored.
+func (v *VoterDB) replayJournal() (bool, error) {
+	fileName := journalFileName(v.dbFileName)
+
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, ErrFailedToLoadDB.Error()
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var entry journalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			if i == len(lines)-1 {
+				log.Printf("ignoring incomplete entry at the end of %s", fileName)
+				break
+			}
+			return true, fmt.Errorf("%w %s line %d: %v", ErrCorruptDB.Error(), fileName, i+1, err)
+		}
+
+		if err := v.applyEntry(entry); err != nil {
+			return true, fmt.Errorf("%w %s line %d: %v", ErrCorruptDB.Error(), fileName, i+1, err)
+		}
+	}
+
+	return true, nil
+}
+
+func (v *VoterDB) applyEntry(entry journalEntry) error {
+	switch entry.Op {
+	case opPutVoter:
+		if entry.Voter == nil {
+			return errors.New("missing voter")
+		}
+		voter := *entry.Voter
+		voter.VoterHistory = v.store.Voters[entry.VoterId].VoterHistory
+		voter.Revisions = v.store.Voters[entry.VoterId].Revisions
+		v.store.Voters[entry.VoterId] = voter
+
+	case opReplaceVoter:
+		if entry.Voter == nil {
+			return errors.New("missing voter")
+		}
+		voter := *entry.Voter
+		voter.Revisions = v.store.Voters[entry.VoterId].Revisions
+		v.store.Voters[entry.VoterId] = voter
+
+	case opDeleteVoter:
+		delete(v.store.Voters, entry.VoterId)
+
+	case opPutHistory:
+		if entry.History == nil {
+			return errors.New("missing history")
+		}
+		voter, exists := v.store.Voters[entry.VoterId]
+		if !exists {
+			return ErrVoterNotFound.Error()
+		}
+		if voter.VoterHistory == nil {
+			voter.VoterHistory = make(HistoryMap)
+		}
+		voter.VoterHistory[entry.PollId] = *entry.History
+		if entry.VoterVersion > 0 {
+			voter.Version = entry.VoterVersion
+		}
+		v.store.Voters[entry.VoterId] = voter
+
+	case opDeleteHistory:
+		if voter, exists := v.store.Voters[entry.VoterId]; exists {
+			delete(voter.VoterHistory, entry.PollId)
+		}
+
+	case opPutPoll:
+		if entry.Poll == nil {
+			return errors.New("missing poll")
+		}
+		v.store.Polls[entry.PollId] = *entry.Poll
+
+	case opDeletePoll:
+		delete(v.store.Polls, entry.PollId)
+
+	default:
+		return fmt.Errorf("unknown journal operation %q", entry.Op)
+	}
+
+	if voter, exists := v.store.Voters[entry.VoterId]; exists {
+		// entries written before versions were kept
+		v.store.Voters[entry.VoterId] = state.WithInitialVersions(voter)
+	}
+
+	if len(entry.Revisions) > 0 {
+		voter, exists := v.store.Voters[entry.VoterId]
+		if !exists {
+			return ErrVoterNotFound.Error()
+		}
+		// revisions already in the snapshot are skipped
+		for _, item := range entry.Revisions {
+			if item.Number > revision.Last(voter.Revisions) {
+				voter.Revisions = append(voter.Revisions, item)
+			}
+		}
+		v.store.Voters[entry.VoterId] = voter
+	}
+
+	return nil
+}
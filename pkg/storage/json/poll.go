@@ -0,0 +1,128 @@
+package json
+
+import (
+	"fmt"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/state"
+)
+
+type Poll = state.Poll
+
+func (v *VoterDB) CreatePoll(poll process.PollDTO) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	undo := v.store.UndoPoll(poll.GetId())
+
+	if err := v.store.CreatePoll(poll); err != nil {
+		return err
+	}
+
+	if err := v.persist(putPollEntry(v.store.Polls[poll.GetId()]), undo); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	fmt.Println("The poll was successfully created.")
+
+	return nil
+}
+
+func (v *VoterDB) UpdatePoll(poll process.PollDTO) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	undo := v.store.UndoPoll(poll.GetId())
+
+	if err := v.store.UpdatePoll(poll); err != nil {
+		return err
+	}
+
+	if err := v.persist(putPollEntry(v.store.Polls[poll.GetId()]), undo); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	fmt.Println("The poll was successfully updated.")
+
+	return nil
+}
+
+// DeletePoll removes the poll for good. Polls that any voter history or
+// secret ballot refers to, deleted or not, are kept.
+func (v *VoterDB) DeletePoll(id int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	undo := v.store.UndoPoll(id)
+
+	if err := v.store.DeletePoll(id); err != nil {
+		return err
+	}
+
+	if err := v.persist(deletePollEntry(id), undo); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	fmt.Println("The poll was successfully deleted.")
+
+	return nil
+}
+
+func (v *VoterDB) SetPollWindow(id int, opensAt time.Time, closesAt time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	undo := v.store.UndoPoll(id)
+
+	if err := v.store.SetPollWindow(id, opensAt, closesAt); err != nil {
+		return err
+	}
+
+	if err := v.persist(putPollEntry(v.store.Polls[id]), undo); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	fmt.Println("The poll window was successfully updated.")
+
+	return nil
+}
+
+// GetPoll is used by the process service to check the poll window.
+func (v *VoterDB) GetPoll(id int) (process.PollDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetPoll(id)
+}
+
+func (v *VoterDB) GetAllPolls() ([]retrieve.PollDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetAllPolls(), nil
+}
+
+func (v *VoterDB) GetSinglePoll(id int) (retrieve.PollDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetSinglePoll(id)
+}
+
+// GetPollVotes returns the history recorded for the poll by voters that have
+// not been deleted, leaving out deleted history.
+func (v *VoterDB) GetPollVotes(pollId int) ([]retrieve.VoterHistoryDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetPollVotes(pollId)
+}
+
+func (v *VoterDB) CountVoters() (int, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.CountVoters(), nil
+}
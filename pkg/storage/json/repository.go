@@ -4,120 +4,177 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"os"
+	"sync"
 	"time"
 
 	"drexel.edu/voter-api/pkg/process"
 	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/chain"
+	"drexel.edu/voter-api/pkg/storage/state"
 )
 
-type DbMap map[int]Voter
-
+// VoterDB keeps the authoritative copy of the voters in memory. The file is
+// read once when the database is opened and every change the store makes is
+// persisted from memory, either by rewriting the file or by appending to the
+// journal, so all access to store must go through mu.
 type VoterDB struct {
-	voterList  DbMap
+	mu         sync.RWMutex
+	store      *state.Store
 	dbFileName string
+	createdBy  string
+
+	// journal is only set in journal mode, see NewJournaledJsonDB
+	journal        *os.File
+	journalEntries int
+	compactAfter   int
+
+	// chainFile is only set once the audit chain is enabled, see
+	// EnableChain. pendingChain holds the entries for a change until the
+	// change itself has been persisted, and unwrittenChain those for saved
+	// changes that could not be written to the chain file yet. chained is
+	// kept in the database file so the chain is never started again from the
+	// history then stored
+	chainFile      *os.File
+	chained        bool
+	chainHead      chain.Entry
+	pendingChain   []chain.Entry
+	unwrittenChain []chain.Entry
 }
 
 func NewJsonDB(dbFile string) (*VoterDB, error) {
 
-	if _, err := os.Stat(dbFile); err != nil {
+	stat, err := os.Stat(dbFile)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
 		//If the file doesn't exist, create it
-		err := initDB(dbFile)
-		if err != nil {
+		if err := initDB(dbFile); err != nil {
 			return nil, err
 		}
+	case err != nil:
+		return nil, ErrFailedToLoadDB.Error()
+	case stat.Size() == 0:
+		// an empty file is never written by initDB or saveDB, so something
+		// else truncated it and its voters are gone
+		return nil, corruptError(dbFile, errors.New("the file is empty"))
 	}
 
-	file, err := os.Open(dbFile)
-	if err != nil {
-		return &VoterDB{}, err
+	voterList := &VoterDB{
+		store:      state.New(),
+		dbFileName: dbFile,
 	}
 
-	stat, err := file.Stat()
+	if err := voterList.loadDB(); err != nil {
+		return nil, err
+	}
+
+	// fold a journal left behind by a previous run into the snapshot so the
+	// database always starts from a single file
+	replayed, err := voterList.replayJournal()
 	if err != nil {
 		return nil, err
 	}
 
-	if stat.Size() == 0 {
-		err := initDB(dbFile)
-		if err != nil {
+	if replayed {
+		voterList.store.Reindex()
+
+		if err := voterList.compact(); err != nil {
+			return nil, ErrSaveFailed.Error()
+		}
+	}
+
+	// once started the audit chain is kept whether or not it was asked for,
+	// so no change to the history is missed
+	if _, err := os.Stat(chainFileName(dbFile)); err == nil || voterList.chained {
+		if err := voterList.openChain(); err != nil {
 			return nil, err
 		}
 	}
 
-	voterList := &VoterDB{
-		voterList:  make(map[int]Voter),
+	return voterList, nil
+}
+
+// openReadOnly loads the Json DB in dbFile and any journal left next to it
+// by a running server, without writing to either.
+func openReadOnly(dbFile string) (*VoterDB, error) {
+	db := &VoterDB{
+		store:      state.New(),
 		dbFileName: dbFile,
 	}
 
-	return voterList, nil
+	if err := db.loadDB(); err != nil {
+		return nil, err
+	}
+
+	if _, err := db.replayJournal(); err != nil {
+		return nil, err
+	}
+
+	return db, nil
 }
 
+// RestoreDB replaces the database file with the backup in targetFileName.
+// The backup is validated before anything is written, so a missing or corrupt
+// backup leaves the current database untouched.
 func (v *VoterDB) RestoreDB(targetFileName string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
 	dbFileName := v.dbFileName
 	backupFileName := targetFileName
 
-	dbFile, err := os.OpenFile(dbFileName, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
+	data, err := os.ReadFile(backupFileName)
 	if err != nil {
-		msg := fmt.Sprintf("failed to open %s", dbFileName)
+		msg := fmt.Sprintf("failed to open %s", backupFileName)
 		return errors.New(msg)
 	}
 
-	defer dbFile.Close()
+	if _, err := parseDB(backupFileName, data); err != nil {
+		return err
+	}
+
+	before := v.store.HistoryRecords()
 
-	backupFile, err := os.Open(backupFileName)
-	if err != nil {
-		msg := fmt.Sprintf("failed to open %s", backupFileName)
+	if err := writeFileAtomic(dbFileName, data, 0644); err != nil {
+		msg := fmt.Sprintf("failed to write to %s", dbFileName)
 		return errors.New(msg)
 	}
 
-	defer backupFile.Close()
+	fmt.Println("finished copying from ", backupFileName, " to ", dbFileName)
+
+	if err := v.truncateJournal(); err != nil {
+		return ErrSaveFailed.Error()
+	}
 
-	buffer := make([]byte, 1024)
+	if err := v.loadDB(); err != nil {
+		return err
+	}
 
-	for {
-		bytesRead, err := backupFile.Read(buffer)
-		if err != nil && err != io.EOF {
-			msg := fmt.Sprintf("failed to read from %s", backupFileName)
-			return errors.New(msg)
-		}
-		if bytesRead == 0 {
-			fmt.Println("finished copying from ", backupFileName, " to ", dbFileName)
-			break
-		}
-		if _, err := dbFile.Write(buffer[:bytesRead]); err != nil {
-			fmt.Print("failed to write to ", dbFileName)
-		}
+	// the restore is chained like any other change to the history
+	if v.chainFile != nil {
+		v.pendingChain = chain.Changes(v.lastChainEntry(), before, v.store.HistoryRecords(), chain.ActionRestoreBackup, time.Now())
+		v.flushChain()
 	}
 
 	return nil
 }
 
 func (v *VoterDB) CreateVoter(voter process.VoterDTO) error {
-	if err := v.loadDB(); err != nil {
-		return ErrFailedToLoadDB.Error()
-	}
-
-	if _, exists := v.voterList[voter.GetId()]; exists {
-		return ErrVoterAlreadyExists.Error()
-	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
-	currentTime := time.Now()
+	undo := v.store.UndoVoter(voter.GetId())
 
-	newVoter := Voter{
-		Id:           voter.GetId(),
-		Name:         voter.GetName(),
-		Email:        voter.GetEmail(),
-		VoterHistory: nil,
-		Created:      currentTime,
-		Modified:     currentTime,
+	change, err := v.store.CreateVoter(voter)
+	if err != nil {
+		return err
 	}
 
-	v.voterList[voter.GetId()] = newVoter
+	newVoter := v.store.Voters[voter.GetId()]
 
-	if err := v.saveDB(); err != nil {
+	v.stageChain(change)
+
+	if err := v.persist(putVoterEntry(newVoter, change.Revisions), undo); err != nil {
 		return ErrSaveFailed.Error()
 	}
 
@@ -128,254 +185,239 @@ func (v *VoterDB) CreateVoter(voter process.VoterDTO) error {
 	return nil
 }
 
-func (v *VoterDB) UpdateVoterInfo(voter process.VoterDTO) error {
+func (v *VoterDB) UpdateVoterInfo(voter process.VoterDTO, expectedVersion int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
-	if err := v.loadDB(); err != nil {
-		return ErrFailedToLoadDB.Error()
+	undo := v.store.UndoVoter(voter.GetId())
+
+	change, err := v.store.UpdateVoterInfo(voter, expectedVersion)
+	if err != nil {
+		return err
 	}
 
-	if previousVoter, exists := v.voterList[voter.GetId()]; exists {
-		currentTime := time.Now()
+	updatedVoter := v.store.Voters[voter.GetId()]
 
-		updatedVoter := Voter{
-			Id:           voter.GetId(),
-			Name:         voter.GetName(),
-			Email:        voter.GetEmail(),
-			VoterHistory: previousVoter.VoterHistory,
-			Created:      previousVoter.Created,
-			Modified:     currentTime,
-		}
+	v.stageChain(change)
 
-		v.voterList[voter.GetId()] = updatedVoter
+	if err := v.persist(putVoterEntry(updatedVoter, change.Revisions), undo); err != nil {
+		return ErrSaveFailed.Error()
+	}
 
-		if err := v.saveDB(); err != nil {
-			return ErrSaveFailed.Error()
-		}
+	fmt.Println("The voter was successfully registered.")
 
-		fmt.Println("The voter was successfully registered.")
+	v.PrintItem(updatedVoter)
 
-		v.PrintItem(updatedVoter)
+	return nil
+}
 
-		return nil
-	}
+// DeleteSingleVoter marks the voter as deleted. The voter and its history are
+// kept on file and can be brought back with RestoreVoter.
+func (v *VoterDB) DeleteSingleVoter(id int, reason string, expectedVersion int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
-	return ErrVoterNotFound.Error()
-}
+	undo := v.store.UndoVoter(id)
 
-func (v *VoterDB) DeleteSingleVoter(id int) error {
+	change, err := v.store.DeleteSingleVoter(id, reason, expectedVersion)
+	if err != nil {
+		return err
+	}
 
-	if err := v.loadDB(); err != nil {
-		return ErrFailedToLoadDB.Error()
+	v.stageChain(change)
+
+	if err := v.persist(putVoterEntry(v.store.Voters[id], change.Revisions), undo); err != nil {
+		return ErrSaveFailed.Error()
 	}
 
-	if _, exists := v.voterList[id]; exists {
-		delete(v.voterList, id)
+	fmt.Println("The voter was successfully deleted.")
 
-		if err := v.saveDB(); err != nil {
-			return ErrSaveFailed.Error()
-		}
+	return nil
+}
 
-		fmt.Println("The voter was successfully deleted.")
+func (v *VoterDB) RestoreVoter(id int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
-		return nil
+	undo := v.store.UndoVoter(id)
+
+	change, err := v.store.RestoreVoter(id)
+	if err != nil {
+		return err
 	}
 
-	return ErrVoterNotFound.Error()
-}
+	v.stageChain(change)
 
-func (v *VoterDB) CreateVoterHistory(voterId int, pollId int, history process.VoterHistoryDTO) error {
-	if err := v.loadDB(); err != nil {
-		return ErrFailedToLoadDB.Error()
+	if err := v.persist(putVoterEntry(v.store.Voters[id], change.Revisions), undo); err != nil {
+		return ErrSaveFailed.Error()
 	}
 
-	voter, exists := v.voterList[voterId]
-	if !exists {
-		return ErrVoterNotFound.Error()
-	}
+	fmt.Println("The voter was successfully restored.")
 
-	if _, exists := v.voterList[voterId].VoterHistory[pollId]; exists {
-		return ErrHistoryAlreadyExists.Error()
-	}
+	return nil
+}
 
-	currentTime := time.Now()
+func (v *VoterDB) CreateVoterHistory(voterId int, pollId int, history process.VoterHistoryDTO) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
-	if voter.VoterHistory == nil {
-		voter.VoterHistory = make(HistoryMap)
-	}
+	undo := v.store.UndoVoter(voterId)
 
-	voter.VoterHistory[pollId] = VoterHistory{
-		PollId:   pollId,
-		VoteId:   history.GetVoteID(),
-		VoteDate: history.GetVoteDate(),
-		Created:  currentTime,
-		Modified: currentTime,
+	change, err := v.store.CreateVoterHistory(voterId, pollId, history)
+	if err != nil {
+		return err
 	}
 
-	v.voterList[voterId] = voter
+	v.stageChain(change)
 
-	if err := v.saveDB(); err != nil {
+	if err := v.persist(v.historyEntry(change, pollId), undo); err != nil {
 		return ErrSaveFailed.Error()
 	}
 
 	fmt.Println("The voter poll was successfully registered.")
 
-	v.PrintItemHistory(v.voterList[voterId].VoterHistory[pollId])
+	v.PrintItemHistory(v.store.Voters[voterId].VoterHistory[pollId])
 
 	return nil
 }
 
-func (v *VoterDB) UpdateVoterHistoryInfo(voterId int, pollId int, history process.VoterHistoryDTO) error {
+func (v *VoterDB) UpdateVoterHistoryInfo(voterId int, pollId int, history process.VoterHistoryDTO, expectedVersion int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
-	if err := v.loadDB(); err != nil {
-		return ErrFailedToLoadDB.Error()
+	undo := v.store.UndoVoter(voterId)
+
+	change, err := v.store.UpdateVoterHistoryInfo(voterId, pollId, history, expectedVersion)
+	if err != nil {
+		return err
 	}
 
-	if previousHistory, exists := v.voterList[voterId].VoterHistory[pollId]; exists {
+	v.stageChain(change)
 
-		currentTime := time.Now()
+	if err := v.persist(v.historyEntry(change, pollId), undo); err != nil {
+		return ErrSaveFailed.Error()
+	}
 
-		newHistory := VoterHistory{
-			PollId:   pollId,
-			VoteId:   history.GetVoteID(),
-			VoteDate: history.GetVoteDate(),
-			Created:  previousHistory.Created,
-			Modified: currentTime,
-		}
+	fmt.Println("The voter poll was successfully updated.")
 
-		v.voterList[voterId].VoterHistory[pollId] = newHistory
+	v.PrintItemHistory(v.store.Voters[voterId].VoterHistory[pollId])
 
-		if err := v.saveDB(); err != nil {
-			return ErrSaveFailed.Error()
-		}
+	return nil
+}
 
-		fmt.Println("The voter poll was successfully updated.")
+// DeleteSingleVoterPoll marks the voter history as deleted. It can be brought
+// back with RestoreVoterPoll.
+func (v *VoterDB) DeleteSingleVoterPoll(voterId int, pollId int, reason string, expectedVersion int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
-		v.PrintItemHistory(newHistory)
+	undo := v.store.UndoVoter(voterId)
 
-		return nil
+	change, err := v.store.DeleteSingleVoterPoll(voterId, pollId, reason, expectedVersion)
+	if err != nil {
+		return err
 	}
 
-	return ErrHistoryNotFound.Error()
+	v.stageChain(change)
 
-}
-
-func (v *VoterDB) DeleteSingleVoterPoll(voterId int, pollId int) error {
-	if err := v.loadDB(); err != nil {
-		return ErrFailedToLoadDB.Error()
+	if err := v.persist(v.historyEntry(change, pollId), undo); err != nil {
+		return ErrSaveFailed.Error()
 	}
 
-	if _, exists := v.voterList[voterId].VoterHistory[pollId]; exists {
-		delete(v.voterList[voterId].VoterHistory, pollId)
+	fmt.Println("The voter poll was successfully deleted.")
 
-		if err := v.saveDB(); err != nil {
-			return ErrSaveFailed.Error()
-		}
-
-		fmt.Println("The voter poll was successfully deleted.")
+	return nil
+}
 
-		return nil
-	}
+// GetVoterPoll returns the voter's history for the poll, deleted or not.
+func (v *VoterDB) GetVoterPoll(voterId int, pollId int) (process.VoterHistoryDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 
-	return ErrHistoryNotFound.Error()
+	return v.store.GetVoterPoll(voterId, pollId)
 }
-func (v *VoterDB) GetAllVoters() ([]retrieve.VoterDTO, error) {
 
-	if err := v.loadDB(); err != nil {
-		return nil, ErrFailedToLoadDB.Error()
-	}
+func (v *VoterDB) RestoreVoterPoll(voterId int, pollId int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 
-	var votersList []retrieve.VoterDTO
+	undo := v.store.UndoVoter(voterId)
 
-	for _, voter := range v.voterList {
+	change, err := v.store.RestoreVoterPoll(voterId, pollId)
+	if err != nil {
+		return err
+	}
 
-		voterDTO := retrieve.NewVoterDTO(
-			voter.Id,
-			voter.Name,
-			voter.Email,
-			v.copyVoterHistoryMap(voter.VoterHistory),
-			voter.Created,
-			voter.Modified,
-		)
+	v.stageChain(change)
 
-		votersList = append(votersList, voterDTO)
+	if err := v.persist(v.historyEntry(change, pollId), undo); err != nil {
+		return ErrSaveFailed.Error()
 	}
 
-	return votersList, nil
+	fmt.Println("The voter poll was successfully restored.")
+
+	return nil
 }
 
-func (v *VoterDB) GetSingleVoter(id int) (retrieve.VoterDTO, error) {
-	if err := v.loadDB(); err != nil {
-		return retrieve.VoterDTO{}, ErrFailedToLoadDB.Error()
-	}
+func (v *VoterDB) GetAllVoters(includeDeleted bool) ([]retrieve.VoterDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 
-	if voter, exists := v.voterList[id]; exists {
-		return retrieve.NewVoterDTO(
-			voter.Id,
-			voter.Name,
-			voter.Email,
-			v.copyVoterHistoryMap(voter.VoterHistory),
-			voter.Created,
-			voter.Modified,
-		), nil
-	}
+	return v.store.GetAllVoters(includeDeleted), nil
+}
 
-	return retrieve.VoterDTO{}, ErrVoterNotFound.Error()
+func (v *VoterDB) ListVoters(query retrieve.VoterQuery) (retrieve.VoterPageDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.ListVoters(query), nil
 }
 
-func (v *VoterDB) GetVoterHistory(voterId int) ([]retrieve.VoterHistoryDTO, error) {
-	if err := v.loadDB(); err != nil {
-		return nil, ErrFailedToLoadDB.Error()
-	}
+func (v *VoterDB) SearchVoters(query retrieve.SearchQuery) ([]retrieve.VoterMatchDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 
-	if _, exists := v.voterList[voterId]; exists {
+	return v.store.SearchVoters(query), nil
+}
 
-		if v.voterList[voterId].VoterHistory == nil {
-			return nil, ErrNoVoterHistory.Error()
-		}
+func (v *VoterDB) GetSingleVoter(id int, includeHistory bool) (retrieve.VoterDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 
-		var historyList []retrieve.VoterHistoryDTO
-
-		for _, item := range v.voterList[voterId].VoterHistory {
-			newHistory := retrieve.NewVoterHistoryDTO(
-				item.PollId,
-				item.VoteId,
-				item.VoteDate,
-				item.Created,
-				item.Modified,
-			)
-			historyList = append(historyList, newHistory)
-		}
+	return v.store.GetSingleVoter(id, includeHistory)
+}
 
-		return historyList, nil
-	}
+func (v *VoterDB) GetVoterHistory(voterId int, includeDeleted bool) ([]retrieve.VoterHistoryDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 
-	return nil, ErrVoterNotFound.Error()
+	return v.store.GetVoterHistory(voterId, includeDeleted)
 }
 
 func (v *VoterDB) GetSingleEvent(voterId int, pollId int) (retrieve.VoterHistoryDTO, error) {
-	if err := v.loadDB(); err != nil {
-		return retrieve.VoterHistoryDTO{}, ErrFailedToLoadDB.Error()
-	}
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 
-	if _, exists := v.voterList[voterId]; !exists {
-		return retrieve.VoterHistoryDTO{}, ErrVoterNotFound.Error()
-	}
+	return v.store.GetSingleEvent(voterId, pollId)
+}
 
-	if history, exists := v.voterList[voterId].VoterHistory[pollId]; exists {
+func (v *VoterDB) GetVoterByEmail(email string, includeHistory bool) (retrieve.VoterDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
 
-		return retrieve.NewVoterHistoryDTO(
-			history.PollId,
-			history.VoteId,
-			history.VoteDate,
-			history.Created,
-			history.Modified,
-		), nil
-	}
+	return v.store.GetVoterByEmail(email, includeHistory)
+}
 
-	return retrieve.VoterHistoryDTO{}, ErrHistoryNotFound.Error()
+// historyEntry is the journal entry for a change to one of the voter's
+// history records. The caller must hold the write lock.
+func (v *VoterDB) historyEntry(change state.Change, pollId int) journalEntry {
+	voter := v.store.Voters[change.VoterId]
+
+	return putHistoryEntry(voter.Id, voter.Version, voter.VoterHistory[pollId], change.Revisions)
 }
 
 func (v *VoterDB) PrintItem(item Voter) {
+	item.Revisions = nil
 	jsonBytes, _ := json.MarshalIndent(item, "", "  ")
 	fmt.Println(string(jsonBytes))
 }
@@ -392,34 +434,24 @@ func (v *VoterDB) PrintAllItems(itemList []Voter) {
 }
 
 func initDB(dbFileName string) error {
-	f, err := os.Create(dbFileName)
+	data, err := encodeDB(AppVersion, false, nil, nil, nil)
 	if err != nil {
 		return err
 	}
 
-	_, err = f.Write([]byte("[]"))
-	if err != nil {
-		return err
-	}
-
-	f.Close()
-
-	return nil
+	return writeFileAtomic(dbFileName, data, 0644)
 }
 
+// saveDB writes the in memory voters, polls and ballots to the database file. The
+// caller must hold the write lock.
 func (v *VoterDB) saveDB() error {
 
-	var voterList []Voter
-	for _, item := range v.voterList {
-		voterList = append(voterList, item)
-	}
-
-	data, err := json.MarshalIndent(voterList, "", "  ")
+	data, err := encodeDB(v.createdBy, v.chained, v.store.SortedVoters(), v.store.SortedPolls(), v.store.SortedBallots())
 	if err != nil {
 		return err
 	}
 
-	err = os.WriteFile(v.dbFileName, data, 0644)
+	err = writeFileAtomic(v.dbFileName, data, 0644)
 	if err != nil {
 		return err
 	}
@@ -427,39 +459,31 @@ func (v *VoterDB) saveDB() error {
 	return nil
 }
 
+// loadDB replaces the in memory voters, polls and ballots with the contents
+// of the database file. It is only called when the database is opened or
+// restored.
 func (v *VoterDB) loadDB() error {
 	data, err := os.ReadFile(v.dbFileName)
 	if err != nil {
-		return err
+		return ErrFailedToLoadDB.Error()
 	}
 
-	var voterList []Voter
-	err = json.Unmarshal(data, &voterList)
+	return v.loadData(data)
+}
+
+// loadData replaces the in memory voters, polls and ballots with data, which
+// is in the format of a database file.
+func (v *VoterDB) loadData(data []byte) error {
+	info, voterList, pollList, ballotList, err := decodeDB(v.dbFileName, data)
 	if err != nil {
 		return err
 	}
 
-	for _, item := range voterList {
-		v.voterList[item.Id] = item
-	}
-
-	return nil
-}
+	v.createdBy = info.CreatedBy
+	// a restored backup may be older than the chain, which is still kept
+	v.chained = v.chained || info.Chained
 
-func (v *VoterDB) copyVoterHistoryMap(history HistoryMap) retrieve.HistoryMap {
-	returnMap := make(retrieve.HistoryMap)
+	v.store = state.Load(voterList, pollList, ballotList)
 
-	for _, item := range history {
-		newHistory := retrieve.NewVoterHistoryDTO(
-			item.PollId,
-			item.PollId,
-			item.VoteDate,
-			item.Created,
-			item.Modified,
-		)
-
-		returnMap[item.PollId] = newHistory
-	}
-
-	return returnMap
+	return nil
 }
@@ -1,9 +1,14 @@
 package json
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"drexel.edu/voter-api/pkg/process"
 	"drexel.edu/voter-api/pkg/retrieve"
@@ -11,7 +16,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-var db VoterDB
+var db *VoterDB
 
 func init() {
 	Refresh()
@@ -35,7 +40,7 @@ func Refresh() {
 		os.Exit(1)
 	}
 
-	db = *testDB
+	db = testDB
 }
 
 func TestCreateNewJsonDB(t *testing.T) {
@@ -52,11 +57,8 @@ func TestRestore(t *testing.T) {
 	filePath := "./tmp_test2"
 	backUpFile := "../../../Data.Bak"
 
-	//Should overwrite with a blank file
-	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
-
-	assert.NoError(t, err, "Couldn't create a blank db file")
-	defer file.Close()
+	//Should start from a new db file
+	os.Remove(filePath)
 
 	dbTemp, err := NewJsonDB(filePath)
 	assert.NoError(t, err)
@@ -125,7 +127,7 @@ func TestCreateRetrieveVoterWithRandomData(t *testing.T) {
 	err := db.CreateVoter(expectedVoter)
 	assert.NoError(t, err)
 
-	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId())
+	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId(), true)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedVoter.GetId(), actualVoter.GetId())
 	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
@@ -148,10 +150,10 @@ func TestUpdateRetrieveVoterWithRandomData(t *testing.T) {
 		fake.Email(),
 	)
 
-	err = db.UpdateVoterInfo(expectedVoter)
+	err = db.UpdateVoterInfo(expectedVoter, process.AnyVersion)
 	assert.NoError(t, err)
 
-	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId())
+	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId(), true)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedVoter.GetId(), actualVoter.GetId())
 	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
@@ -168,18 +170,18 @@ func TestDeleteVoter(t *testing.T) {
 	err := db.CreateVoter(expectedVoter)
 	assert.NoError(t, err)
 
-	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId())
+	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId(), true)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedVoter.GetId(), actualVoter.GetId())
 	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
 	assert.Equal(t, expectedVoter.GetEmail(), actualVoter.GetEmail())
 
-	err = db.DeleteSingleVoter(expectedVoter.GetId())
+	err = db.DeleteSingleVoter(expectedVoter.GetId(), "", process.AnyVersion)
 	assert.NoError(t, err)
 
 	nullVoter := retrieve.VoterDTO{}
 
-	actualVoter, err = db.GetSingleVoter(expectedVoter.GetId())
+	actualVoter, err = db.GetSingleVoter(expectedVoter.GetId(), true)
 	assert.Error(t, err)
 	assert.Equal(t, nullVoter, actualVoter)
 	assert.Equal(t, ErrVoterNotFound.Error(), err)
@@ -201,13 +203,15 @@ func TestCreateRetrievePollWithRandomData(t *testing.T) {
 		fake.Date(),
 	)
 
+	createPolls(t, db, expectedPoll.GetPollID())
+
 	err = db.CreateVoterHistory(
 		expectedVoter.GetId(),
 		expectedPoll.GetPollID(),
 		expectedPoll)
 	assert.NoError(t, err)
 
-	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId())
+	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId(), true)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedVoter.GetId(), actualVoter.GetId())
 	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
@@ -237,6 +241,8 @@ func TestUpdateRetrievePollWithRandomData(t *testing.T) {
 		fake.Date(),
 	)
 
+	createPolls(t, db, expectedPoll.GetPollID())
+
 	err = db.CreateVoterHistory(
 		expectedVoter.GetId(),
 		expectedPoll.GetPollID(),
@@ -252,10 +258,12 @@ func TestUpdateRetrievePollWithRandomData(t *testing.T) {
 	err = db.UpdateVoterHistoryInfo(
 		expectedVoter.GetId(),
 		expectedPoll.GetPollID(),
-		expectedPoll)
+		expectedPoll,
+		process.AnyVersion,
+	)
 	assert.NoError(t, err)
 
-	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId())
+	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId(), true)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedVoter.GetId(), actualVoter.GetId())
 	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
@@ -285,13 +293,15 @@ func TestDeletePoll(t *testing.T) {
 		fake.Date(),
 	)
 
+	createPolls(t, db, expectedPoll.GetPollID())
+
 	err = db.CreateVoterHistory(
 		expectedVoter.GetId(),
 		expectedPoll.GetPollID(),
 		expectedPoll)
 	assert.NoError(t, err)
 
-	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId())
+	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId(), true)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedVoter.GetId(), actualVoter.GetId())
 	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
@@ -307,6 +317,8 @@ func TestDeletePoll(t *testing.T) {
 	err = db.DeleteSingleVoterPoll(
 		expectedVoter.GetId(),
 		expectedPoll.GetPollID(),
+		"",
+		process.AnyVersion,
 	)
 	assert.NoError(t, err)
 
@@ -323,11 +335,8 @@ func TestGetAllVoterHistory(t *testing.T) {
 
 	filePath := "./tmp_test2"
 
-	//Should overwrite with a blank file
-	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
-
-	assert.NoError(t, err, "Couldn't create a blank db file")
-	defer file.Close()
+	//Should start from a new db file
+	os.Remove(filePath)
 
 	dbTemp, err := NewJsonDB(filePath)
 	assert.NoError(t, err)
@@ -342,6 +351,8 @@ func TestGetAllVoterHistory(t *testing.T) {
 
 	var history [3]process.VoterHistoryDTO
 
+	createPolls(t, dbTemp, 1, 2, 3)
+
 	iterator := 1
 
 	for _, item := range history {
@@ -359,7 +370,7 @@ func TestGetAllVoterHistory(t *testing.T) {
 		iterator++
 	}
 
-	actualVoter, err := dbTemp.GetSingleVoter(expectedVoter.GetId())
+	actualVoter, err := dbTemp.GetSingleVoter(expectedVoter.GetId(), true)
 	assert.NoError(t, err)
 
 	assert.Equal(t, 3, len(actualVoter.GetHistory()))
@@ -386,11 +397,8 @@ func TestGetAllVoters(t *testing.T) {
 
 	fmt.Println(fmt.Printf("Testing in directory: %s", currentDir))
 
-	//Should overwrite with a blank file
-	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_TRUNC|os.O_CREATE, 0644)
-
-	assert.NoError(t, err, "Couldn't create a blank db file")
-	defer file.Close()
+	//Should start from a new db file
+	os.Remove(filePath)
 
 	dbTemp, err := NewJsonDB(filePath)
 	assert.NoError(t, err)
@@ -424,7 +432,7 @@ func TestGetAllVoters(t *testing.T) {
 	err = dbTemp.CreateVoter(item3)
 	assert.NoError(t, err)
 
-	actualVoters, err := dbTemp.GetAllVoters()
+	actualVoters, err := dbTemp.GetAllVoters(false)
 	assert.NoError(t, err)
 
 	assert.Equal(t, 3, len(actualVoters))
@@ -485,3 +493,1837 @@ func TestGetAllVoters(t *testing.T) {
 
 	os.Remove(filePath)
 }
+
+func TestConcurrentCreateVoters(t *testing.T) {
+
+	filePath := "./tmp_test4"
+
+	os.Remove(filePath)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 1; i <= 50; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			err := dbTemp.CreateVoter(process.NewVoterDTO(
+				id,
+				fake.Name(),
+				fake.Email(),
+			))
+			assert.NoError(t, err)
+
+			_, err = dbTemp.GetAllVoters(false)
+			assert.NoError(t, err)
+		}(i)
+	}
+
+	wg.Wait()
+
+	reloaded, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	actualVoters, err := reloaded.GetAllVoters(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 50, len(actualVoters))
+
+	os.Remove(filePath)
+}
+
+func TestLoadCorruptDB(t *testing.T) {
+
+	filePath := "./tmp_test5"
+
+	err := os.WriteFile(filePath, []byte(`[{"id": 1, "name": "trunc`), 0644)
+	assert.NoError(t, err)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.Error(t, err)
+	assert.Nil(t, dbTemp)
+	assert.ErrorIs(t, err, ErrCorruptDB.Error())
+
+	os.Remove(filePath)
+}
+
+func TestLoadEmptyDB(t *testing.T) {
+
+	filePath := "./tmp_test26"
+
+	err := os.WriteFile(filePath, nil, 0644)
+	assert.NoError(t, err)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.Nil(t, dbTemp)
+	assert.ErrorIs(t, err, ErrCorruptDB.Error())
+
+	// the empty file is left for the operator to restore, not replaced
+	info, err := os.Stat(filePath)
+	assert.NoError(t, err)
+	assert.Zero(t, info.Size())
+
+	os.Remove(filePath)
+}
+
+func TestRestoreFileOverCorruptDB(t *testing.T) {
+
+	filePath := "./tmp_test27"
+	backupFile := "./tmp_test27.bak"
+
+	os.Remove(filePath)
+	os.Remove(chainFileName(filePath))
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+	assert.NoError(t, dbTemp.EnableChain())
+	createPolls(t, dbTemp, 1, 2)
+
+	voter := process.NewVoterDTO(1, fake.Name(), fake.Email())
+	assert.NoError(t, dbTemp.CreateVoter(voter))
+	assert.NoError(t, dbTemp.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, time.Now())))
+
+	data, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(backupFile, data, 0644))
+
+	assert.NoError(t, dbTemp.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 2, time.Now())))
+
+	err = os.WriteFile(filePath, []byte(`[{"id": 1, "name": "trunc`), 0644)
+	assert.NoError(t, err)
+
+	_, err = NewJsonDB(filePath)
+	assert.ErrorIs(t, err, ErrCorruptDB.Error())
+
+	err = RestoreFile(filePath, backupFile)
+	assert.NoError(t, err)
+
+	restored, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	history, err := restored.GetVoterHistory(1, false)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	report, err := restored.VerifyChain("")
+	assert.NoError(t, err)
+	assert.Empty(t, report.GetProblems())
+
+	os.Remove(filePath)
+	os.Remove(backupFile)
+	os.Remove(chainFileName(filePath))
+}
+
+func TestRestoreFileRejectsCorruptBackup(t *testing.T) {
+
+	filePath := "./tmp_test28"
+	backupFile := "./tmp_test28.bak"
+
+	os.Remove(filePath)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+	assert.NoError(t, dbTemp.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email())))
+
+	before, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+
+	err = os.WriteFile(backupFile, []byte(`[{"id": 1, "name": "trunc`), 0644)
+	assert.NoError(t, err)
+
+	err = RestoreFile(filePath, backupFile)
+	assert.ErrorIs(t, err, ErrCorruptDB.Error())
+
+	after, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	os.Remove(filePath)
+	os.Remove(backupFile)
+}
+
+func TestExportFile(t *testing.T) {
+
+	filePath := "./tmp_test30"
+	exportFile := "./tmp_test30.export"
+
+	os.Remove(filePath)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+	createPolls(t, dbTemp, 1, 2)
+
+	for id := 1; id <= 3; id++ {
+		assert.NoError(t, dbTemp.CreateVoter(process.NewVoterDTO(id, fake.Name(), fake.Email())))
+	}
+
+	assert.NoError(t, dbTemp.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, time.Now())))
+	assert.NoError(t, dbTemp.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 2, time.Now())))
+	assert.NoError(t, dbTemp.DeleteSingleVoterPoll(1, 2, "", process.AnyVersion))
+	assert.NoError(t, dbTemp.DeleteSingleVoter(3, "moved", process.AnyVersion))
+
+	before, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+
+	count, err := ExportFile(filePath, exportFile)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	// the database is only read
+	after, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	data, err := os.ReadFile(exportFile)
+	assert.NoError(t, err)
+
+	var roll exportEnvelope
+	assert.NoError(t, json.Unmarshal(data, &roll))
+	assert.Equal(t, 2, roll.RecordCount)
+	assert.Equal(t, []int{1, 2}, []int{roll.Voters[0].Id, roll.Voters[1].Id})
+	assert.Len(t, roll.Voters[0].VoterHistory, 1)
+	assert.Contains(t, roll.Voters[0].VoterHistory, 1)
+	assert.Nil(t, roll.Voters[0].Revisions)
+
+	os.Remove(filePath)
+	os.Remove(exportFile)
+}
+
+func TestRestoreMissingBackupKeepsDB(t *testing.T) {
+
+	filePath := "./tmp_test6"
+
+	os.Remove(filePath)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	err = dbTemp.CreateVoter(process.NewVoterDTO(
+		1,
+		fake.Name(),
+		fake.Email(),
+	))
+	assert.NoError(t, err)
+
+	before, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+
+	err = dbTemp.RestoreDB("./does_not_exist")
+	assert.Error(t, err)
+
+	after, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	_, err = dbTemp.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+
+	os.Remove(filePath)
+}
+
+func TestFailedSaveLeavesMemoryUnchanged(t *testing.T) {
+
+	filePath := "./tmp_test29"
+
+	os.Remove(filePath)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+	createPolls(t, dbTemp, 1)
+
+	email := fake.Email()
+	assert.NoError(t, dbTemp.CreateVoter(process.NewVoterDTO(1, fake.Name(), email)))
+	assert.NoError(t, dbTemp.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, time.Now())))
+
+	// the directory does not exist, so every write fails
+	dbTemp.dbFileName = "./tmp_test29_missing/db"
+
+	err = dbTemp.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email()))
+	assert.Equal(t, ErrSaveFailed.Error(), err)
+
+	err = dbTemp.UpdateVoterInfo(process.NewVoterDTO(1, "Renamed", fake.Email()), process.AnyVersion)
+	assert.Equal(t, ErrSaveFailed.Error(), err)
+
+	err = dbTemp.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.Equal(t, ErrSaveFailed.Error(), err)
+
+	err = dbTemp.CreatePoll(process.NewPollDTO(2, fake.Sentence(3), "", time.Time{}, time.Time{}, process.PollOpen))
+	assert.Equal(t, ErrSaveFailed.Error(), err)
+
+	_, err = dbTemp.GetSingleVoter(2, true)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	_, err = dbTemp.GetSinglePoll(2)
+	assert.Error(t, err)
+
+	voter, err := dbTemp.GetVoterByEmail(email, true)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "Renamed", voter.GetName())
+	assert.Equal(t, 2, voter.GetVersion())
+
+	history, err := dbTemp.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, history.GetVersion())
+
+	dbTemp.dbFileName = filePath
+
+	// what was rolled back can be written once saving works again
+	assert.NoError(t, dbTemp.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email())))
+
+	os.Remove(filePath)
+}
+
+func TestJournalReplay(t *testing.T) {
+
+	filePath := "./tmp_test7"
+
+	os.Remove(filePath)
+	os.Remove(filePath + journalSuffix)
+
+	dbTemp, err := NewJournaledJsonDB(filePath, 100)
+	assert.NoError(t, err)
+
+	expectedVoter := process.NewVoterDTO(
+		1,
+		fake.Name(),
+		fake.Email(),
+	)
+	err = dbTemp.CreateVoter(expectedVoter)
+	assert.NoError(t, err)
+
+	err = dbTemp.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	expectedPoll := process.NewVoterHistoryDTO(
+		1,
+		1,
+		fake.Date(),
+	)
+	createPolls(t, dbTemp, expectedPoll.GetPollID())
+
+	err = dbTemp.CreateVoterHistory(expectedVoter.GetId(), expectedPoll.GetPollID(), expectedPoll)
+	assert.NoError(t, err)
+
+	err = dbTemp.DeleteSingleVoter(2, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	//the snapshot has not been rewritten yet
+	snapshot, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	snapshotVoters, err := parseDB(filePath, snapshot)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(snapshotVoters))
+
+	//simulate a crash part way through an append
+	journal, err := os.OpenFile(filePath+journalSuffix, os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	_, err = journal.Write([]byte(`{"op":"put_voter","voter_id":3,"vot`))
+	assert.NoError(t, err)
+	journal.Close()
+
+	reloaded, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	actualVoters, err := reloaded.GetAllVoters(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(actualVoters))
+
+	actualVoter, err := reloaded.GetSingleVoter(expectedVoter.GetId(), true)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
+	assert.Equal(t, 1, len(actualVoter.GetHistory()))
+
+	_, err = reloaded.GetSinglePoll(expectedPoll.GetPollID())
+	assert.NoError(t, err)
+
+	_, err = os.Stat(filePath + journalSuffix)
+	assert.True(t, os.IsNotExist(err))
+
+	os.Remove(filePath)
+}
+
+func TestFailedJournalWrite(t *testing.T) {
+
+	filePath := "./tmp_test34"
+
+	os.Remove(filePath)
+	os.Remove(filePath + journalSuffix)
+
+	dbTemp, err := NewJournaledJsonDB(filePath, 100)
+	assert.NoError(t, err)
+
+	assert.NoError(t, dbTemp.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email())))
+
+	before, err := os.ReadFile(filePath + journalSuffix)
+	assert.NoError(t, err)
+
+	// a handle that cannot be written to makes every append fail
+	journal := dbTemp.journal
+	dbTemp.journal, err = os.Open(filePath + journalSuffix)
+	assert.NoError(t, err)
+
+	err = dbTemp.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email()))
+	assert.Equal(t, ErrSaveFailed.Error(), err)
+
+	dbTemp.journal.Close()
+	dbTemp.journal = journal
+
+	after, err := os.ReadFile(filePath + journalSuffix)
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	// the entries after the failed one still replay
+	assert.NoError(t, dbTemp.CreateVoter(process.NewVoterDTO(3, fake.Name(), fake.Email())))
+
+	reloaded, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	voters, err := reloaded.GetAllVoters(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(voters))
+
+	_, err = reloaded.GetSingleVoter(2, false)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	dbTemp.journal.Close()
+	os.Remove(filePath)
+	os.Remove(filePath + journalSuffix)
+}
+
+func TestJournalCompaction(t *testing.T) {
+
+	filePath := "./tmp_test8"
+
+	os.Remove(filePath)
+	os.Remove(filePath + journalSuffix)
+
+	dbTemp, err := NewJournaledJsonDB(filePath, 2)
+	assert.NoError(t, err)
+
+	err = dbTemp.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = dbTemp.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	journal, err := os.ReadFile(filePath + journalSuffix)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(journal))
+
+	snapshot, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+
+	actualVoters, err := parseDB(filePath, snapshot)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(actualVoters))
+
+	assert.NoError(t, dbTemp.Close())
+
+	os.Remove(filePath)
+	os.Remove(filePath + journalSuffix)
+}
+
+func TestMigrateLegacyDB(t *testing.T) {
+
+	filePath := "./tmp_test9"
+	backupPath := "./tmp_test9.bak"
+
+	legacy := []byte(`[{"id": 1, "name": "Legacy", "email": "legacy@abc.com", "history": null}]`)
+
+	err := os.WriteFile(filePath, legacy, 0644)
+	assert.NoError(t, err)
+
+	info, err := ReadFormat(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, LegacySchemaVersion, info.SchemaVersion)
+
+	info, err = MigrateDB(filePath, backupPath)
+	assert.NoError(t, err)
+	assert.Equal(t, LegacySchemaVersion, info.SchemaVersion)
+	assert.Equal(t, 1, info.RecordCount)
+
+	backup, err := os.ReadFile(backupPath)
+	assert.NoError(t, err)
+	assert.Equal(t, legacy, backup)
+
+	info, err = ReadFormat(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, CurrentSchemaVersion, info.SchemaVersion)
+	assert.Equal(t, 1, info.RecordCount)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	actualVoter, err := dbTemp.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "Legacy", actualVoter.GetName())
+
+	os.Remove(filePath)
+	os.Remove(backupPath)
+}
+
+func TestRefuseFutureVersion(t *testing.T) {
+
+	filePath := "./tmp_test10"
+
+	err := os.WriteFile(filePath, []byte(`{"schema_version": 99, "created_by": "9.9.9", "record_count": 0, "voters": []}`), 0644)
+	assert.NoError(t, err)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.Error(t, err)
+	assert.Nil(t, dbTemp)
+	assert.ErrorIs(t, err, ErrUnsupportedVersion.Error())
+
+	os.Remove(filePath)
+}
+
+func TestBackupAndVerifySnapshot(t *testing.T) {
+
+	filePath := "./tmp_test11"
+	backupDir := t.TempDir()
+
+	os.Remove(filePath)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	err = dbTemp.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	snapshot, err := dbTemp.Backup(backupDir)
+	assert.NoError(t, err)
+	assert.NoError(t, VerifySnapshot(snapshot.Path))
+
+	snapshots, err := ListSnapshots(backupDir)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(snapshots))
+	assert.Equal(t, snapshot.Name, snapshots[0].Name)
+	assert.Equal(t, snapshot.Checksum, snapshots[0].Checksum)
+
+	voters, err := parseDB(snapshot.Path, mustReadFile(t, snapshot.Path))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(voters))
+
+	//any change to the snapshot is caught by the checksum
+	err = os.WriteFile(snapshot.Path, append(mustReadFile(t, snapshot.Path), ' '), 0644)
+	assert.NoError(t, err)
+	err = VerifySnapshot(snapshot.Path)
+	assert.Error(t, err)
+	assert.ErrorIs(t, err, ErrBadSnapshot.Error())
+
+	os.Remove(filePath)
+}
+
+func TestBackupFileIncludesJournal(t *testing.T) {
+
+	filePath := "./tmp_test37"
+	backupDir := t.TempDir()
+
+	os.Remove(filePath)
+	os.Remove(filePath + journalSuffix)
+
+	dbTemp, err := NewJournaledJsonDB(filePath, 100)
+	assert.NoError(t, err)
+
+	err = dbTemp.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	journal := mustReadFile(t, filePath+journalSuffix)
+
+	//the voter is only in the journal, as it is while the server is running
+	snapshot, err := BackupFile(filePath, backupDir)
+	assert.NoError(t, err)
+	assert.NoError(t, VerifySnapshot(snapshot.Path))
+
+	voters, err := parseDB(snapshot.Path, mustReadFile(t, snapshot.Path))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(voters))
+
+	//neither file is written to
+	assert.Equal(t, journal, mustReadFile(t, filePath+journalSuffix))
+
+	voters, err = parseDB(filePath, mustReadFile(t, filePath))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(voters))
+
+	dbTemp.journal.Close()
+	os.Remove(filePath)
+	os.Remove(filePath + journalSuffix)
+}
+
+func TestPruneSnapshots(t *testing.T) {
+
+	backupDir := t.TempDir()
+	data, err := encodeDB(AppVersion, false, nil, nil, nil)
+	assert.NoError(t, err)
+
+	newest := time.Date(2024, time.March, 20, 12, 0, 0, 0, time.UTC)
+
+	//two snapshots a day for the last 30 days
+	for day := 0; day < 30; day++ {
+		for _, hour := range []int{0, 6} {
+			created := newest.AddDate(0, 0, -day).Add(-time.Duration(hour) * time.Hour)
+			_, err := writeSnapshot(backupDir, data, created)
+			assert.NoError(t, err)
+		}
+	}
+
+	_, err = PruneSnapshots(backupDir, 3, 2)
+	assert.NoError(t, err)
+
+	snapshots, err := ListSnapshots(backupDir)
+	assert.NoError(t, err)
+
+	//3 daily snapshots, the newest of which also covers this week, plus the
+	//newest of the previous week
+	assert.Equal(t, 4, len(snapshots))
+	assert.Equal(t, newest, snapshots[0].Created)
+
+	for _, snapshot := range snapshots {
+		assert.NoError(t, VerifySnapshot(snapshot.Path))
+	}
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestCheckAndRepairVoters(t *testing.T) {
+
+	created := time.Date(2024, time.January, 2, 0, 0, 0, 0, time.UTC)
+
+	voterList := []Voter{
+		{
+			Id:    1,
+			Name:  fake.Name(),
+			Email: "badEmail",
+			VoterHistory: HistoryMap{
+				2: {PollId: 3, VoteId: 9, VoteDate: created, Created: created, Modified: created.AddDate(0, 0, -1)},
+			},
+			Modified: created,
+		},
+		{
+			Id:       1,
+			Name:     fake.Name(),
+			Email:    fake.Email(),
+			Created:  created,
+			Modified: created.AddDate(0, 0, 1),
+		},
+	}
+
+	problems := CheckVoters(voterList)
+
+	kinds := make(map[ProblemKind]int)
+	for _, problem := range problems {
+		kinds[problem.Kind]++
+	}
+
+	assert.Equal(t, 1, kinds[ProblemDuplicateVoter])
+	assert.Equal(t, 1, kinds[ProblemInvalidEmail])
+	assert.Equal(t, 1, kinds[ProblemMissingTimestamp])
+	assert.Equal(t, 1, kinds[ProblemHistoryKey])
+	assert.Equal(t, 1, kinds[ProblemVoteId])
+	assert.Equal(t, 1, kinds[ProblemModifiedBefore])
+
+	repaired := RepairVoters(voterList, time.Now())
+	assert.Equal(t, 1, len(repaired))
+	assert.Equal(t, voterList[1].Name, repaired[0].Name)
+	assert.Empty(t, CheckVoters(repaired))
+
+	//the history of the discarded duplicate is repaired when it is kept
+	repaired = RepairVoters(voterList[:1], time.Now())
+	assert.Equal(t, 2, repaired[0].VoterHistory[2].PollId)
+	assert.Equal(t, 2, repaired[0].VoterHistory[2].VoteId)
+	assert.Equal(t, created, repaired[0].Created)
+
+	remaining := CheckVoters(repaired)
+	assert.Equal(t, 1, len(remaining))
+	assert.Equal(t, ProblemInvalidEmail, remaining[0].Kind)
+
+	//duplicate emails are reported but left for an operator to resolve
+	email := fake.Email()
+	shared := []Voter{
+		{Id: 1, Name: fake.Name(), Email: email, Created: created, Modified: created},
+		{Id: 2, Name: fake.Name(), Email: strings.ToUpper(email), Created: created, Modified: created},
+	}
+
+	remaining = CheckVoters(shared)
+	assert.Equal(t, 1, len(remaining))
+	assert.Equal(t, ProblemDuplicateEmail, remaining[0].Kind)
+	assert.Equal(t, 2, remaining[0].VoterId)
+}
+
+func TestCheckUnparseableFile(t *testing.T) {
+
+	filePath := "./tmp_test12"
+
+	err := os.WriteFile(filePath, []byte(`[{"id": 1`), 0644)
+	assert.NoError(t, err)
+
+	problems, voterList, _, _, err := CheckFile(filePath)
+	assert.NoError(t, err)
+	assert.Nil(t, voterList)
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, ProblemParse, problems[0].Kind)
+
+	os.Remove(filePath)
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+
+	filePath := "./tmp_test13"
+
+	os.Remove(filePath)
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	expectedVoter := process.NewVoterDTO(1, fake.Name(), fake.Email())
+	err = dbTemp.CreateVoter(expectedVoter)
+	assert.NoError(t, err)
+
+	createPolls(t, dbTemp, 1)
+
+	expectedPoll := process.NewVoterHistoryDTO(1, 1, fake.Date())
+	err = dbTemp.CreateVoterHistory(1, 1, expectedPoll)
+	assert.NoError(t, err)
+
+	err = dbTemp.RestoreVoterPoll(1, 1)
+	assert.Equal(t, ErrHistoryNotDeleted.Error(), err)
+
+	err = dbTemp.DeleteSingleVoterPoll(1, 1, "entered in error", process.AnyVersion)
+	assert.NoError(t, err)
+
+	history, err := dbTemp.GetVoterHistory(1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(history))
+
+	history, err = dbTemp.GetVoterHistory(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(history))
+	assert.True(t, history[0].IsDeleted())
+	assert.Equal(t, "entered in error", history[0].GetDeleteReason())
+
+	err = dbTemp.CreateVoterHistory(1, 1, expectedPoll)
+	assert.Equal(t, ErrHistoryAlreadyExists.Error(), err)
+
+	err = dbTemp.DeleteSingleVoter(1, "duplicate registration", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = dbTemp.DeleteSingleVoter(1, "", process.AnyVersion)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	_, err = dbTemp.GetSingleVoter(1, true)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	err = dbTemp.CreateVoter(expectedVoter)
+	assert.Equal(t, ErrVoterAlreadyExists.Error(), err)
+
+	voters, err := dbTemp.GetAllVoters(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(voters))
+
+	//the tombstone survives a reload
+	reloaded, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	voters, err = reloaded.GetAllVoters(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(voters))
+	assert.True(t, voters[0].IsDeleted())
+	assert.Equal(t, "duplicate registration", voters[0].GetDeleteReason())
+
+	err = reloaded.RestoreVoter(1)
+	assert.NoError(t, err)
+
+	err = reloaded.RestoreVoterPoll(1, 1)
+	assert.NoError(t, err)
+
+	actualPoll, err := reloaded.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+	assert.False(t, actualPoll.IsDeleted())
+
+	err = reloaded.RestoreVoter(1)
+	assert.Equal(t, ErrVoterNotDeleted.Error(), err)
+
+	os.Remove(filePath)
+}
+
+func TestRevisionsAndRevert(t *testing.T) {
+
+	filePath := "./tmp_test14"
+
+	os.Remove(filePath)
+	os.Remove(filePath + journalSuffix)
+
+	dbTemp, err := NewJournaledJsonDB(filePath, 100)
+	assert.NoError(t, err)
+
+	err = dbTemp.CreateVoter(process.NewVoterDTO(1, "first", "first@abc.com"))
+	assert.NoError(t, err)
+
+	err = dbTemp.UpdateVoterInfo(process.NewVoterDTO(1, "second", "first@abc.com"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	createPolls(t, dbTemp, 7)
+
+	err = dbTemp.CreateVoterHistory(1, 7, process.NewVoterHistoryDTO(7, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	revisions, err := dbTemp.GetVoterRevisions(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, "create", revisions[0].GetAction())
+
+	changes := revisions[1].GetChanges()
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, "name", changes[0].GetField())
+	assert.Equal(t, "first", changes[0].GetOld())
+	assert.Equal(t, "second", changes[0].GetNew())
+
+	_, err = dbTemp.GetVoterRevision(1, 4)
+	assert.Equal(t, ErrRevisionNotFound.Error(), err)
+
+	err = dbTemp.RevertVoter(1, 1)
+	assert.NoError(t, err)
+
+	//revisions are replayed from the journal
+	dbTemp.journal.Close()
+	reloaded, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	voter, err := reloaded.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", voter.GetName())
+	assert.Equal(t, 0, len(voter.GetHistory()))
+
+	revision, err := reloaded.GetVoterRevision(1, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, "revert", revision.GetAction())
+	reverted := revision.GetVoter()
+	assert.Equal(t, "first", reverted.GetName())
+
+	err = reloaded.RevertVoter(2, 1)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	os.Remove(filePath)
+}
+
+func TestBaselineRevision(t *testing.T) {
+
+	filePath := "./tmp_test15"
+
+	//a voter written before revisions were kept
+	data, err := encodeDB(AppVersion, false, []Voter{{Id: 1, Name: "old", Email: "old@abc.com", Created: time.Now(), Modified: time.Now()}}, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filePath, data, 0644))
+
+	dbTemp, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	err = dbTemp.UpdateVoterInfo(process.NewVoterDTO(1, "new", "old@abc.com"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	revisions, err := dbTemp.GetVoterRevisions(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, "baseline", revisions[0].GetAction())
+	assert.Equal(t, 1, len(revisions[1].GetChanges()))
+
+	os.Remove(filePath)
+}
+
+func TestVersionCheck(t *testing.T) {
+	filePath := "./tmp_test16"
+
+	os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "first", fake.Email()), 1)
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "second", fake.Email()), 1)
+	assert.Equal(t, process.ErrVersionMismatch.Error(), err)
+
+	voter, err := db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", voter.GetName())
+	assert.Equal(t, 2, voter.GetVersion())
+
+	//history changes move the voter on as well
+	createPolls(t, db, 1)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	history, err := db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, history.GetVersion())
+
+	err = db.UpdateVoterHistoryInfo(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()), 2)
+	assert.Equal(t, process.ErrVersionMismatch.Error(), err)
+
+	err = db.DeleteSingleVoterPoll(1, 1, "", 1)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(1, "", 2)
+	assert.Equal(t, process.ErrVersionMismatch.Error(), err)
+
+	err = db.DeleteSingleVoter(1, "", 4)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(1, "", 4)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	os.Remove(filePath)
+}
+
+func TestGetVoterIncludeHistory(t *testing.T) {
+	filePath := "./tmp_test32"
+
+	os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	createPolls(t, db, 1)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), "pat@example.com"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	voter, err := db.GetSingleVoter(1, false)
+	assert.NoError(t, err)
+	assert.Empty(t, voter.GetHistory())
+
+	voter, err = db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Len(t, voter.GetHistory(), 1)
+
+	voter, err = db.GetVoterByEmail("pat@example.com", false)
+	assert.NoError(t, err)
+	assert.Empty(t, voter.GetHistory())
+
+	voter, err = db.GetVoterByEmail("pat@example.com", true)
+	assert.NoError(t, err)
+	assert.Len(t, voter.GetHistory(), 1)
+
+	os.Remove(filePath)
+}
+
+func TestUniqueEmail(t *testing.T) {
+	filePath := "./tmp_test17"
+
+	os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), "Pat@Example.com"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(2, fake.Name(), " pat@example.COM"))
+	assert.Equal(t, process.ErrEmailTaken.Error(), err)
+
+	err = db.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(2, fake.Name(), "pat@example.com"), process.AnyVersion)
+	assert.Equal(t, process.ErrEmailTaken.Error(), err)
+
+	voter, err := db.GetVoterByEmail("PAT@example.com", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, voter.GetId())
+
+	//the address is freed once its owner moves to another one
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, fake.Name(), "new@example.com"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	_, err = db.GetVoterByEmail("pat@example.com", true)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(2, fake.Name(), "pat@example.com"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	//the index is rebuilt when the file is loaded again
+	db, err = NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	voter, err = db.GetVoterByEmail("pat@example.com", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, voter.GetId())
+
+	os.Remove(filePath)
+}
+
+func TestPolls(t *testing.T) {
+	filePath := "./tmp_test18"
+
+	os.Remove(filePath)
+	os.Remove(filePath + journalSuffix)
+
+	db, err := NewJournaledJsonDB(filePath, 100)
+	assert.NoError(t, err)
+
+	opensAt := time.Date(2024, time.November, 5, 6, 0, 0, 0, time.UTC)
+
+	err = db.CreatePoll(process.NewPollDTO(2, "General", "", opensAt, opensAt.Add(14*time.Hour), process.PollDraft))
+	assert.NoError(t, err)
+
+	err = db.CreatePoll(process.NewPollDTO(2, "General", "", time.Time{}, time.Time{}, process.PollDraft))
+	assert.Equal(t, ErrPollAlreadyExists.Error(), err)
+
+	createPolls(t, db, 1, 3)
+
+	err = db.UpdatePoll(process.NewPollDTO(2, "General election", "", opensAt, opensAt.Add(14*time.Hour), process.PollOpen))
+	assert.NoError(t, err)
+
+	err = db.UpdatePoll(process.NewPollDTO(4, "Primary", "", time.Time{}, time.Time{}, process.PollOpen))
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	//history can only be recorded for a poll that exists
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 4, process.NewVoterHistoryDTO(4, 4, fake.Date()))
+	assert.Equal(t, process.ErrUnknownPoll.Error(), err)
+
+	err = db.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 2, fake.Date()))
+	assert.NoError(t, err)
+
+	err = db.DeletePoll(2)
+	assert.Equal(t, process.ErrPollInUse.Error(), err)
+
+	err = db.DeletePoll(3)
+	assert.NoError(t, err)
+
+	err = db.DeletePoll(3)
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	//the journal is replayed when the file is opened again
+	db.journal.Close()
+	db.journal = nil
+
+	db, err = NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	polls, err := db.GetAllPolls()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(polls))
+	assert.Equal(t, 1, polls[0].GetId())
+
+	poll, err := db.GetSinglePoll(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "General election", poll.GetTitle())
+	assert.Equal(t, process.PollOpen, poll.GetStatus())
+	assert.True(t, opensAt.Equal(poll.GetOpensAt()))
+
+	_, err = db.GetSinglePoll(3)
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	os.Remove(filePath)
+}
+
+func TestUpdatePollWithVotes(t *testing.T) {
+	filePath := "./tmp_test31"
+
+	os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	measure := process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"})
+
+	err = db.CreatePoll(measure)
+	assert.NoError(t, err)
+
+	err = db.CreatePoll(measure.WithSecret(true))
+	assert.Equal(t, ErrPollAlreadyExists.Error(), err)
+
+	//without votes the ballot can still change
+	err = db.UpdatePoll(measure.WithOptions([]string{"yes", "no", "abstain"}))
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("abstain"))
+	assert.NoError(t, err)
+
+	for _, changed := range []process.PollDTO{
+		measure,
+		measure.WithOptions([]string{"yes", "no", "abstain"}).WithRanked(true),
+		measure.WithOptions([]string{"yes", "no", "abstain"}).WithSecret(true),
+	} {
+		err = db.UpdatePoll(changed)
+		assert.Equal(t, process.ErrPollHasVotes.Error(), err)
+	}
+
+	//the rest of the poll can still change, and the options be reordered
+	err = db.UpdatePoll(process.NewPollDTO(1, "Measure 1A", "", time.Time{}, time.Time{}, process.PollClosed).WithOptions([]string{"abstain", "no", "yes"}))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Measure 1A", poll.GetTitle())
+	assert.Equal(t, []string{"abstain", "no", "yes"}, poll.GetOptions())
+	assert.False(t, poll.IsSecret())
+
+	//a deleted Poll event still counts as a vote
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.UpdatePoll(measure)
+	assert.Equal(t, process.ErrPollHasVotes.Error(), err)
+
+	//and so does a secret ballot
+	err = db.CreatePoll(process.NewPollDTO(2, "Measure 2", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}).WithSecret(true))
+	assert.NoError(t, err)
+
+	err = db.CastSecretBallot(1, 2, process.NewVoterHistoryDTO(2, 2, fake.Date()), process.NewBallotDTO(2, "QRSTUVWXYZ234567", "yes", nil))
+	assert.NoError(t, err)
+
+	err = db.UpdatePoll(process.NewPollDTO(2, "Measure 2", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}))
+	assert.Equal(t, process.ErrPollHasVotes.Error(), err)
+
+	os.Remove(filePath)
+}
+
+func createPolls(t *testing.T, db *VoterDB, ids ...int) {
+	for _, id := range ids {
+		err := db.CreatePoll(process.NewPollDTO(id, fake.Sentence(3), "", time.Time{}, time.Time{}, process.PollOpen))
+		assert.NoError(t, err)
+	}
+}
+
+func TestPollWindow(t *testing.T) {
+	filePath := "./tmp_test19"
+
+	os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	createPolls(t, db, 1)
+
+	opensAt := time.Date(2024, time.November, 5, 6, 0, 0, 0, time.UTC)
+
+	err = db.SetPollWindow(1, opensAt, opensAt.Add(14*time.Hour))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.True(t, opensAt.Equal(poll.GetOpensAt()))
+	assert.True(t, opensAt.Add(14*time.Hour).Equal(poll.GetClosesAt()))
+	assert.Equal(t, process.PollOpen, poll.GetStatus())
+
+	err = db.SetPollWindow(1, opensAt, time.Time{})
+	assert.NoError(t, err)
+
+	err = db.SetPollWindow(2, opensAt, time.Time{})
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	_, err = db.GetPoll(2)
+	assert.Equal(t, process.ErrUnknownPoll.Error(), err)
+
+	//the window is kept in the file
+	db, err = NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	poll, err = db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.True(t, opensAt.Equal(poll.GetOpensAt()))
+	assert.True(t, poll.GetClosesAt().IsZero())
+
+	os.Remove(filePath)
+}
+
+func TestPollVotes(t *testing.T) {
+	filePath := "./tmp_test20"
+
+	os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	err = db.CreatePoll(process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"yes", "no"}, poll.GetOptions())
+
+	for id := 1; id <= 3; id++ {
+		err = db.CreateVoter(process.NewVoterDTO(id, fake.Name(), fake.Email()))
+		assert.NoError(t, err)
+	}
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterHistoryInfo(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("no"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	//a deleted voter is neither counted nor has a vote
+	err = db.CreateVoterHistory(3, 1, process.NewVoterHistoryDTO(1, 3, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(3, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	votes, err := db.GetPollVotes(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(votes))
+
+	choices := []string{}
+	for _, vote := range votes {
+		choices = append(choices, vote.GetChoice())
+	}
+	assert.ElementsMatch(t, []string{"yes", "no"}, choices)
+
+	count, err := db.CountVoters()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = db.GetPollVotes(2)
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	//a ranked-choice poll keeps the order of the ranking
+	err = db.CreatePoll(process.NewPollDTO(2, "Board", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"alice", "bob"}).WithRanked(true))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 1, fake.Date()).WithRanking([]string{"bob", "alice"}))
+	assert.NoError(t, err)
+
+	ranked, err := db.GetSinglePoll(2)
+	assert.NoError(t, err)
+	assert.True(t, ranked.IsRanked())
+
+	votes, err = db.GetPollVotes(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob", "alice"}, votes[0].GetRanking())
+	//the options and choices are kept in the file
+	db, err = NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	poll, err = db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"yes", "no"}, poll.GetOptions())
+
+	history, err := db.GetSingleEvent(2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "no", history.GetChoice())
+
+	history, err = db.GetSingleEvent(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob", "alice"}, history.GetRanking())
+
+	os.Remove(filePath)
+}
+
+func TestSecretBallot(t *testing.T) {
+	filePath := "./tmp_test21"
+
+	os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	err = db.CreatePoll(process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}).WithSecret(true))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.True(t, poll.IsSecret())
+
+	for id := 1; id <= 2; id++ {
+		err = db.CreateVoter(process.NewVoterDTO(id, fake.Name(), fake.Email()))
+		assert.NoError(t, err)
+	}
+
+	err = db.CastSecretBallot(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()), process.NewBallotDTO(1, "QRSTUVWXYZ234567", "yes", nil))
+	assert.NoError(t, err)
+
+	err = db.CastSecretBallot(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()), process.NewBallotDTO(1, "ABCDEFGHIJKLMNOP", "no", nil))
+	assert.NoError(t, err)
+
+	//a receipt is never handed out twice
+	err = db.CreateVoter(process.NewVoterDTO(3, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CastSecretBallot(3, 1, process.NewVoterHistoryDTO(1, 3, fake.Date()), process.NewBallotDTO(1, "ABCDEFGHIJKLMNOP", "yes", nil))
+	assert.Equal(t, ErrBallotAlreadyExists.Error(), err)
+
+	//the history records that the voter took part but not how
+	history, err := db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "", history.GetChoice())
+
+	_, err = db.GetSingleEvent(3, 1)
+	assert.Error(t, err)
+
+	ballots, err := db.GetPollBallots(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ballots))
+	assert.Equal(t, "ABCDEFGHIJKLMNOP", ballots[0].GetReceipt())
+	assert.Equal(t, "no", ballots[0].GetChoice())
+	assert.Equal(t, "yes", ballots[1].GetChoice())
+
+	ballot, err := db.GetBallot(1, "QRSTUVWXYZ234567")
+	assert.NoError(t, err)
+	assert.Equal(t, "yes", ballot.GetChoice())
+
+	_, err = db.GetBallot(1, "AAAAAAAAAAAAAAAA")
+	assert.Equal(t, process.ErrUnknownBallot.Error(), err)
+
+	_, err = db.GetPollBallots(2)
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	//the ballots keep the poll in use
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.DeletePoll(1)
+	assert.Equal(t, process.ErrPollInUse.Error(), err)
+
+	//the ballots are kept in the file
+	db, err = NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	poll, err = db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.True(t, poll.IsSecret())
+
+	ballots, err = db.GetPollBallots(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ballots))
+
+	ballot, err = db.GetBallot(1, "ABCDEFGHIJKLMNOP")
+	assert.NoError(t, err)
+	assert.Equal(t, "no", ballot.GetChoice())
+
+	os.Remove(filePath)
+}
+
+func TestAuditChain(t *testing.T) {
+	filePath := "./tmp_test22"
+
+	os.Remove(filePath)
+	os.Remove(filePath + chainSuffix)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	_, err = db.VerifyChain("")
+	assert.Equal(t, process.ErrChainDisabled.Error(), err)
+
+	err = db.CreatePoll(process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}))
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	//history that existed before the chain is the baseline
+	err = db.EnableChain()
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterHistoryInfo(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("no"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	report, err := db.VerifyChain("")
+	assert.NoError(t, err)
+	assert.True(t, report.IsValid())
+	assert.Equal(t, 4, report.GetEntries())
+
+	head := report.GetHead()
+
+	//the chain is picked up again when the file is reopened
+	db, err = NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	report, err = db.VerifyChain(head)
+	assert.NoError(t, err)
+	assert.True(t, report.IsValid())
+
+	//restoring a backup is chained like any other change
+	data, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+
+	err = os.WriteFile(filePath+".bak", data, 0644)
+	assert.NoError(t, err)
+
+	err = db.RestoreVoterPoll(1, 1)
+	assert.NoError(t, err)
+
+	err = db.RestoreDB(filePath + ".bak")
+	assert.NoError(t, err)
+
+	report, err = db.VerifyChain(head)
+	assert.NoError(t, err)
+	assert.True(t, report.IsValid())
+	assert.Equal(t, 6, report.GetEntries())
+
+	//an edit made directly to the file
+	data, err = os.ReadFile(filePath)
+	assert.NoError(t, err)
+
+	err = os.WriteFile(filePath, bytes.Replace(data, []byte(`"no"`), []byte(`"yes"`), 1), 0644)
+	assert.NoError(t, err)
+
+	report, err = VerifyFile(filePath, head)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"voter 2 poll 1: choice changed outside the API"}, report.GetProblems())
+
+	os.Remove(filePath)
+	os.Remove(filePath + ".bak")
+	os.Remove(filePath + chainSuffix)
+}
+
+func TestAuditChainLost(t *testing.T) {
+	filePath := "./tmp_test33"
+
+	os.Remove(filePath)
+	os.Remove(filePath + chainSuffix)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	createPolls(t, db, 1)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.EnableChain()
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	//an emptied chain is not started again from the history in the file
+	err = os.Truncate(filePath+chainSuffix, 0)
+	assert.NoError(t, err)
+
+	db, err = NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	report, err := db.VerifyChain("")
+	assert.NoError(t, err)
+	assert.False(t, report.IsValid())
+	assert.Equal(t, 0, report.GetEntries())
+	assert.Contains(t, report.GetProblems(), filePath+chainSuffix+" is empty but the database has history")
+	assert.NoError(t, db.Close())
+
+	//nor is a removed one, which is not mistaken for a database without a chain
+	os.Remove(filePath + chainSuffix)
+
+	report, err = VerifyFile(filePath, "")
+	assert.NoError(t, err)
+	assert.False(t, report.IsValid())
+	assert.Contains(t, report.GetProblems(), filePath+chainSuffix+" is missing")
+
+	db, err = NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	err = db.EnableChain()
+	assert.NoError(t, err)
+
+	report, err = db.VerifyChain("")
+	assert.NoError(t, err)
+	assert.False(t, report.IsValid())
+	assert.Contains(t, report.GetProblems(), "voter 1 poll 1: history was added outside the API")
+
+	db.Close()
+	os.Remove(filePath)
+	os.Remove(filePath + chainSuffix)
+}
+
+func TestFailedChainWrite(t *testing.T) {
+	filePath := "./tmp_test35"
+
+	os.Remove(filePath)
+	os.Remove(filePath + chainSuffix)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	createPolls(t, db, 1, 2)
+
+	err = db.EnableChain()
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	// a handle that cannot be written to makes every append fail
+	chainFile := db.chainFile
+	db.chainFile, err = os.Open(filePath + chainSuffix)
+	assert.NoError(t, err)
+
+	//the vote is saved, so it is not reported as failed
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	_, err = db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+
+	reloaded, err := openReadOnly(filePath)
+	assert.NoError(t, err)
+	assert.Contains(t, reloaded.store.Voters[1].VoterHistory, 1)
+
+	//and its entry is reported as unwritten rather than as a change made
+	//outside the API
+	report, err := db.VerifyChain("")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1 entries could not be written to " + filePath + chainSuffix + " yet"}, report.GetProblems())
+
+	db.chainFile.Close()
+	db.chainFile = chainFile
+
+	//the entry is written ahead of the next change's
+	err = db.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 1, fake.Date()).WithChoice("no"))
+	assert.NoError(t, err)
+
+	report, err = db.VerifyChain("")
+	assert.NoError(t, err)
+	assert.True(t, report.IsValid())
+	assert.Equal(t, 2, report.GetEntries())
+
+	report, err = VerifyFile(filePath, "")
+	assert.NoError(t, err)
+	assert.True(t, report.IsValid())
+
+	db.Close()
+	os.Remove(filePath)
+	os.Remove(filePath + chainSuffix)
+}
+
+func TestRevertedHistory(t *testing.T) {
+	filePath := "./tmp_test36"
+
+	os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	createPolls(t, db, 1, 2)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	voteDate := time.Date(2024, time.November, 5, 12, 0, 0, 0, time.UTC)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, voteDate).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterHistoryInfo(1, 1, process.NewVoterHistoryDTO(1, 1, voteDate).WithChoice("no"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 1, voteDate))
+	assert.NoError(t, err)
+
+	//revision 2 has the first choice in poll 1, and nothing in poll 2, which
+	//the revert would delete rather than bring back
+	reverted, err := db.GetRevertedHistory(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []process.VoterHistoryDTO{process.NewVoterHistoryDTO(1, 1, voteDate).WithChoice("yes")}, reverted)
+
+	reverted, err = db.GetRevertedHistory(1, 4)
+	assert.NoError(t, err)
+	assert.Empty(t, reverted)
+
+	_, err = db.GetRevertedHistory(1, 9)
+	assert.Equal(t, ErrRevisionNotFound.Error(), err)
+
+	//a deleted vote is still returned so it can be checked before a restore
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	history, err := db.GetVoterPoll(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "no", history.GetChoice())
+	assert.True(t, voteDate.Equal(history.GetVoteDate()))
+
+	_, err = db.GetVoterPoll(1, 3)
+	assert.Equal(t, ErrHistoryNotFound.Error(), err)
+
+	_, err = db.GetVoterPoll(2, 1)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	os.Remove(filePath)
+}
+
+func TestListVotersNonASCII(t *testing.T) {
+	filePath := "./tmp_test38"
+
+	os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	for id, name := range map[int]string{1: "Émile", 2: "émilie", 3: "Eve", 4: "Ölaf"} {
+		err := db.CreateVoter(process.NewVoterDTO(id, name, fmt.Sprintf("v%d@Drexel.EDU", id)))
+		assert.NoError(t, err)
+	}
+
+	ids := func(query retrieve.VoterQuery) []int {
+		query.Page = 1
+		query.PerPage = retrieve.DefaultPerPage
+		if query.Sort == "" {
+			query.Sort = retrieve.SortId
+		}
+
+		page, err := db.ListVoters(query)
+		assert.NoError(t, err)
+
+		var ids []int
+		for _, voter := range page.GetVoters() {
+			ids = append(ids, voter.GetId())
+		}
+		return ids
+	}
+
+	//case is folded beyond ASCII, the same in every backend
+	assert.Equal(t, []int{1, 2}, ids(retrieve.VoterQuery{NamePrefix: "éMI"}))
+	assert.Equal(t, []int{4}, ids(retrieve.VoterQuery{NamePrefix: "ö"}))
+	assert.Equal(t, []int{1, 2, 3, 4}, ids(retrieve.VoterQuery{EmailDomain: "drexel.edu"}))
+	assert.Equal(t, []int{3, 1, 2, 4}, ids(retrieve.VoterQuery{Sort: retrieve.SortName}))
+
+	os.Remove(filePath)
+}
+
+func TestVoterProfile(t *testing.T) {
+	filePath := "./tmp_test23"
+
+	os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	dateOfBirth := time.Date(1990, time.May, 1, 0, 0, 0, 0, time.UTC)
+	home := process.NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104")
+	mail := process.NewAddressDTO("PO Box 1", "Philadelphia", "PA", "19104-0001")
+
+	err = db.CreateVoter(process.NewVoterDTO(1, "Pat", "pat@abc.com").WithProfile(dateOfBirth, home, mail, "Philadelphia County"))
+	assert.NoError(t, err)
+
+	//moving drops the mailing address
+	moved := process.NewAddressDTO("2 Oak Ave", "Pittsburgh", "PA", "15213")
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "Pat", "pat@abc.com").WithProfile(dateOfBirth, moved, process.AddressDTO{}, "Allegheny County"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	//the profile is kept in the file
+	db, err = NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	voter, err := db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, dateOfBirth, voter.GetDateOfBirth())
+	assert.Equal(t, retrieve.NewAddressDTO("2 Oak Ave", "Pittsburgh", "PA", "15213"), voter.GetResidentialAddress())
+	assert.Equal(t, retrieve.AddressDTO{}, voter.GetMailingAddress())
+	assert.Equal(t, "Allegheny County", voter.GetJurisdiction())
+
+	revisions, err := db.GetVoterRevisions(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, 8, len(revisions[1].GetChanges()))
+
+	err = db.RevertVoter(1, 1)
+	assert.NoError(t, err)
+
+	voter, err = db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, retrieve.NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104"), voter.GetResidentialAddress())
+	assert.Equal(t, retrieve.NewAddressDTO("PO Box 1", "Philadelphia", "PA", "19104-0001"), voter.GetMailingAddress())
+	assert.Equal(t, "Philadelphia County", voter.GetJurisdiction())
+
+	os.Remove(filePath)
+}
+
+func TestListVoters(t *testing.T) {
+	filePath := "./tmp_test24"
+
+	os.Remove(filePath)
+	defer os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	createPolls(t, db, 1)
+
+	var middle time.Time
+
+	for id, name := range []string{"", "carol", "Alice", "bob", "alan"} {
+		if id == 0 {
+			continue
+		}
+		if id == 3 {
+			middle = time.Now()
+		}
+
+		domain := "drexel.edu"
+		if id == 2 {
+			domain = "example.com"
+		}
+
+		err := db.CreateVoter(process.NewVoterDTO(id, name, name+"@"+domain))
+		assert.NoError(t, err)
+
+		//keeps the created times apart
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	for _, id := range []int{1, 2, 3} {
+		err := db.CreateVoterHistory(id, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+		assert.NoError(t, err)
+	}
+
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(4, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	ids := func(query retrieve.VoterQuery) ([]int, int) {
+		if query.Sort == "" {
+			query.Sort = retrieve.SortId
+		}
+		if query.Page == 0 {
+			query.Page = 1
+		}
+		if query.PerPage == 0 {
+			query.PerPage = retrieve.DefaultPerPage
+		}
+
+		page, err := db.ListVoters(query)
+		assert.NoError(t, err)
+
+		var ids []int
+		for _, voter := range page.GetVoters() {
+			ids = append(ids, voter.GetId())
+		}
+		return ids, page.GetTotal()
+	}
+
+	list, total := ids(retrieve.VoterQuery{})
+	assert.Equal(t, []int{1, 2, 3}, list)
+	assert.Equal(t, 3, total)
+
+	list, total = ids(retrieve.VoterQuery{Sort: "name", IncludeDeleted: true})
+	assert.Equal(t, []int{4, 2, 3, 1}, list)
+	assert.Equal(t, 4, total)
+
+	list, total = ids(retrieve.VoterQuery{Sort: "-created", Page: 2, PerPage: 2})
+	assert.Equal(t, []int{1}, list)
+	assert.Equal(t, 3, total)
+
+	list, _ = ids(retrieve.VoterQuery{NamePrefix: "AL", IncludeDeleted: true})
+	assert.Equal(t, []int{2, 4}, list)
+
+	list, _ = ids(retrieve.VoterQuery{EmailDomain: "Drexel.edu"})
+	assert.Equal(t, []int{1, 3}, list)
+
+	list, _ = ids(retrieve.VoterQuery{CreatedAfter: middle})
+	assert.Equal(t, []int{3}, list)
+
+	list, _ = ids(retrieve.VoterQuery{CreatedBefore: middle})
+	assert.Equal(t, []int{1, 2}, list)
+
+	list, _ = ids(retrieve.VoterQuery{VotedInPoll: 1})
+	assert.Equal(t, []int{2, 3}, list)
+
+	//history is only loaded when asked for
+	page, err := db.ListVoters(retrieve.VoterQuery{Sort: retrieve.SortId, Page: 1, PerPage: 1, VotedInPoll: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(page.GetVoters()[0].GetHistory()))
+
+	page, err = db.ListVoters(retrieve.VoterQuery{Sort: retrieve.SortId, Page: 1, PerPage: 1, VotedInPoll: 1, IncludeHistory: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page.GetVoters()[0].GetHistory()))
+}
+
+func TestSearchVoters(t *testing.T) {
+	filePath := "./tmp_test25"
+
+	os.Remove(filePath)
+	defer os.Remove(filePath)
+
+	db, err := NewJsonDB(filePath)
+	assert.NoError(t, err)
+
+	for id, name := range []string{"", "Anna Kopp", "Ben Koepp", "José Núñez", "Carl Smith"} {
+		if id == 0 {
+			continue
+		}
+
+		err := db.CreateVoter(process.NewVoterDTO(id, name, fmt.Sprintf("voter%d@drexel.edu", id)))
+		assert.NoError(t, err)
+	}
+
+	search := func(text string, limit int) []string {
+		matches, err := db.SearchVoters(retrieve.SearchQuery{Text: text, Limit: limit})
+		assert.NoError(t, err)
+
+		var found []string
+		for _, match := range matches {
+			voter := match.GetVoter()
+			found = append(found, fmt.Sprintf("%d:%d", voter.GetId(), match.GetDistance()))
+		}
+		return found
+	}
+
+	assert.Equal(t, []string{"2:0", "1:1"}, search("Koepp", 10))
+	assert.Equal(t, []string{"2:0"}, search("Koepp", 1))
+	assert.Equal(t, []string{"3:0"}, search("jose nunez", 10))
+	assert.Empty(t, search("--", 10))
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(2, "Ben Smyth", "voter2@drexel.edu"), process.AnyVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1:1"}, search("Koepp", 10))
+	assert.Equal(t, []string{"4:0", "2:1"}, search("smith", 10))
+
+	err = db.DeleteSingleVoter(1, "", process.AnyVersion)
+	assert.NoError(t, err)
+	assert.Empty(t, search("Koepp", 10))
+
+	//the index is rebuilt when the file is opened again
+	db, err = NewJsonDB(filePath)
+	assert.NoError(t, err)
+	assert.Empty(t, search("Koepp", 10))
+	assert.Equal(t, []string{"4:0", "2:1"}, search("smith", 10))
+
+	err = db.RestoreVoter(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1:1"}, search("Koepp", 10))
+
+	err = db.RevertVoter(2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2:0", "1:1"}, search("Koepp", 10))
+}
@@ -0,0 +1,56 @@
+package json
+
+import (
+	"fmt"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+)
+
+// GetVoterRevisions lists the revisions of a voter, deleted or not.
+func (v *VoterDB) GetVoterRevisions(voterId int) ([]retrieve.RevisionDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetVoterRevisions(voterId)
+}
+
+func (v *VoterDB) GetVoterRevision(voterId int, number int) (retrieve.RevisionDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetVoterRevision(voterId, number)
+}
+
+// GetRevertedHistory returns the history that reverting the voter to the
+// given revision would bring back or change.
+func (v *VoterDB) GetRevertedHistory(voterId int, number int) ([]process.VoterHistoryDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetRevertedHistory(voterId, number)
+}
+
+// RevertVoter sets the voter and its history back to the given revision.
+// History added after that revision is marked as deleted rather than removed.
+func (v *VoterDB) RevertVoter(voterId int, number int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	undo := v.store.UndoVoter(voterId)
+
+	change, err := v.store.RevertVoter(voterId, number)
+	if err != nil {
+		return err
+	}
+
+	v.stageChain(change)
+
+	if err := v.persist(replaceVoterEntry(v.store.Voters[voterId], change.Revisions), undo); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	fmt.Println("The voter was successfully reverted.")
+
+	return nil
+}
@@ -1,16 +1,10 @@
 package json
 
-import (
-	"time"
-)
-
-type HistoryMap map[int]VoterHistory
+import "drexel.edu/voter-api/pkg/storage/state"
 
-type Voter struct {
-	Id           int        `json:"id"`
-	Name         string     `json:"name"`
-	Email        string     `json:"email"`
-	VoterHistory HistoryMap `json:"history"`
-	Created      time.Time  `json:"created"`
-	Modified     time.Time  `json:"modified"`
-}
+// The records are kept in the state shared with the memory repository, whose
+// json tags are the format of the file.
+type (
+	HistoryMap = state.HistoryMap
+	Voter      = state.Voter
+)
@@ -1,13 +1,5 @@
 package json
 
-import (
-	"time"
-)
+import "drexel.edu/voter-api/pkg/storage/state"
 
-type VoterHistory struct {
-	PollId   int       `json:"poll_id"`
-	VoteId   int       `json:"vote_id"`
-	VoteDate time.Time `json:"vote_date"`
-	Created  time.Time `json:"created"`
-	Modified time.Time `json:"modified"`
-}
+type VoterHistory = state.VoterHistory
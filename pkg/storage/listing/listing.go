@@ -0,0 +1,116 @@
+package listing
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"drexel.edu/voter-api/pkg/retrieve"
+)
+
+// Record is what a voter is filtered and sorted on by the backends that keep
+// their voters in memory.
+type Record struct {
+	Id       int
+	Name     string
+	Email    string
+	Created  time.Time
+	Modified time.Time
+	Deleted  bool
+
+	// Voted is whether the voter has history for the query's VotedInPoll
+	// that has not been deleted. It is ignored if the query has no poll.
+	Voted bool
+}
+
+// Select filters and sorts the records by the query and returns the ids on
+// its page, along with how many records matched in total.
+func Select(query retrieve.VoterQuery, records []Record) ([]int, int) {
+	var matched []Record
+
+	for _, record := range records {
+		if Matches(query, record) {
+			matched = append(matched, record)
+		}
+	}
+
+	field, descending := query.SortField()
+
+	sort.Slice(matched, func(i, j int) bool {
+		a, b := matched[i], matched[j]
+
+		var compare int
+		switch field {
+		case retrieve.SortName:
+			compare = strings.Compare(NameKey(a.Name), NameKey(b.Name))
+		case retrieve.SortCreated:
+			compare = a.Created.Compare(b.Created)
+		case retrieve.SortModified:
+			compare = a.Modified.Compare(b.Modified)
+		}
+
+		if descending {
+			compare = -compare
+		}
+
+		if compare != 0 {
+			return compare < 0
+		}
+		return a.Id < b.Id
+	})
+
+	// the offset is clamped so a query that was not checked by the service
+	// cannot index out of range
+	start := min(max(query.Offset(), 0), len(matched))
+	end := start + min(max(query.PerPage, 0), len(matched)-start)
+
+	var ids []int
+	for _, record := range matched[start:end] {
+		ids = append(ids, record.Id)
+	}
+
+	return ids, len(matched)
+}
+
+// NameKey returns the form of a name that the name filter and sort compare.
+// Backends that cannot fold case the same way store it with the voter.
+func NameKey(name string) string {
+	return strings.ToLower(name)
+}
+
+// DomainKey returns the domain of an email in the form the email domain
+// filter compares.
+func DomainKey(email string) string {
+	_, domain, _ := strings.Cut(email, "@")
+
+	return strings.ToLower(strings.TrimSpace(domain))
+}
+
+// Matches reports whether the record passes every filter in the query.
+func Matches(query retrieve.VoterQuery, record Record) bool {
+	if record.Deleted && !query.IncludeDeleted {
+		return false
+	}
+
+	if !strings.HasPrefix(NameKey(record.Name), NameKey(query.NamePrefix)) {
+		return false
+	}
+
+	if query.EmailDomain != "" && DomainKey(record.Email) != strings.ToLower(query.EmailDomain) {
+		return false
+	}
+
+	if !inRange(record.Created, query.CreatedAfter, query.CreatedBefore) || !inRange(record.Modified, query.ModifiedAfter, query.ModifiedBefore) {
+		return false
+	}
+
+	return query.VotedInPoll == 0 || record.Voted
+}
+
+func inRange(t time.Time, after time.Time, before time.Time) bool {
+	if !after.IsZero() && t.Before(after) {
+		return false
+	}
+
+	return before.IsZero() || t.Before(before)
+}
@@ -0,0 +1,83 @@
+package listing
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"drexel.edu/voter-api/pkg/retrieve"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var records = []Record{
+	{Id: 1, Name: "carol", Email: "carol@drexel.edu", Created: start, Modified: start.Add(5 * time.Hour)},
+	{Id: 2, Name: "Alice", Email: "alice@example.com", Created: start.Add(time.Hour), Modified: start.Add(time.Hour), Voted: true},
+	{Id: 3, Name: "bob", Email: "bob@Drexel.edu", Created: start.Add(2 * time.Hour), Modified: start.Add(2 * time.Hour), Voted: true},
+	{Id: 4, Name: "alan", Email: "alan@drexel.edu", Created: start.Add(3 * time.Hour), Modified: start.Add(3 * time.Hour), Deleted: true},
+}
+
+func query() retrieve.VoterQuery {
+	return retrieve.VoterQuery{Sort: retrieve.SortId, Page: 1, PerPage: retrieve.DefaultPerPage}
+}
+
+func TestSelect(t *testing.T) {
+	ids, total := Select(query(), records)
+	assert.Equal(t, []int{1, 2, 3}, ids)
+	assert.Equal(t, 3, total)
+
+	//an offset that overflowed is past the end rather than out of range
+	q := query()
+	q.Page, q.PerPage = math.MaxInt, 2
+	ids, total = Select(q, records)
+	assert.Empty(t, ids)
+	assert.Equal(t, 3, total)
+
+	q = query()
+	q.Sort = "name"
+	q.IncludeDeleted = true
+	ids, total = Select(q, records)
+	assert.Equal(t, []int{4, 2, 3, 1}, ids)
+	assert.Equal(t, 4, total)
+
+	q = query()
+	q.Sort = "-created"
+	q.Page = 2
+	q.PerPage = 2
+	ids, total = Select(q, records)
+	assert.Equal(t, []int{1}, ids)
+	assert.Equal(t, 3, total)
+
+	q.Page = 3
+	ids, _ = Select(q, records)
+	assert.Empty(t, ids)
+}
+
+func TestMatches(t *testing.T) {
+	q := query()
+	q.NamePrefix = "AL"
+	ids, _ := Select(q, records)
+	assert.Equal(t, []int{2}, ids)
+
+	q = query()
+	q.EmailDomain = "drexel.EDU"
+	ids, _ = Select(q, records)
+	assert.Equal(t, []int{1, 3}, ids)
+
+	q = query()
+	q.CreatedAfter = start.Add(time.Hour)
+	q.CreatedBefore = start.Add(2 * time.Hour)
+	ids, _ = Select(q, records)
+	assert.Equal(t, []int{2}, ids)
+
+	q = query()
+	q.ModifiedAfter = start.Add(4 * time.Hour)
+	ids, _ = Select(q, records)
+	assert.Equal(t, []int{1}, ids)
+
+	q = query()
+	q.VotedInPoll = 1
+	ids, _ = Select(q, records)
+	assert.Equal(t, []int{2, 3}, ids)
+}
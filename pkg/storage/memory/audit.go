@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/chain"
+	"drexel.edu/voter-api/pkg/storage/state"
+)
+
+// EnableChain starts keeping the audit chain over voter history. History
+// that already exists is chained as a baseline.
+func (v *VoterDB) EnableChain() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.chainEnabled {
+		return nil
+	}
+
+	v.auditChain = chain.Baseline(chain.Entry{}, v.store.HistoryRecords(), time.Now())
+	v.chainEnabled = true
+
+	return nil
+}
+
+func (v *VoterDB) VerifyChain(head string) (retrieve.ChainReportDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	if !v.chainEnabled {
+		return retrieve.ChainReportDTO{}, process.ErrChainDisabled.Error()
+	}
+
+	report := chain.Verify(v.auditChain, v.store.HistoryRecords(), head)
+
+	return retrieve.NewChainReportDTO(report.Entries, report.Head, report.Problems), nil
+}
+
+// appendChain chains the history records the change made different. The
+// caller must hold the write lock.
+func (v *VoterDB) appendChain(change state.Change) {
+	if !v.chainEnabled {
+		return
+	}
+
+	head := chain.Entry{}
+	if len(v.auditChain) > 0 {
+		head = v.auditChain[len(v.auditChain)-1]
+	}
+
+	v.auditChain = append(v.auditChain, chain.Next(head, change.VoterId, change.Before, change.After, change.Action, change.Time)...)
+}
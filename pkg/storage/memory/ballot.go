@@ -0,0 +1,29 @@
+package memory
+
+import (
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+)
+
+// CastSecretBallot records the history and stores the ballot together, so
+// neither is kept without the other.
+func (v *VoterDB) CastSecretBallot(voterId int, pollId int, history process.VoterHistoryDTO, ballot process.BallotDTO) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.apply(v.store.CastSecretBallot(voterId, pollId, history, ballot))
+}
+
+func (v *VoterDB) GetPollBallots(pollId int) ([]retrieve.BallotDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetPollBallots(pollId)
+}
+
+func (v *VoterDB) GetBallot(pollId int, receipt string) (retrieve.BallotDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetBallot(pollId, receipt)
+}
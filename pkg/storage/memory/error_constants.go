@@ -0,0 +1,18 @@
+package memory
+
+import "drexel.edu/voter-api/pkg/storage/state"
+
+// The memory repository can only fail the checks made by the state it keeps.
+const (
+	ErrVoterAlreadyExists   = state.ErrVoterAlreadyExists
+	ErrVoterNotFound        = state.ErrVoterNotFound
+	ErrHistoryNotFound      = state.ErrHistoryNotFound
+	ErrHistoryAlreadyExists = state.ErrHistoryAlreadyExists
+	ErrNoVoterHistory       = state.ErrNoVoterHistory
+	ErrVoterNotDeleted      = state.ErrVoterNotDeleted
+	ErrHistoryNotDeleted    = state.ErrHistoryNotDeleted
+	ErrRevisionNotFound     = state.ErrRevisionNotFound
+	ErrPollAlreadyExists    = state.ErrPollAlreadyExists
+	ErrPollNotFound         = state.ErrPollNotFound
+	ErrBallotAlreadyExists  = state.ErrBallotAlreadyExists
+)
@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+)
+
+func (v *VoterDB) CreatePoll(poll process.PollDTO) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.store.CreatePoll(poll)
+}
+
+func (v *VoterDB) UpdatePoll(poll process.PollDTO) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.store.UpdatePoll(poll)
+}
+
+// DeletePoll removes the poll for good. Polls that any voter history or
+// secret ballot refers to are kept.
+func (v *VoterDB) DeletePoll(id int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.store.DeletePoll(id)
+}
+
+func (v *VoterDB) SetPollWindow(id int, opensAt time.Time, closesAt time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.store.SetPollWindow(id, opensAt, closesAt)
+}
+
+// GetPoll is used by the process service to check the poll window.
+func (v *VoterDB) GetPoll(id int) (process.PollDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetPoll(id)
+}
+
+func (v *VoterDB) GetAllPolls() ([]retrieve.PollDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetAllPolls(), nil
+}
+
+func (v *VoterDB) GetSinglePoll(id int) (retrieve.PollDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetSinglePoll(id)
+}
+
+// GetPollVotes returns the history recorded for the poll by voters that have
+// not been deleted, leaving out deleted history.
+func (v *VoterDB) GetPollVotes(pollId int) ([]retrieve.VoterHistoryDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetPollVotes(pollId)
+}
+
+func (v *VoterDB) CountVoters() (int, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.CountVoters(), nil
+}
@@ -0,0 +1,185 @@
+package memory
+
+import (
+	"sync"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/chain"
+	"drexel.edu/voter-api/pkg/storage/state"
+)
+
+// VoterDB is a repository that only lives in memory. It keeps the same state
+// as the json repository but nothing is written to disk, which makes it
+// suitable for tests and demos.
+type VoterDB struct {
+	mu    sync.RWMutex
+	store *state.Store
+
+	// auditChain is only kept once EnableChain is called
+	auditChain   []chain.Entry
+	chainEnabled bool
+}
+
+func NewMemoryDB() *VoterDB {
+	return &VoterDB{
+		store: state.New(),
+	}
+}
+
+func (v *VoterDB) CreateVoter(voter process.VoterDTO) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.apply(v.store.CreateVoter(voter))
+}
+
+func (v *VoterDB) UpdateVoterInfo(voter process.VoterDTO, expectedVersion int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.apply(v.store.UpdateVoterInfo(voter, expectedVersion))
+}
+
+func (v *VoterDB) DeleteSingleVoter(id int, reason string, expectedVersion int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.apply(v.store.DeleteSingleVoter(id, reason, expectedVersion))
+}
+
+func (v *VoterDB) RestoreVoter(id int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.apply(v.store.RestoreVoter(id))
+}
+
+func (v *VoterDB) CreateVoterHistory(voterId int, pollId int, history process.VoterHistoryDTO) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.apply(v.store.CreateVoterHistory(voterId, pollId, history))
+}
+
+func (v *VoterDB) UpdateVoterHistoryInfo(voterId int, pollId int, history process.VoterHistoryDTO, expectedVersion int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.apply(v.store.UpdateVoterHistoryInfo(voterId, pollId, history, expectedVersion))
+}
+
+func (v *VoterDB) DeleteSingleVoterPoll(voterId int, pollId int, reason string, expectedVersion int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.apply(v.store.DeleteSingleVoterPoll(voterId, pollId, reason, expectedVersion))
+}
+
+// GetVoterPoll returns the voter's history for the poll, deleted or not.
+func (v *VoterDB) GetVoterPoll(voterId int, pollId int) (process.VoterHistoryDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetVoterPoll(voterId, pollId)
+}
+
+func (v *VoterDB) RestoreVoterPoll(voterId int, pollId int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.apply(v.store.RestoreVoterPoll(voterId, pollId))
+}
+
+func (v *VoterDB) GetAllVoters(includeDeleted bool) ([]retrieve.VoterDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetAllVoters(includeDeleted), nil
+}
+
+func (v *VoterDB) ListVoters(query retrieve.VoterQuery) (retrieve.VoterPageDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.ListVoters(query), nil
+}
+
+func (v *VoterDB) SearchVoters(query retrieve.SearchQuery) ([]retrieve.VoterMatchDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.SearchVoters(query), nil
+}
+
+func (v *VoterDB) GetSingleVoter(id int, includeHistory bool) (retrieve.VoterDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetSingleVoter(id, includeHistory)
+}
+
+func (v *VoterDB) GetVoterHistory(voterId int, includeDeleted bool) ([]retrieve.VoterHistoryDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetVoterHistory(voterId, includeDeleted)
+}
+
+func (v *VoterDB) GetSingleEvent(voterId int, pollId int) (retrieve.VoterHistoryDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetSingleEvent(voterId, pollId)
+}
+
+func (v *VoterDB) GetVoterRevisions(voterId int) ([]retrieve.RevisionDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetVoterRevisions(voterId)
+}
+
+func (v *VoterDB) GetVoterRevision(voterId int, number int) (retrieve.RevisionDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetVoterRevision(voterId, number)
+}
+
+// GetRevertedHistory returns the history that reverting the voter to the
+// given revision would bring back or change.
+func (v *VoterDB) GetRevertedHistory(voterId int, number int) ([]process.VoterHistoryDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetRevertedHistory(voterId, number)
+}
+
+// RevertVoter sets the voter and its history back to the given revision.
+// History added after that revision is marked as deleted rather than removed.
+func (v *VoterDB) RevertVoter(voterId int, number int) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.apply(v.store.RevertVoter(voterId, number))
+}
+
+func (v *VoterDB) GetVoterByEmail(email string, includeHistory bool) (retrieve.VoterDTO, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.store.GetVoterByEmail(email, includeHistory)
+}
+
+// apply chains a change the store has made, there is nothing else to keep.
+// The caller must hold the write lock.
+func (v *VoterDB) apply(change state.Change, err error) error {
+	if err != nil {
+		return err
+	}
+
+	v.appendChain(change)
+
+	return nil
+}
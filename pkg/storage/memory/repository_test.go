@@ -0,0 +1,897 @@
+package memory
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	fake "github.com/brianvoe/gofakeit/v6" //aliasing package name
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRetrieveVoterWithRandomData(t *testing.T) {
+	db := NewMemoryDB()
+
+	expectedVoter := process.NewVoterDTO(
+		fake.IntRange(1, 10),
+		fake.Name(),
+		fake.Email(),
+	)
+
+	err := db.CreateVoter(expectedVoter)
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(expectedVoter)
+	assert.Equal(t, ErrVoterAlreadyExists.Error(), err)
+
+	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId(), true)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedVoter.GetId(), actualVoter.GetId())
+	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
+	assert.Equal(t, expectedVoter.GetEmail(), actualVoter.GetEmail())
+}
+
+func TestUpdateDeleteVoter(t *testing.T) {
+	db := NewMemoryDB()
+
+	expectedVoter := process.NewVoterDTO(
+		1,
+		fake.Name(),
+		fake.Email(),
+	)
+
+	err := db.UpdateVoterInfo(expectedVoter, process.AnyVersion)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	err = db.CreateVoter(expectedVoter)
+	assert.NoError(t, err)
+
+	expectedVoter = process.NewVoterDTO(
+		expectedVoter.GetId(),
+		fake.Name(),
+		fake.Email(),
+	)
+
+	err = db.UpdateVoterInfo(expectedVoter, process.AnyVersion)
+	assert.NoError(t, err)
+
+	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId(), true)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
+	assert.Equal(t, expectedVoter.GetEmail(), actualVoter.GetEmail())
+
+	err = db.DeleteSingleVoter(expectedVoter.GetId(), "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	actualVoter, err = db.GetSingleVoter(expectedVoter.GetId(), true)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+	assert.Equal(t, retrieve.VoterDTO{}, actualVoter)
+
+	err = db.DeleteSingleVoter(expectedVoter.GetId(), "", process.AnyVersion)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+}
+
+func TestVoterHistory(t *testing.T) {
+	db := NewMemoryDB()
+
+	err := db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	_, err = db.GetVoterHistory(1, false)
+	assert.Equal(t, ErrNoVoterHistory.Error(), err)
+
+	expectedPoll := process.NewVoterHistoryDTO(
+		fake.IntRange(1, 10),
+		fake.IntRange(11, 20),
+		fake.Date(),
+	)
+
+	err = db.CreateVoterHistory(1, expectedPoll.GetPollID(), expectedPoll)
+	assert.Equal(t, process.ErrUnknownPoll.Error(), err)
+
+	createPolls(t, db, expectedPoll.GetPollID())
+
+	err = db.CreateVoterHistory(1, expectedPoll.GetPollID(), expectedPoll)
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, expectedPoll.GetPollID(), expectedPoll)
+	assert.Equal(t, ErrHistoryAlreadyExists.Error(), err)
+
+	expectedPoll = process.NewVoterHistoryDTO(
+		expectedPoll.GetPollID(),
+		expectedPoll.GetVoteID(),
+		fake.Date(),
+	)
+
+	err = db.UpdateVoterHistoryInfo(1, expectedPoll.GetPollID(), expectedPoll, process.AnyVersion)
+	assert.NoError(t, err)
+
+	actualPoll, err := db.GetSingleEvent(1, expectedPoll.GetPollID())
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPoll.GetPollID(), actualPoll.GetPollID())
+	assert.Equal(t, expectedPoll.GetVoteDate(), actualPoll.GetVoteDate())
+
+	history, err := db.GetVoterHistory(1, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(history))
+
+	err = db.DeleteSingleVoterPoll(1, expectedPoll.GetPollID(), "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	_, err = db.GetSingleEvent(1, expectedPoll.GetPollID())
+	assert.Equal(t, ErrHistoryNotFound.Error(), err)
+
+	err = db.UpdateVoterHistoryInfo(1, expectedPoll.GetPollID(), expectedPoll, process.AnyVersion)
+	assert.Equal(t, ErrHistoryNotFound.Error(), err)
+
+	err = db.DeleteSingleVoterPoll(1, expectedPoll.GetPollID(), "", process.AnyVersion)
+	assert.Equal(t, ErrHistoryNotFound.Error(), err)
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	db := NewMemoryDB()
+	createPolls(t, db, 1)
+
+	err := db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoterPoll(1, 1, "entered in error", process.AnyVersion)
+	assert.NoError(t, err)
+
+	history, err := db.GetVoterHistory(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(history))
+	assert.Equal(t, "entered in error", history[0].GetDeleteReason())
+
+	err = db.DeleteSingleVoter(1, "moved away", process.AnyVersion)
+	assert.NoError(t, err)
+
+	voters, err := db.GetAllVoters(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(voters))
+
+	voters, err = db.GetAllVoters(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(voters))
+	assert.True(t, voters[0].IsDeleted())
+
+	err = db.RestoreVoterPoll(1, 1)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	err = db.RestoreVoter(1)
+	assert.NoError(t, err)
+
+	err = db.RestoreVoter(1)
+	assert.Equal(t, ErrVoterNotDeleted.Error(), err)
+
+	err = db.RestoreVoterPoll(1, 1)
+	assert.NoError(t, err)
+
+	_, err = db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+}
+
+func TestRevisionsAndRevert(t *testing.T) {
+	db := NewMemoryDB()
+	createPolls(t, db, 1)
+
+	err := db.CreateVoter(process.NewVoterDTO(1, "first", "first@abc.com"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "second", "second@abc.com"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	revisions, err := db.GetVoterRevisions(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, 2, len(revisions[2].GetChanges()))
+
+	err = db.RevertVoter(1, 1)
+	assert.NoError(t, err)
+
+	voter, err := db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", voter.GetName())
+	assert.Equal(t, 0, len(voter.GetHistory()))
+
+	history, err := db.GetVoterHistory(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "reverted to revision 1", history[0].GetDeleteReason())
+
+	err = db.RevertVoter(1, 2)
+	assert.NoError(t, err)
+
+	_, err = db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+
+	err = db.RevertVoter(1, 10)
+	assert.Equal(t, ErrRevisionNotFound.Error(), err)
+}
+
+func TestRevertedHistory(t *testing.T) {
+	db := NewMemoryDB()
+
+	createPolls(t, db, 1, 2)
+
+	err := db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	voteDate := time.Date(2024, time.November, 5, 12, 0, 0, 0, time.UTC)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, voteDate).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterHistoryInfo(1, 1, process.NewVoterHistoryDTO(1, 1, voteDate).WithChoice("no"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 1, voteDate))
+	assert.NoError(t, err)
+
+	//revision 2 has the first choice in poll 1, and nothing in poll 2, which
+	//the revert would delete rather than bring back
+	reverted, err := db.GetRevertedHistory(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []process.VoterHistoryDTO{process.NewVoterHistoryDTO(1, 1, voteDate).WithChoice("yes")}, reverted)
+
+	reverted, err = db.GetRevertedHistory(1, 4)
+	assert.NoError(t, err)
+	assert.Empty(t, reverted)
+
+	_, err = db.GetRevertedHistory(1, 9)
+	assert.Equal(t, ErrRevisionNotFound.Error(), err)
+
+	//a deleted vote is still returned so it can be checked before a restore
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	history, err := db.GetVoterPoll(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "no", history.GetChoice())
+	assert.True(t, voteDate.Equal(history.GetVoteDate()))
+
+	_, err = db.GetVoterPoll(1, 3)
+	assert.Equal(t, ErrHistoryNotFound.Error(), err)
+
+	_, err = db.GetVoterPoll(2, 1)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+}
+
+func TestVersionCheck(t *testing.T) {
+	db := NewMemoryDB()
+	createPolls(t, db, 1)
+
+	err := db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "first", fake.Email()), 1)
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "second", fake.Email()), 1)
+	assert.Equal(t, process.ErrVersionMismatch.Error(), err)
+
+	voter, err := db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", voter.GetName())
+	assert.Equal(t, 2, voter.GetVersion())
+
+	//history changes move the voter on as well
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	history, err := db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, history.GetVersion())
+
+	err = db.UpdateVoterHistoryInfo(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()), 2)
+	assert.Equal(t, process.ErrVersionMismatch.Error(), err)
+
+	err = db.DeleteSingleVoterPoll(1, 1, "", 1)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(1, "", 2)
+	assert.Equal(t, process.ErrVersionMismatch.Error(), err)
+
+	err = db.DeleteSingleVoter(1, "", 4)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(1, "", 4)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+}
+
+func TestGetVoterIncludeHistory(t *testing.T) {
+	db := NewMemoryDB()
+	var err error
+
+	createPolls(t, db, 1)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), "pat@example.com"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	voter, err := db.GetSingleVoter(1, false)
+	assert.NoError(t, err)
+	assert.Empty(t, voter.GetHistory())
+
+	voter, err = db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Len(t, voter.GetHistory(), 1)
+
+	voter, err = db.GetVoterByEmail("pat@example.com", false)
+	assert.NoError(t, err)
+	assert.Empty(t, voter.GetHistory())
+
+	voter, err = db.GetVoterByEmail("pat@example.com", true)
+	assert.NoError(t, err)
+	assert.Len(t, voter.GetHistory(), 1)
+}
+
+func TestUniqueEmail(t *testing.T) {
+	db := NewMemoryDB()
+
+	err := db.CreateVoter(process.NewVoterDTO(1, fake.Name(), "Pat@Example.com"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(2, fake.Name(), "pat@example.COM"))
+	assert.Equal(t, process.ErrEmailTaken.Error(), err)
+
+	err = db.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(2, fake.Name(), "pat@example.com"), process.AnyVersion)
+	assert.Equal(t, process.ErrEmailTaken.Error(), err)
+
+	voter, err := db.GetVoterByEmail("PAT@example.com", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, voter.GetId())
+
+	//deleted voters keep their address reserved
+	err = db.DeleteSingleVoter(1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	_, err = db.GetVoterByEmail("pat@example.com", true)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(2, fake.Name(), "pat@example.com"), process.AnyVersion)
+	assert.Equal(t, process.ErrEmailTaken.Error(), err)
+}
+
+func TestPolls(t *testing.T) {
+	db := NewMemoryDB()
+
+	opensAt := time.Date(2024, time.November, 5, 6, 0, 0, 0, time.UTC)
+
+	err := db.CreatePoll(process.NewPollDTO(2, "General", "", opensAt, opensAt.Add(14*time.Hour), process.PollDraft))
+	assert.NoError(t, err)
+
+	err = db.CreatePoll(process.NewPollDTO(2, "General", "", time.Time{}, time.Time{}, process.PollDraft))
+	assert.Equal(t, ErrPollAlreadyExists.Error(), err)
+
+	createPolls(t, db, 1)
+
+	err = db.UpdatePoll(process.NewPollDTO(2, "General election", "", opensAt, opensAt.Add(14*time.Hour), process.PollOpen))
+	assert.NoError(t, err)
+
+	err = db.UpdatePoll(process.NewPollDTO(3, "Primary", "", time.Time{}, time.Time{}, process.PollOpen))
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	poll, err := db.GetSinglePoll(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "General election", poll.GetTitle())
+	assert.Equal(t, process.PollOpen, poll.GetStatus())
+	assert.Equal(t, opensAt, poll.GetOpensAt())
+
+	polls, err := db.GetAllPolls()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(polls))
+	assert.Equal(t, 1, polls[0].GetId())
+
+	//a poll with voter history is kept
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 2, fake.Date()))
+	assert.NoError(t, err)
+
+	err = db.DeletePoll(2)
+	assert.Equal(t, process.ErrPollInUse.Error(), err)
+
+	err = db.DeletePoll(1)
+	assert.NoError(t, err)
+
+	_, err = db.GetSinglePoll(1)
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+}
+
+func TestUpdatePollWithVotes(t *testing.T) {
+	db := NewMemoryDB()
+	var err error
+
+	measure := process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"})
+
+	err = db.CreatePoll(measure)
+	assert.NoError(t, err)
+
+	err = db.CreatePoll(measure.WithSecret(true))
+	assert.Equal(t, ErrPollAlreadyExists.Error(), err)
+
+	//without votes the ballot can still change
+	err = db.UpdatePoll(measure.WithOptions([]string{"yes", "no", "abstain"}))
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("abstain"))
+	assert.NoError(t, err)
+
+	for _, changed := range []process.PollDTO{
+		measure,
+		measure.WithOptions([]string{"yes", "no", "abstain"}).WithRanked(true),
+		measure.WithOptions([]string{"yes", "no", "abstain"}).WithSecret(true),
+	} {
+		err = db.UpdatePoll(changed)
+		assert.Equal(t, process.ErrPollHasVotes.Error(), err)
+	}
+
+	//the rest of the poll can still change, and the options be reordered
+	err = db.UpdatePoll(process.NewPollDTO(1, "Measure 1A", "", time.Time{}, time.Time{}, process.PollClosed).WithOptions([]string{"abstain", "no", "yes"}))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Measure 1A", poll.GetTitle())
+	assert.Equal(t, []string{"abstain", "no", "yes"}, poll.GetOptions())
+	assert.False(t, poll.IsSecret())
+
+	//a deleted Poll event still counts as a vote
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.UpdatePoll(measure)
+	assert.Equal(t, process.ErrPollHasVotes.Error(), err)
+
+	//and so does a secret ballot
+	err = db.CreatePoll(process.NewPollDTO(2, "Measure 2", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}).WithSecret(true))
+	assert.NoError(t, err)
+
+	err = db.CastSecretBallot(1, 2, process.NewVoterHistoryDTO(2, 2, fake.Date()), process.NewBallotDTO(2, "QRSTUVWXYZ234567", "yes", nil))
+	assert.NoError(t, err)
+
+	err = db.UpdatePoll(process.NewPollDTO(2, "Measure 2", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}))
+	assert.Equal(t, process.ErrPollHasVotes.Error(), err)
+}
+
+func createPolls(t *testing.T, db *VoterDB, ids ...int) {
+	for _, id := range ids {
+		err := db.CreatePoll(process.NewPollDTO(id, fake.Sentence(3), "", time.Time{}, time.Time{}, process.PollOpen))
+		assert.NoError(t, err)
+	}
+}
+
+func TestPollWindow(t *testing.T) {
+	db := NewMemoryDB()
+	createPolls(t, db, 1)
+
+	opensAt := time.Date(2024, time.November, 5, 6, 0, 0, 0, time.UTC)
+
+	err := db.SetPollWindow(1, opensAt, opensAt.Add(14*time.Hour))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.True(t, opensAt.Equal(poll.GetOpensAt()))
+	assert.True(t, opensAt.Add(14*time.Hour).Equal(poll.GetClosesAt()))
+	assert.Equal(t, process.PollOpen, poll.GetStatus())
+
+	err = db.SetPollWindow(1, opensAt, time.Time{})
+	assert.NoError(t, err)
+
+	err = db.SetPollWindow(2, opensAt, time.Time{})
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	_, err = db.GetPoll(2)
+	assert.Equal(t, process.ErrUnknownPoll.Error(), err)
+}
+
+func TestPollVotes(t *testing.T) {
+	db := NewMemoryDB()
+
+	err := db.CreatePoll(process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"yes", "no"}, poll.GetOptions())
+
+	for id := 1; id <= 3; id++ {
+		err = db.CreateVoter(process.NewVoterDTO(id, fake.Name(), fake.Email()))
+		assert.NoError(t, err)
+	}
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterHistoryInfo(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("no"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	//a deleted voter is neither counted nor has a vote
+	err = db.CreateVoterHistory(3, 1, process.NewVoterHistoryDTO(1, 3, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(3, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	votes, err := db.GetPollVotes(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(votes))
+
+	//the votes are in voter order, the same as the json repository
+	choices := []string{}
+	for _, vote := range votes {
+		choices = append(choices, vote.GetChoice())
+	}
+	assert.Equal(t, []string{"yes", "no"}, choices)
+
+	count, err := db.CountVoters()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = db.GetPollVotes(2)
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	//a ranked-choice poll keeps the order of the ranking
+	err = db.CreatePoll(process.NewPollDTO(2, "Board", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"alice", "bob"}).WithRanked(true))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 1, fake.Date()).WithRanking([]string{"bob", "alice"}))
+	assert.NoError(t, err)
+
+	ranked, err := db.GetSinglePoll(2)
+	assert.NoError(t, err)
+	assert.True(t, ranked.IsRanked())
+
+	votes, err = db.GetPollVotes(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob", "alice"}, votes[0].GetRanking())
+}
+
+func TestSecretBallot(t *testing.T) {
+	db := NewMemoryDB()
+
+	err := db.CreatePoll(process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}).WithSecret(true))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.True(t, poll.IsSecret())
+
+	for id := 1; id <= 2; id++ {
+		err = db.CreateVoter(process.NewVoterDTO(id, fake.Name(), fake.Email()))
+		assert.NoError(t, err)
+	}
+
+	err = db.CastSecretBallot(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()), process.NewBallotDTO(1, "QRSTUVWXYZ234567", "yes", nil))
+	assert.NoError(t, err)
+
+	err = db.CastSecretBallot(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()), process.NewBallotDTO(1, "ABCDEFGHIJKLMNOP", "no", nil))
+	assert.NoError(t, err)
+
+	//a receipt is never handed out twice
+	err = db.CreateVoter(process.NewVoterDTO(3, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CastSecretBallot(3, 1, process.NewVoterHistoryDTO(1, 3, fake.Date()), process.NewBallotDTO(1, "ABCDEFGHIJKLMNOP", "yes", nil))
+	assert.Equal(t, ErrBallotAlreadyExists.Error(), err)
+
+	//the history records that the voter took part but not how
+	history, err := db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "", history.GetChoice())
+
+	_, err = db.GetSingleEvent(3, 1)
+	assert.Error(t, err)
+
+	ballots, err := db.GetPollBallots(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ballots))
+	assert.Equal(t, "ABCDEFGHIJKLMNOP", ballots[0].GetReceipt())
+	assert.Equal(t, "no", ballots[0].GetChoice())
+	assert.Equal(t, "yes", ballots[1].GetChoice())
+
+	ballot, err := db.GetBallot(1, "QRSTUVWXYZ234567")
+	assert.NoError(t, err)
+	assert.Equal(t, "yes", ballot.GetChoice())
+
+	_, err = db.GetBallot(1, "AAAAAAAAAAAAAAAA")
+	assert.Equal(t, process.ErrUnknownBallot.Error(), err)
+
+	_, err = db.GetPollBallots(2)
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	//the ballots keep the poll in use
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.DeletePoll(1)
+	assert.Equal(t, process.ErrPollInUse.Error(), err)
+}
+
+func TestAuditChain(t *testing.T) {
+	db := NewMemoryDB()
+
+	_, err := db.VerifyChain("")
+	assert.Equal(t, process.ErrChainDisabled.Error(), err)
+
+	err = db.CreatePoll(process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}))
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	//history that existed before the chain is the baseline
+	err = db.EnableChain()
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterHistoryInfo(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("no"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	report, err := db.VerifyChain("")
+	assert.NoError(t, err)
+	assert.True(t, report.IsValid())
+	assert.Equal(t, 4, report.GetEntries())
+
+	head := report.GetHead()
+
+	//an edit that did not go through the repository
+	history := db.store.Voters[2].VoterHistory[1]
+	history.Choice = "yes"
+	db.store.Voters[2].VoterHistory[1] = history
+
+	report, err = db.VerifyChain(head)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"voter 2 poll 1: choice changed outside the API"}, report.GetProblems())
+}
+
+func TestVoterProfile(t *testing.T) {
+	db := NewMemoryDB()
+
+	dateOfBirth := time.Date(1990, time.May, 1, 0, 0, 0, 0, time.UTC)
+	home := process.NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104")
+	mail := process.NewAddressDTO("PO Box 1", "Philadelphia", "PA", "19104-0001")
+
+	err := db.CreateVoter(process.NewVoterDTO(1, "Pat", "pat@abc.com").WithProfile(dateOfBirth, home, mail, "Philadelphia County"))
+	assert.NoError(t, err)
+
+	voter, err := db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, dateOfBirth, voter.GetDateOfBirth())
+	assert.Equal(t, retrieve.NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104"), voter.GetResidentialAddress())
+	assert.Equal(t, retrieve.NewAddressDTO("PO Box 1", "Philadelphia", "PA", "19104-0001"), voter.GetMailingAddress())
+	assert.Equal(t, "Philadelphia County", voter.GetJurisdiction())
+
+	//moving drops the mailing address
+	moved := process.NewAddressDTO("2 Oak Ave", "Pittsburgh", "PA", "15213")
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "Pat", "pat@abc.com").WithProfile(dateOfBirth, moved, process.AddressDTO{}, "Allegheny County"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	voter, err = db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, retrieve.NewAddressDTO("2 Oak Ave", "Pittsburgh", "PA", "15213"), voter.GetResidentialAddress())
+	assert.Equal(t, retrieve.AddressDTO{}, voter.GetMailingAddress())
+
+	revisions, err := db.GetVoterRevisions(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, 8, len(revisions[1].GetChanges()))
+
+	err = db.RevertVoter(1, 1)
+	assert.NoError(t, err)
+
+	voter, err = db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, retrieve.NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104"), voter.GetResidentialAddress())
+	assert.Equal(t, retrieve.NewAddressDTO("PO Box 1", "Philadelphia", "PA", "19104-0001"), voter.GetMailingAddress())
+	assert.Equal(t, "Philadelphia County", voter.GetJurisdiction())
+}
+
+func TestListVoters(t *testing.T) {
+	db := NewMemoryDB()
+	createPolls(t, db, 1)
+
+	var middle time.Time
+
+	for id, name := range []string{"", "carol", "Alice", "bob", "alan"} {
+		if id == 0 {
+			continue
+		}
+		if id == 3 {
+			middle = time.Now()
+		}
+
+		domain := "drexel.edu"
+		if id == 2 {
+			domain = "example.com"
+		}
+
+		err := db.CreateVoter(process.NewVoterDTO(id, name, name+"@"+domain))
+		assert.NoError(t, err)
+
+		//keeps the created times apart
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	for _, id := range []int{1, 2, 3} {
+		err := db.CreateVoterHistory(id, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+		assert.NoError(t, err)
+	}
+
+	err := db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(4, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	ids := func(query retrieve.VoterQuery) ([]int, int) {
+		if query.Sort == "" {
+			query.Sort = retrieve.SortId
+		}
+		if query.Page == 0 {
+			query.Page = 1
+		}
+		if query.PerPage == 0 {
+			query.PerPage = retrieve.DefaultPerPage
+		}
+
+		page, err := db.ListVoters(query)
+		assert.NoError(t, err)
+
+		var ids []int
+		for _, voter := range page.GetVoters() {
+			ids = append(ids, voter.GetId())
+		}
+		return ids, page.GetTotal()
+	}
+
+	list, total := ids(retrieve.VoterQuery{})
+	assert.Equal(t, []int{1, 2, 3}, list)
+	assert.Equal(t, 3, total)
+
+	list, total = ids(retrieve.VoterQuery{Sort: "name", IncludeDeleted: true})
+	assert.Equal(t, []int{4, 2, 3, 1}, list)
+	assert.Equal(t, 4, total)
+
+	list, total = ids(retrieve.VoterQuery{Sort: "-created", Page: 2, PerPage: 2})
+	assert.Equal(t, []int{1}, list)
+	assert.Equal(t, 3, total)
+
+	list, _ = ids(retrieve.VoterQuery{NamePrefix: "AL", IncludeDeleted: true})
+	assert.Equal(t, []int{2, 4}, list)
+
+	list, _ = ids(retrieve.VoterQuery{EmailDomain: "Drexel.edu"})
+	assert.Equal(t, []int{1, 3}, list)
+
+	list, _ = ids(retrieve.VoterQuery{CreatedAfter: middle})
+	assert.Equal(t, []int{3}, list)
+
+	list, _ = ids(retrieve.VoterQuery{CreatedBefore: middle})
+	assert.Equal(t, []int{1, 2}, list)
+
+	list, _ = ids(retrieve.VoterQuery{VotedInPoll: 1})
+	assert.Equal(t, []int{2, 3}, list)
+
+	//history is only loaded when asked for
+	page, err := db.ListVoters(retrieve.VoterQuery{Sort: retrieve.SortId, Page: 1, PerPage: 1, VotedInPoll: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(page.GetVoters()[0].GetHistory()))
+
+	page, err = db.ListVoters(retrieve.VoterQuery{Sort: retrieve.SortId, Page: 1, PerPage: 1, VotedInPoll: 1, IncludeHistory: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page.GetVoters()[0].GetHistory()))
+}
+
+func TestListVotersNonASCII(t *testing.T) {
+	db := NewMemoryDB()
+
+	for id, name := range map[int]string{1: "Émile", 2: "émilie", 3: "Eve", 4: "Ölaf"} {
+		err := db.CreateVoter(process.NewVoterDTO(id, name, fmt.Sprintf("v%d@Drexel.EDU", id)))
+		assert.NoError(t, err)
+	}
+
+	ids := func(query retrieve.VoterQuery) []int {
+		query.Page = 1
+		query.PerPage = retrieve.DefaultPerPage
+		if query.Sort == "" {
+			query.Sort = retrieve.SortId
+		}
+
+		page, err := db.ListVoters(query)
+		assert.NoError(t, err)
+
+		var ids []int
+		for _, voter := range page.GetVoters() {
+			ids = append(ids, voter.GetId())
+		}
+		return ids
+	}
+
+	//case is folded beyond ASCII, the same in every backend
+	assert.Equal(t, []int{1, 2}, ids(retrieve.VoterQuery{NamePrefix: "éMI"}))
+	assert.Equal(t, []int{4}, ids(retrieve.VoterQuery{NamePrefix: "ö"}))
+	assert.Equal(t, []int{1, 2, 3, 4}, ids(retrieve.VoterQuery{EmailDomain: "drexel.edu"}))
+	assert.Equal(t, []int{3, 1, 2, 4}, ids(retrieve.VoterQuery{Sort: retrieve.SortName}))
+}
+
+func TestSearchVoters(t *testing.T) {
+	db := NewMemoryDB()
+
+	for id, name := range []string{"", "Anna Kopp", "Ben Koepp", "José Núñez", "Carl Smith"} {
+		if id == 0 {
+			continue
+		}
+
+		err := db.CreateVoter(process.NewVoterDTO(id, name, fmt.Sprintf("voter%d@drexel.edu", id)))
+		assert.NoError(t, err)
+	}
+
+	search := func(text string, limit int) []string {
+		matches, err := db.SearchVoters(retrieve.SearchQuery{Text: text, Limit: limit})
+		assert.NoError(t, err)
+
+		var found []string
+		for _, match := range matches {
+			voter := match.GetVoter()
+			found = append(found, fmt.Sprintf("%d:%d", voter.GetId(), match.GetDistance()))
+		}
+		return found
+	}
+
+	assert.Equal(t, []string{"2:0", "1:1"}, search("Koepp", 10))
+	assert.Equal(t, []string{"2:0"}, search("Koepp", 1))
+	assert.Equal(t, []string{"3:0"}, search("jose nunez", 10))
+	assert.Empty(t, search("--", 10))
+
+	err := db.UpdateVoterInfo(process.NewVoterDTO(2, "Ben Smyth", "voter2@drexel.edu"), process.AnyVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1:1"}, search("Koepp", 10))
+	assert.Equal(t, []string{"4:0", "2:1"}, search("smith", 10))
+
+	err = db.DeleteSingleVoter(1, "", process.AnyVersion)
+	assert.NoError(t, err)
+	assert.Empty(t, search("Koepp", 10))
+
+	err = db.RestoreVoter(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1:1"}, search("Koepp", 10))
+
+	err = db.RevertVoter(2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2:0", "1:1"}, search("Koepp", 10))
+}
@@ -0,0 +1,341 @@
+package revision
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+)
+
+type Action string
+
+const (
+	// ActionBaseline records the state of a voter that existed before
+	// revisions were kept, so the first tracked change has something to be
+	// compared to.
+	ActionBaseline       Action = "baseline"
+	ActionCreate         Action = "create"
+	ActionUpdate         Action = "update"
+	ActionDelete         Action = "delete"
+	ActionRestore        Action = "restore"
+	ActionCreateHistory  Action = "create_history"
+	ActionUpdateHistory  Action = "update_history"
+	ActionDeleteHistory  Action = "delete_history"
+	ActionRestoreHistory Action = "restore_history"
+	ActionRevert         Action = "revert"
+)
+
+// HistorySnapshot is the state of a single voter history entry.
+type HistorySnapshot struct {
+	VoteId       int       `json:"vote_id"`
+	VoteDate     time.Time `json:"vote_date"`
+	Choice       string    `json:"choice,omitempty"`
+	Ranking      []string  `json:"ranking,omitempty"`
+	Deleted      bool      `json:"deleted,omitempty"`
+	DeleteReason string    `json:"delete_reason,omitempty"`
+}
+
+// Snapshot is the state of a voter and its history after a change. The
+// created and modified timestamps are left out as they are implied by the
+// revision itself.
+type Snapshot struct {
+	Name         string                  `json:"name"`
+	Email        string                  `json:"email"`
+	Deleted      bool                    `json:"deleted,omitempty"`
+	DeleteReason string                  `json:"delete_reason,omitempty"`
+	History      map[int]HistorySnapshot `json:"history,omitempty"`
+
+	DateOfBirth        *time.Time `json:"date_of_birth,omitempty"`
+	ResidentialAddress *Address   `json:"residential_address,omitempty"`
+	MailingAddress     *Address   `json:"mailing_address,omitempty"`
+	Jurisdiction       string     `json:"jurisdiction,omitempty"`
+}
+
+// Address is a postal address in a snapshot, nil when the voter has none.
+type Address struct {
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+}
+
+// Change is a single field that differs between two snapshots. History
+// fields are named history.<poll id>.<field>.
+type Change struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Revision is a numbered version of a voter, starting at 1.
+type Revision struct {
+	Number   int       `json:"revision"`
+	Created  time.Time `json:"created"`
+	Action   Action    `json:"action"`
+	Snapshot Snapshot  `json:"snapshot"`
+	Changes  []Change  `json:"changes"`
+}
+
+// Next returns the revisions to append after a change from before to after.
+// lastNumber is the number of the latest stored revision, 0 if there are
+// none. before is nil when the voter is being created. When a voter that
+// already existed has no revisions yet, a baseline revision of before is
+// returned ahead of the new one.
+func Next(lastNumber int, before *Snapshot, beforeTime time.Time, after Snapshot, action Action, now time.Time) []Revision {
+	var revisions []Revision
+
+	previous := Snapshot{}
+
+	if before != nil {
+		previous = *before
+
+		if lastNumber == 0 {
+			lastNumber++
+			revisions = append(revisions, Revision{
+				Number:   lastNumber,
+				Created:  beforeTime,
+				Action:   ActionBaseline,
+				Snapshot: previous,
+				Changes:  Diff(Snapshot{}, previous),
+			})
+		}
+	}
+
+	revisions = append(revisions, Revision{
+		Number:   lastNumber + 1,
+		Created:  now,
+		Action:   action,
+		Snapshot: after,
+		Changes:  Diff(previous, after),
+	})
+
+	return revisions
+}
+
+// Last returns the number of the latest revision, 0 if there are none.
+func Last(revisions []Revision) int {
+	if len(revisions) == 0 {
+		return 0
+	}
+
+	return revisions[len(revisions)-1].Number
+}
+
+// Find returns the revision with the given number.
+func Find(revisions []Revision, number int) (Revision, bool) {
+	for _, item := range revisions {
+		if item.Number == number {
+			return item, true
+		}
+	}
+
+	return Revision{}, false
+}
+
+// Diff lists the fields that differ between old and new, voter fields first
+// and then history ordered by poll id.
+func Diff(old Snapshot, new Snapshot) []Change {
+	changes := []Change{}
+
+	changes = appendChange(changes, "name", old.Name, new.Name)
+	changes = appendChange(changes, "email", old.Email, new.Email)
+	changes = appendChange(changes, "deleted", formatBool(old.Deleted), formatBool(new.Deleted))
+	changes = appendChange(changes, "delete_reason", old.DeleteReason, new.DeleteReason)
+	changes = appendChange(changes, "date_of_birth", formatDate(old.DateOfBirth), formatDate(new.DateOfBirth))
+	changes = appendAddressChanges(changes, "residential_address.", old.ResidentialAddress, new.ResidentialAddress)
+	changes = appendAddressChanges(changes, "mailing_address.", old.MailingAddress, new.MailingAddress)
+	changes = appendChange(changes, "jurisdiction", old.Jurisdiction, new.Jurisdiction)
+
+	for _, pollId := range pollIds(old.History, new.History) {
+		oldHistory, oldExists := old.History[pollId]
+		newHistory, newExists := new.History[pollId]
+
+		prefix := fmt.Sprintf("history.%d.", pollId)
+
+		oldValues := historyValues(oldHistory, oldExists)
+		newValues := historyValues(newHistory, newExists)
+
+		for _, field := range historyFields {
+			changes = appendChange(changes, prefix+field, oldValues[field], newValues[field])
+		}
+	}
+
+	return changes
+}
+
+// ToDTO converts a revision for the retrieve layer.
+func ToDTO(voterId int, r Revision) retrieve.RevisionDTO {
+	history := make(retrieve.HistoryMap)
+
+	for pollId, item := range r.Snapshot.History {
+		historyDTO := retrieve.NewVoterHistoryDTO(pollId, item.VoteId, item.VoteDate, time.Time{}, time.Time{}).WithChoice(item.Choice).WithRanking(item.Ranking)
+		if item.Deleted {
+			historyDTO = historyDTO.WithDeleted(r.Created, item.DeleteReason)
+		}
+		history[pollId] = historyDTO
+	}
+
+	voter := retrieve.NewVoterDTO(voterId, r.Snapshot.Name, r.Snapshot.Email, history, time.Time{}, r.Created)
+	if r.Snapshot.Deleted {
+		voter = voter.WithDeleted(r.Created, r.Snapshot.DeleteReason)
+	}
+
+	voter = voter.WithProfile(DateValue(r.Snapshot.DateOfBirth), r.Snapshot.ResidentialAddress.ToDTO(), r.Snapshot.MailingAddress.ToDTO(), r.Snapshot.Jurisdiction)
+
+	var changes []retrieve.ChangeDTO
+	for _, item := range r.Changes {
+		changes = append(changes, retrieve.NewChangeDTO(item.Field, item.Old, item.New))
+	}
+
+	return retrieve.NewRevisionDTO(r.Number, r.Created, string(r.Action), changes, voter)
+}
+
+// HistoryDTO converts a history entry for the process layer.
+func HistoryDTO(pollId int, history HistorySnapshot) process.VoterHistoryDTO {
+	return process.NewVoterHistoryDTO(pollId, history.VoteId, history.VoteDate).WithChoice(history.Choice).WithRanking(history.Ranking)
+}
+
+// Reverted returns the history that reverting current to target would bring
+// back or change, ordered by poll id. History the revert deletes, or leaves
+// as it is, is not returned.
+func Reverted(current Snapshot, target Snapshot) []process.VoterHistoryDTO {
+	var reverted []process.VoterHistoryDTO
+
+	for _, pollId := range pollIds(target.History, nil) {
+		history := target.History[pollId]
+		if history.Deleted {
+			continue
+		}
+
+		old, exists := current.History[pollId]
+		if exists && !old.Deleted && maps.Equal(historyValues(old, true), historyValues(history, true)) {
+			continue
+		}
+
+		reverted = append(reverted, HistoryDTO(pollId, history))
+	}
+
+	return reverted
+}
+
+var historyFields = []string{"vote_id", "vote_date", "choice", "ranking", "deleted", "delete_reason"}
+
+func historyValues(history HistorySnapshot, exists bool) map[string]string {
+	if !exists {
+		return map[string]string{}
+	}
+
+	return map[string]string{
+		"vote_id":       strconv.Itoa(history.VoteId),
+		"vote_date":     history.VoteDate.Format(time.RFC3339),
+		"choice":        history.Choice,
+		"ranking":       strings.Join(history.Ranking, ","),
+		"deleted":       formatBool(history.Deleted),
+		"delete_reason": history.DeleteReason,
+	}
+}
+
+func appendAddressChanges(changes []Change, prefix string, old *Address, new *Address) []Change {
+	if old == nil {
+		old = &Address{}
+	}
+	if new == nil {
+		new = &Address{}
+	}
+
+	changes = appendChange(changes, prefix+"street", old.Street, new.Street)
+	changes = appendChange(changes, prefix+"city", old.City, new.City)
+	changes = appendChange(changes, prefix+"state", old.State, new.State)
+	changes = appendChange(changes, prefix+"postal_code", old.PostalCode, new.PostalCode)
+
+	return changes
+}
+
+func appendChange(changes []Change, field string, old string, new string) []Change {
+	if old == new {
+		return changes
+	}
+
+	return append(changes, Change{Field: field, Old: old, New: new})
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(DateFormat)
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "true"
+	}
+	return ""
+}
+
+func pollIds(a map[int]HistorySnapshot, b map[int]HistorySnapshot) []int {
+	seen := make(map[int]bool)
+	var ids []int
+
+	for _, history := range []map[int]HistorySnapshot{a, b} {
+		for pollId := range history {
+			if !seen[pollId] {
+				seen[pollId] = true
+				ids = append(ids, pollId)
+			}
+		}
+	}
+
+	sort.Ints(ids)
+
+	return ids
+}
+
+// DateFormat is how a date of birth is shown in changes.
+const DateFormat = "2006-01-02"
+
+// DateOf returns nil for the zero time, the same as a voter with no date of
+// birth.
+func DateOf(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// DateValue is the opposite of DateOf.
+func DateValue(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+// AddressOf returns nil for an address that was not given.
+func AddressOf(address process.AddressDTO) *Address {
+	if address.IsZero() {
+		return nil
+	}
+
+	return &Address{
+		Street:     address.GetStreet(),
+		City:       address.GetCity(),
+		State:      address.GetState(),
+		PostalCode: address.GetPostalCode(),
+	}
+}
+
+// ToDTO converts the address for the retrieve layer, the zero AddressDTO if
+// it is nil.
+func (a *Address) ToDTO() retrieve.AddressDTO {
+	if a == nil {
+		return retrieve.AddressDTO{}
+	}
+
+	return retrieve.NewAddressDTO(a.Street, a.City, a.State, a.PostalCode)
+}
@@ -0,0 +1,61 @@
+package revision
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	voteDate := time.Date(2024, 2, 14, 0, 0, 0, 0, time.UTC)
+
+	old := Snapshot{
+		Name:  "a",
+		Email: "a@abc.com",
+		History: map[int]HistorySnapshot{
+			1: {VoteId: 1, VoteDate: voteDate},
+		},
+	}
+
+	new := Snapshot{
+		Name:  "b",
+		Email: "a@abc.com",
+		History: map[int]HistorySnapshot{
+			1: {VoteId: 1, VoteDate: voteDate, Deleted: true, DeleteReason: "typo"},
+			2: {VoteId: 3, VoteDate: voteDate},
+		},
+	}
+
+	assert.Equal(t, []Change{
+		{Field: "name", Old: "a", New: "b"},
+		{Field: "history.1.deleted", Old: "", New: "true"},
+		{Field: "history.1.delete_reason", Old: "", New: "typo"},
+		{Field: "history.2.vote_id", Old: "", New: "3"},
+		{Field: "history.2.vote_date", Old: "", New: "2024-02-14T00:00:00Z"},
+	}, Diff(old, new))
+
+	assert.Equal(t, []Change{}, Diff(new, new))
+}
+
+func TestNext(t *testing.T) {
+	now := time.Now()
+	before := Snapshot{Name: "a"}
+	after := Snapshot{Name: "b"}
+
+	created := Next(0, nil, time.Time{}, after, ActionCreate, now)
+	assert.Equal(t, 1, len(created))
+	assert.Equal(t, 1, created[0].Number)
+
+	//a voter without revisions gets a baseline first
+	updated := Next(0, &before, now.Add(-time.Hour), after, ActionUpdate, now)
+	assert.Equal(t, 2, len(updated))
+	assert.Equal(t, ActionBaseline, updated[0].Action)
+	assert.Equal(t, before, updated[0].Snapshot)
+	assert.Equal(t, 2, updated[1].Number)
+
+	updated = Next(5, &before, now, after, ActionUpdate, now)
+	assert.Equal(t, 1, len(updated))
+	assert.Equal(t, 6, updated[0].Number)
+	assert.Equal(t, []Change{{Field: "name", Old: "a", New: "b"}}, updated[0].Changes)
+}
@@ -0,0 +1,262 @@
+// Package search matches voter names the way clerks type them. Names are
+// split into tokens that ignore case and diacritics, each token is indexed
+// along with its Soundex code, and the voters sharing a key with the query are
+// ranked by edit distance.
+package search
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Candidate is a voter that shares at least one key with a query.
+type Candidate struct {
+	Id   int
+	Name string
+}
+
+// Match is a candidate with its distance from the query, 0 when every query
+// token is one of the name's tokens.
+type Match struct {
+	Id       int
+	Name     string
+	Distance int
+}
+
+// folds spells the letters with diacritics that Latin scripts commonly use
+// without them. Lower case is enough since names are lowered first.
+var folds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d", 'ð': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'ğ': "g", 'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'į': "i", 'ı': "i",
+	'ł': "l", 'ľ': "l", 'ñ': "n", 'ń': "n", 'ň': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o", 'œ': "oe",
+	'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss", 'ť': "t", 'ţ': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// soundexCodes are the Soundex digits of the consonants. Vowels separate
+// repeated codes while h and w do not, see Soundex.
+var soundexCodes = map[rune]byte{
+	'b': '1', 'f': '1', 'p': '1', 'v': '1',
+	'c': '2', 'g': '2', 'j': '2', 'k': '2', 'q': '2', 's': '2', 'x': '2', 'z': '2',
+	'd': '3', 't': '3',
+	'l': '4',
+	'm': '5', 'n': '5',
+	'r': '6',
+}
+
+// Fold lowers the text and spells out letters with diacritics without them,
+// so "Ñúñez" folds to "nunez".
+func Fold(text string) string {
+	var folded strings.Builder
+
+	for _, r := range strings.ToLower(text) {
+		if spelling, exists := folds[r]; exists {
+			folded.WriteString(spelling)
+		} else {
+			folded.WriteRune(r)
+		}
+	}
+
+	return folded.String()
+}
+
+// Tokens splits the folded text into its words, dropping punctuation, so
+// "O'Brien-Smith" is o, brien and smith.
+func Tokens(text string) []string {
+	return strings.FieldsFunc(Fold(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Soundex is the American Soundex code of a token, e.g. both "koepp" and
+// "kopp" are K100. Tokens that do not start with a letter a to z have no code.
+func Soundex(token string) string {
+	if token == "" || token[0] < 'a' || token[0] > 'z' {
+		return ""
+	}
+
+	code := []byte{token[0] - 'a' + 'A'}
+	last := soundexCodes[rune(token[0])]
+
+	for _, r := range token[1:] {
+		if len(code) == 4 {
+			break
+		}
+
+		digit, consonant := soundexCodes[r]
+		switch {
+		case r == 'h' || r == 'w':
+		case !consonant:
+			last = 0
+		case digit != last:
+			code = append(code, digit)
+			last = digit
+		}
+	}
+
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+
+	return string(code)
+}
+
+// Keys are what a name is indexed under: each of its tokens, and the Soundex
+// code of each, without duplicates. Tokens and codes never collide since codes
+// are upper case.
+func Keys(name string) []string {
+	var keys []string
+	seen := make(map[string]bool)
+
+	for _, token := range Tokens(name) {
+		for _, key := range []string{token, Soundex(token)} {
+			if key != "" && !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return keys
+}
+
+// Distance is the Levenshtein distance between a and b, counted in runes.
+func Distance(a string, b string) int {
+	source, target := []rune(a), []rune(b)
+
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(target); j++ {
+			substitution := previous[j-1]
+			if source[i-1] != target[j-1] {
+				substitution++
+			}
+
+			current[j] = min(previous[j]+1, current[j-1]+1, substitution)
+		}
+
+		previous, current = current, previous
+	}
+
+	return previous[len(target)]
+}
+
+// Rank orders the candidates by their distance from the text and returns at
+// most limit of them. A name's distance is the sum, over the tokens of the
+// text, of the distance to the closest token of the name. Ties are broken by
+// name and then by id.
+func Rank(text string, candidates []Candidate, limit int) []Match {
+	queryTokens := Tokens(text)
+
+	matches := make([]Match, 0, len(candidates))
+
+	for _, candidate := range candidates {
+		nameTokens := Tokens(candidate.Name)
+		if len(nameTokens) == 0 {
+			continue
+		}
+
+		distance := 0
+		for _, queryToken := range queryTokens {
+			closest := -1
+			for _, nameToken := range nameTokens {
+				if d := Distance(queryToken, nameToken); closest < 0 || d < closest {
+					closest = d
+				}
+			}
+			distance += closest
+		}
+
+		matches = append(matches, Match{Id: candidate.Id, Name: candidate.Name, Distance: distance})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+
+		if a.Distance != b.Distance {
+			return a.Distance < b.Distance
+		}
+		if compare := strings.Compare(Fold(a.Name), Fold(b.Name)); compare != 0 {
+			return compare < 0
+		}
+		return a.Id < b.Id
+	})
+
+	return matches[:min(limit, len(matches))]
+}
+
+// Index maps the keys of voter names to the voters, for the backends that
+// keep their voters in memory. It is not safe for concurrent use, the
+// repositories guard it with their own locks.
+type Index struct {
+	keys  map[string]map[int]bool
+	names map[int]string
+}
+
+func NewIndex() *Index {
+	return &Index{
+		keys:  make(map[string]map[int]bool),
+		names: make(map[int]string),
+	}
+}
+
+// Set indexes the voter under its name, replacing any name it had before.
+func (x *Index) Set(id int, name string) {
+	x.Remove(id)
+
+	x.names[id] = name
+
+	for _, key := range Keys(name) {
+		if x.keys[key] == nil {
+			x.keys[key] = make(map[int]bool)
+		}
+		x.keys[key][id] = true
+	}
+}
+
+// Remove takes the voter out of the index, if it is there.
+func (x *Index) Remove(id int) {
+	name, exists := x.names[id]
+	if !exists {
+		return
+	}
+
+	for _, key := range Keys(name) {
+		delete(x.keys[key], id)
+		if len(x.keys[key]) == 0 {
+			delete(x.keys, key)
+		}
+	}
+
+	delete(x.names, id)
+}
+
+// Candidates returns the voters sharing at least one key with the text.
+func (x *Index) Candidates(text string) []Candidate {
+	seen := make(map[int]bool)
+	var candidates []Candidate
+
+	for _, key := range Keys(text) {
+		for id := range x.keys[key] {
+			if !seen[id] {
+				seen[id] = true
+				candidates = append(candidates, Candidate{Id: id, Name: x.names[id]})
+			}
+		}
+	}
+
+	return candidates
+}
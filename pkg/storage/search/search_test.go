@@ -0,0 +1,65 @@
+package search
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokens(t *testing.T) {
+	assert.Equal(t, []string{"jose", "nunez"}, Tokens("  José Ñúñez "))
+	assert.Equal(t, []string{"o", "brien", "smith"}, Tokens("O'Brien-Smith"))
+	assert.Equal(t, []string{"strasse"}, Tokens("STRAßE"))
+	assert.Empty(t, Tokens("--"))
+}
+
+func TestSoundex(t *testing.T) {
+	tests := map[string]string{
+		"robert":   "R163",
+		"rupert":   "R163",
+		"ashcraft": "A261",
+		"tymczak":  "T522",
+		"pfister":  "P236",
+		"koepp":    "K100",
+		"kopp":     "K100",
+		"lee":      "L000",
+		"42":       "",
+	}
+
+	for token, code := range tests {
+		assert.Equal(t, code, Soundex(token), token)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, Distance("kopp", "kopp"))
+	assert.Equal(t, 1, Distance("koepp", "kopp"))
+	assert.Equal(t, 3, Distance("kitten", "sitting"))
+	assert.Equal(t, 5, Distance("", "nunez"))
+}
+
+func TestIndex(t *testing.T) {
+	index := NewIndex()
+	index.Set(1, "Anna Kopp")
+	index.Set(2, "Ben Koepp")
+	index.Set(3, "Carl Smith")
+	index.Set(4, "Kopp")
+
+	candidates := index.Candidates("koepp")
+	assert.ElementsMatch(t, []Candidate{{1, "Anna Kopp"}, {2, "Ben Koepp"}, {4, "Kopp"}}, candidates)
+
+	assert.Equal(t, []Match{
+		{Id: 2, Name: "Ben Koepp", Distance: 0},
+		{Id: 1, Name: "Anna Kopp", Distance: 1},
+		{Id: 4, Name: "Kopp", Distance: 1},
+	}, Rank("Köepp", candidates, 10))
+	assert.Equal(t, 1, len(Rank("koepp", candidates, 1)))
+
+	index.Set(2, "Ben Smyth")
+	index.Remove(4)
+	index.Remove(5)
+
+	assert.Equal(t, []Candidate{{1, "Anna Kopp"}}, index.Candidates("koepp"))
+	assert.ElementsMatch(t, []Candidate{{2, "Ben Smyth"}, {3, "Carl Smith"}}, index.Candidates("smith"))
+	assert.Empty(t, index.Candidates("!!"))
+}
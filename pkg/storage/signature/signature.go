@@ -0,0 +1,197 @@
+package signature
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"drexel.edu/voter-api/pkg/process"
+)
+
+const (
+	// Suffix is added to the name of a signed file to get the name of its
+	// detached signature.
+	Suffix = ".sig"
+
+	PrivateKeySuffix = ".key"
+	PublicKeySuffix  = ".pub"
+
+	privateKeyType = "PRIVATE KEY"
+	publicKeyType  = "PUBLIC KEY"
+)
+
+type SignatureError string
+
+const (
+	ErrKeyExists     SignatureError = "A key already exists at that path."
+	ErrNotAKey       SignatureError = "The file does not hold an Ed25519 key."
+	ErrBadSignature  SignatureError = "The signature does not match the file."
+	ErrNotASignature SignatureError = "The signature file does not hold an Ed25519 signature."
+)
+
+var signatureErrors = map[SignatureError]process.Error{
+	ErrKeyExists:     {Kind: process.KindConflict, Code: "key_exists"},
+	ErrNotAKey:       {Kind: process.KindInvalid, Code: "not_a_key"},
+	ErrBadSignature:  {Kind: process.KindUnprocessable, Code: "bad_signature"},
+	ErrNotASignature: {Kind: process.KindInvalid, Code: "not_a_signature"},
+}
+
+func (e SignatureError) Error() error {
+	return signatureErrors[e].WithMessage(string(e))
+}
+
+// GenerateKeys writes a new Ed25519 key pair to <path>.key and <path>.pub.
+// The private key is only readable by its owner. Existing keys are only
+// replaced if overwrite is set.
+func GenerateKeys(path string, overwrite bool) (ed25519.PublicKey, error) {
+	if !overwrite {
+		for _, name := range []string{path + PrivateKeySuffix, path + PublicKeySuffix} {
+			if _, err := os.Stat(name); err == nil {
+				return nil, fmt.Errorf("%w %s", ErrKeyExists.Error(), name)
+			}
+		}
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return nil, err
+	}
+
+	privatePEM := pem.EncodeToMemory(&pem.Block{Type: privateKeyType, Bytes: privateDER})
+	if err := os.WriteFile(path+PrivateKeySuffix, privatePEM, 0600); err != nil {
+		return nil, err
+	}
+
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: publicKeyType, Bytes: publicDER})
+	if err := os.WriteFile(path+PublicKeySuffix, publicPEM, 0644); err != nil {
+		return nil, err
+	}
+
+	return public, nil
+}
+
+// LoadPrivateKey reads a private key written by GenerateKeys.
+func LoadPrivateKey(fileName string) (ed25519.PrivateKey, error) {
+	der, err := readPEM(fileName, privateKeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, notAKey(fileName)
+	}
+
+	private, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, notAKey(fileName)
+	}
+
+	return private, nil
+}
+
+// LoadPublicKey reads a public key written by GenerateKeys.
+func LoadPublicKey(fileName string) (ed25519.PublicKey, error) {
+	der, err := readPEM(fileName, publicKeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, notAKey(fileName)
+	}
+
+	public, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, notAKey(fileName)
+	}
+
+	return public, nil
+}
+
+// Fingerprint identifies a public key in the same format as ssh-keygen -l.
+func Fingerprint(public ed25519.PublicKey) string {
+	sum := sha256.Sum256(public)
+	return "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:])
+}
+
+// SignFile writes a detached signature of fileName to fileName.sig and
+// returns the name of the signature file.
+func SignFile(fileName string, private ed25519.PrivateKey) (string, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return "", err
+	}
+
+	line := base64.StdEncoding.EncodeToString(ed25519.Sign(private, data)) + "\n"
+
+	if err := os.WriteFile(fileName+Suffix, []byte(line), 0644); err != nil {
+		return "", err
+	}
+
+	return fileName + Suffix, nil
+}
+
+// VerifyFile checks fileName against the detached signature in
+// signatureFileName, fileName.sig if it is blank.
+func VerifyFile(fileName string, signatureFileName string, public ed25519.PublicKey) error {
+	if signatureFileName == "" {
+		signatureFileName = fileName + Suffix
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+
+	encoded, err := os.ReadFile(signatureFileName)
+	if err != nil {
+		return err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("%w %s", ErrNotASignature.Error(), signatureFileName)
+	}
+
+	if !ed25519.Verify(public, data, sig) {
+		return fmt.Errorf("%w %s", ErrBadSignature.Error(), fileName)
+	}
+
+	return nil
+}
+
+func readPEM(fileName string, blockType string) ([]byte, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, notAKey(fileName)
+	}
+
+	return block.Bytes, nil
+}
+
+func notAKey(fileName string) error {
+	return fmt.Errorf("%w %s", ErrNotAKey.Error(), fileName)
+}
@@ -0,0 +1,75 @@
+package signature
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"drexel.edu/voter-api/pkg/process"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "voter-api")
+
+	public, err := GenerateKeys(keyPath, false)
+	assert.NoError(t, err)
+
+	//keys are not replaced by accident
+	_, err = GenerateKeys(keyPath, false)
+	assert.ErrorIs(t, err, ErrKeyExists.Error())
+
+	info, err := os.Stat(keyPath + PrivateKeySuffix)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	private, err := LoadPrivateKey(keyPath + PrivateKeySuffix)
+	assert.NoError(t, err)
+
+	loaded, err := LoadPublicKey(keyPath + PublicKeySuffix)
+	assert.NoError(t, err)
+	assert.Equal(t, public, loaded)
+	assert.True(t, strings.HasPrefix(Fingerprint(loaded), "SHA256:"))
+
+	_, err = LoadPublicKey(keyPath + PrivateKeySuffix)
+	assert.ErrorIs(t, err, ErrNotAKey.Error())
+
+	fileName := filepath.Join(dir, "voters.json")
+	err = os.WriteFile(fileName, []byte(`{"voters": []}`), 0644)
+	assert.NoError(t, err)
+
+	sigName, err := SignFile(fileName, private)
+	assert.NoError(t, err)
+	assert.Equal(t, fileName+Suffix, sigName)
+
+	err = VerifyFile(fileName, "", loaded)
+	assert.NoError(t, err)
+
+	//another key does not verify
+	other, err := GenerateKeys(filepath.Join(dir, "other"), false)
+	assert.NoError(t, err)
+
+	err = VerifyFile(fileName, sigName, other)
+	assert.True(t, strings.HasPrefix(err.Error(), string(ErrBadSignature)))
+
+	var signatureErr *process.Error
+	assert.True(t, errors.As(err, &signatureErr))
+	assert.Equal(t, process.KindUnprocessable, signatureErr.Kind)
+	assert.Equal(t, "bad_signature", signatureErr.Code)
+
+	//nor does a modified file
+	err = os.WriteFile(fileName, []byte(`{"voters": [1]}`), 0644)
+	assert.NoError(t, err)
+
+	err = VerifyFile(fileName, "", loaded)
+	assert.True(t, strings.HasPrefix(err.Error(), string(ErrBadSignature)))
+
+	err = os.WriteFile(sigName, []byte("not a signature"), 0644)
+	assert.NoError(t, err)
+
+	err = VerifyFile(fileName, "", loaded)
+	assert.ErrorIs(t, err, ErrNotASignature.Error())
+}
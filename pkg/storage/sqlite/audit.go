@@ -0,0 +1,175 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/chain"
+	"drexel.edu/voter-api/pkg/storage/revision"
+)
+
+// EnableChain starts keeping the audit chain in the audit_chain table.
+// History that already exists is chained as a baseline. Once the table has
+// entries the chain is kept every time the database is opened.
+func (v *VoterDB) EnableChain() error {
+
+	if v.chainEnabled {
+		return nil
+	}
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return ErrSaveFailed.Error()
+	}
+	defer tx.Rollback()
+
+	head, err := chainHead(tx)
+	if err != nil {
+		return ErrFailedToLoadDB.Error()
+	}
+
+	if head.Seq == 0 {
+		records, err := historyRecords(tx)
+		if err != nil {
+			return ErrGettingVoter.Error()
+		}
+
+		for _, entry := range chain.Baseline(head, records, time.Now()) {
+			if err := insertChainEntry(tx, entry); err != nil {
+				return ErrSaveFailed.Error()
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	v.chainEnabled = true
+
+	return nil
+}
+
+func (v *VoterDB) VerifyChain(head string) (retrieve.ChainReportDTO, error) {
+
+	if !v.chainEnabled {
+		return retrieve.ChainReportDTO{}, process.ErrChainDisabled.Error()
+	}
+
+	// both are read in one transaction so a change made in between cannot
+	// show up as a problem
+	tx, err := v.db.Begin()
+	if err != nil {
+		return retrieve.ChainReportDTO{}, ErrFailedToLoadDB.Error()
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT seq, entry FROM audit_chain ORDER BY seq`)
+	if err != nil {
+		return retrieve.ChainReportDTO{}, ErrFailedToLoadDB.Error()
+	}
+	defer rows.Close()
+
+	var entries []chain.Entry
+	problems := []string{}
+
+	for rows.Next() {
+		var seq int
+		var data string
+
+		if err := rows.Scan(&seq, &data); err != nil {
+			return retrieve.ChainReportDTO{}, ErrFailedToLoadDB.Error()
+		}
+
+		var entry chain.Entry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			problems = append(problems, fmt.Sprintf("audit_chain row %d is not a chain entry", seq))
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return retrieve.ChainReportDTO{}, ErrFailedToLoadDB.Error()
+	}
+
+	records, err := historyRecords(tx)
+	if err != nil {
+		return retrieve.ChainReportDTO{}, ErrGettingVoter.Error()
+	}
+
+	report := chain.Verify(entries, records, head)
+
+	return retrieve.NewChainReportDTO(report.Entries, report.Head, append(problems, report.Problems...)), nil
+}
+
+// appendChain stores the entries for history changed from before to after
+// in the same transaction as the change.
+func appendChain(tx *sql.Tx, voterId int, before map[int]revision.HistorySnapshot, after map[int]revision.HistorySnapshot, action revision.Action) error {
+	head, err := chainHead(tx)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range chain.Next(head, voterId, before, after, action, time.Now()) {
+		if err := insertChainEntry(tx, entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// chainHead returns the last entry in the chain, the zero Entry if there
+// are none.
+func chainHead(q querier) (chain.Entry, error) {
+	var data string
+
+	err := q.QueryRow(`SELECT entry FROM audit_chain ORDER BY seq DESC LIMIT 1`).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return chain.Entry{}, nil
+	}
+	if err != nil {
+		return chain.Entry{}, err
+	}
+
+	var head chain.Entry
+	if err := json.Unmarshal([]byte(data), &head); err != nil {
+		return chain.Entry{}, err
+	}
+
+	return head, nil
+}
+
+func insertChainEntry(tx *sql.Tx, entry chain.Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO audit_chain (seq, entry) VALUES (?, ?)`, entry.Seq, string(data))
+
+	return err
+}
+
+// historyRecords returns every voter's history, deleted or not.
+func historyRecords(q querier) (map[chain.Key]revision.HistorySnapshot, error) {
+	history, err := queryHistory(q, `SELECT `+historyColumns+` FROM voter_history`)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[chain.Key]revision.HistorySnapshot)
+
+	for _, item := range history {
+		records[chain.Key{VoterId: item.voterId, PollId: item.pollId}] = item.toSnapshot()
+	}
+
+	return records, nil
+}
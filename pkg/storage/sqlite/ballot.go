@@ -0,0 +1,108 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/revision"
+	"github.com/mattn/go-sqlite3"
+)
+
+// CastSecretBallot records the history and stores the ballot in the same
+// transaction, so neither is kept without the other. The ballot row carries
+// no voter id or timestamps.
+func (v *VoterDB) CastSecretBallot(voterId int, pollId int, history process.VoterHistoryDTO, ballot process.BallotDTO) error {
+
+	return v.withRevision(voterId, revision.ActionCreateHistory, func(tx *sql.Tx) error {
+		if err := insertHistory(tx, voterId, pollId, history); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(
+			`INSERT INTO ballots (poll_id, receipt, choice, ranking) VALUES (?, ?, ?, ?)`,
+			pollId,
+			ballot.GetReceipt(),
+			ballot.GetChoice(),
+			formatList(ballot.GetRanking()),
+		)
+		if isConstraintError(err, sqlite3.ErrConstraintPrimaryKey) {
+			return ErrBallotAlreadyExists.Error()
+		}
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		return nil
+	})
+}
+
+func (v *VoterDB) GetPollBallots(pollId int) ([]retrieve.BallotDTO, error) {
+
+	exists, err := pollExists(v.db, pollId)
+	if err != nil {
+		return nil, ErrGettingPoll.Error()
+	}
+
+	if !exists {
+		return nil, ErrPollNotFound.Error()
+	}
+
+	rows, err := v.db.Query(`SELECT poll_id, receipt, choice, ranking FROM ballots WHERE poll_id = ? ORDER BY receipt`, pollId)
+	if err != nil {
+		return nil, ErrGettingPoll.Error()
+	}
+	defer rows.Close()
+
+	var ballots []retrieve.BallotDTO
+
+	for rows.Next() {
+		ballot, err := scanBallot(rows)
+		if err != nil {
+			return nil, ErrGettingPoll.Error()
+		}
+		ballots = append(ballots, ballot)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, ErrGettingPoll.Error()
+	}
+
+	return ballots, nil
+}
+
+func (v *VoterDB) GetBallot(pollId int, receipt string) (retrieve.BallotDTO, error) {
+
+	row := v.db.QueryRow(`SELECT poll_id, receipt, choice, ranking FROM ballots WHERE poll_id = ? AND receipt = ?`, pollId, receipt)
+
+	ballot, err := scanBallot(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return retrieve.BallotDTO{}, process.ErrUnknownBallot.Error()
+	}
+	if err != nil {
+		return retrieve.BallotDTO{}, ErrGettingPoll.Error()
+	}
+
+	return ballot, nil
+}
+
+func scanBallot(s scanner) (retrieve.BallotDTO, error) {
+	var (
+		pollId  int
+		receipt string
+		choice  string
+		ranking string
+	)
+
+	if err := s.Scan(&pollId, &receipt, &choice, &ranking); err != nil {
+		return retrieve.BallotDTO{}, err
+	}
+
+	list, err := parseList(ranking)
+	if err != nil {
+		return retrieve.BallotDTO{}, err
+	}
+
+	return retrieve.NewBallotDTO(pollId, receipt, choice, list), nil
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import "drexel.edu/voter-api/pkg/process"
+
+type RepositoryError string
+
+const (
+	ErrFailedToLoadDB       RepositoryError = "Failed to load the database."
+	ErrGettingVoter         RepositoryError = "Unhandled Exception Occured While attempting to retrieve a Voter."
+	ErrVoterAlreadyExists   RepositoryError = "Attempted to create a voter but the id already exists."
+	ErrVoterNotFound        RepositoryError = "The Voter Id was not found."
+	ErrSaveFailed           RepositoryError = "Error saving to the database."
+	ErrHistoryNotFound      RepositoryError = "The History Id for the Voter was not found"
+	ErrHistoryAlreadyExists RepositoryError = "Attempted to create new history for the voter but the poll Id already exists"
+	ErrNoVoterHistory       RepositoryError = "No history was found for the voter Id"
+	ErrVoterNotDeleted      RepositoryError = "The Voter Id has not been deleted."
+	ErrHistoryNotDeleted    RepositoryError = "The History Id for the Voter has not been deleted."
+	ErrRevisionNotFound     RepositoryError = "The revision was not found for the Voter Id."
+	ErrPollAlreadyExists    RepositoryError = "Attempted to create a poll but the id already exists."
+	ErrPollNotFound         RepositoryError = "The Poll Id was not found."
+	ErrBallotAlreadyExists  RepositoryError = "Attempted to cast a ballot but the receipt already exists."
+	ErrGettingPoll          RepositoryError = "Unhandled Exception Occured While attempting to retrieve a Poll."
+	ErrDuplicateEmails      RepositoryError = "Some voters share an email address, which must be changed before the database can be opened."
+)
+
+var repositoryErrors = map[RepositoryError]process.Error{
+	ErrFailedToLoadDB:       {Kind: process.KindInternal, Code: "load_failed"},
+	ErrGettingVoter:         {Kind: process.KindInternal, Code: "get_voter_failed"},
+	ErrVoterAlreadyExists:   {Kind: process.KindConflict, Code: "voter_exists"},
+	ErrVoterNotFound:        {Kind: process.KindNotFound, Code: "voter_not_found"},
+	ErrSaveFailed:           {Kind: process.KindInternal, Code: "save_failed"},
+	ErrHistoryNotFound:      {Kind: process.KindNotFound, Code: "history_not_found"},
+	ErrHistoryAlreadyExists: {Kind: process.KindConflict, Code: "history_exists"},
+	ErrNoVoterHistory:       {Kind: process.KindNotFound, Code: "no_voter_history"},
+	ErrVoterNotDeleted:      {Kind: process.KindConflict, Code: "voter_not_deleted"},
+	ErrHistoryNotDeleted:    {Kind: process.KindConflict, Code: "history_not_deleted"},
+	ErrRevisionNotFound:     {Kind: process.KindNotFound, Code: "revision_not_found"},
+	ErrPollAlreadyExists:    {Kind: process.KindConflict, Code: "poll_exists"},
+	ErrPollNotFound:         {Kind: process.KindNotFound, Code: "poll_not_found"},
+	ErrBallotAlreadyExists:  {Kind: process.KindConflict, Code: "ballot_exists"},
+	ErrGettingPoll:          {Kind: process.KindInternal, Code: "get_poll_failed"},
+	ErrDuplicateEmails:      {Kind: process.KindInternal, Code: "duplicate_emails"},
+}
+
+func (e RepositoryError) Error() error {
+	return repositoryErrors[e].WithMessage(string(e))
+}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"strings"
+
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/listing"
+)
+
+// voterSortColumns are the ORDER BY terms for each sort field. Times are
+// compared with julianday as they are not always stored in the same zone.
+var voterSortColumns = map[string]string{
+	retrieve.SortId:       `id`,
+	retrieve.SortName:     `name_key`,
+	retrieve.SortCreated:  `julianday(created)`,
+	retrieve.SortModified: `julianday(modified)`,
+}
+
+// ListVoters counts the voters matching the query, then only loads the
+// voters on the page, and their history if the query asks for it.
+func (v *VoterDB) ListVoters(query retrieve.VoterQuery) (retrieve.VoterPageDTO, error) {
+
+	where, args := voterFilter(query)
+
+	var total int
+	if err := v.db.QueryRow(`SELECT COUNT(*) FROM voters WHERE `+where, args...).Scan(&total); err != nil {
+		return retrieve.VoterPageDTO{}, ErrGettingVoter.Error()
+	}
+
+	field, descending := query.SortField()
+
+	order := voterSortColumns[field]
+	if descending {
+		order += ` DESC`
+	}
+
+	rows, err := v.db.Query(
+		`SELECT `+voterColumns+` FROM voters WHERE `+where+` ORDER BY `+order+`, id LIMIT ? OFFSET ?`,
+		append(args, query.PerPage, query.Offset())...,
+	)
+	if err != nil {
+		return retrieve.VoterPageDTO{}, ErrGettingVoter.Error()
+	}
+	defer rows.Close()
+
+	var voters []voterRow
+	var ids []any
+
+	for rows.Next() {
+		voter, err := scanVoter(rows)
+		if err != nil {
+			return retrieve.VoterPageDTO{}, ErrGettingVoter.Error()
+		}
+		voters = append(voters, voter)
+		ids = append(ids, voter.id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return retrieve.VoterPageDTO{}, ErrGettingVoter.Error()
+	}
+
+	historyByVoter := make(map[int]retrieve.HistoryMap)
+	for _, voter := range voters {
+		historyByVoter[voter.id] = make(retrieve.HistoryMap)
+	}
+
+	if len(voters) > 0 && query.IncludeHistory {
+		if err := loadPageHistory(v.db, historyByVoter, ids, query.IncludeDeleted); err != nil {
+			return retrieve.VoterPageDTO{}, ErrGettingVoter.Error()
+		}
+	}
+
+	votersList := make([]retrieve.VoterDTO, 0, len(voters))
+	for _, voter := range voters {
+		votersList = append(votersList, voter.toDTO(historyByVoter[voter.id]))
+	}
+
+	return retrieve.NewVoterPageDTO(votersList, total), nil
+}
+
+// loadPageHistory fills in the history of the voters on a page.
+func loadPageHistory(q querier, historyByVoter map[int]retrieve.HistoryMap, ids []any, includeDeleted bool) error {
+
+	historyQuery := `SELECT ` + historyColumns + ` FROM voter_history WHERE voter_id IN (` + placeholders(len(ids)) + `)`
+	if !includeDeleted {
+		historyQuery += ` AND deleted IS NULL`
+	}
+
+	history, err := queryHistory(q, historyQuery, ids...)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range history {
+		historyByVoter[item.voterId][item.pollId] = item.toDTO()
+	}
+
+	return nil
+}
+
+// voterFilter builds the WHERE clause for the query's filters.
+func voterFilter(query retrieve.VoterQuery) (string, []any) {
+	conditions := []string{`1 = 1`}
+	var args []any
+
+	if !query.IncludeDeleted {
+		conditions = append(conditions, `deleted IS NULL`)
+	}
+
+	// the keys are folded the same way as by listing.Matches
+	if query.NamePrefix != "" {
+		conditions = append(conditions, `name_key LIKE ? ESCAPE '\'`)
+		args = append(args, escapeLike(listing.NameKey(query.NamePrefix))+"%")
+	}
+
+	if query.EmailDomain != "" {
+		conditions = append(conditions, `email_domain = ?`)
+		args = append(args, strings.ToLower(query.EmailDomain))
+	}
+
+	if !query.CreatedAfter.IsZero() {
+		conditions = append(conditions, `julianday(created) >= julianday(?)`)
+		args = append(args, formatTime(query.CreatedAfter))
+	}
+
+	if !query.CreatedBefore.IsZero() {
+		conditions = append(conditions, `julianday(created) < julianday(?)`)
+		args = append(args, formatTime(query.CreatedBefore))
+	}
+
+	if !query.ModifiedAfter.IsZero() {
+		conditions = append(conditions, `julianday(modified) >= julianday(?)`)
+		args = append(args, formatTime(query.ModifiedAfter))
+	}
+
+	if !query.ModifiedBefore.IsZero() {
+		conditions = append(conditions, `julianday(modified) < julianday(?)`)
+		args = append(args, formatTime(query.ModifiedBefore))
+	}
+
+	if query.VotedInPoll != 0 {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM voter_history h WHERE h.voter_id = voters.id AND h.poll_id = ? AND h.deleted IS NULL)`)
+		args = append(args, query.VotedInPoll)
+	}
+
+	return strings.Join(conditions, ` AND `), args
+}
+
+// escapeLike makes the wildcards in s match themselves in a LIKE pattern
+// with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat(`?, `, n), `, `)
+}
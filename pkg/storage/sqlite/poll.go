@@ -0,0 +1,263 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"github.com/mattn/go-sqlite3"
+)
+
+const pollColumns = `id, title, description, opens_at, closes_at, status, options, ranked, secret, created, modified`
+
+func (v *VoterDB) CreatePoll(poll process.PollDTO) error {
+
+	currentTime := formatTime(time.Now())
+
+	_, err := v.db.Exec(
+		`INSERT INTO polls (`+pollColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		poll.GetId(),
+		poll.GetTitle(),
+		poll.GetDescription(),
+		formatNullTime(poll.GetOpensAt()),
+		formatNullTime(poll.GetClosesAt()),
+		poll.GetStatus(),
+		formatList(poll.GetOptions()),
+		poll.IsRanked(),
+		poll.IsSecret(),
+		currentTime,
+		currentTime,
+	)
+	if isConstraintError(err, sqlite3.ErrConstraintPrimaryKey) {
+		return ErrPollAlreadyExists.Error()
+	}
+	if err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	return nil
+}
+
+func (v *VoterDB) UpdatePoll(poll process.PollDTO) error {
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return ErrSaveFailed.Error()
+	}
+	defer tx.Rollback()
+
+	previous, err := scanPoll(tx.QueryRow(`SELECT `+pollColumns+` FROM polls WHERE id = ?`, poll.GetId()))
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPollNotFound.Error()
+	}
+	if err != nil {
+		return ErrGettingPoll.Error()
+	}
+
+	// votes already cast were checked against the old options
+	if !poll.SameBallot(previous.options, previous.ranked, previous.secret) {
+		inUse, err := pollInUse(tx, poll.GetId())
+		if err != nil {
+			return ErrGettingPoll.Error()
+		}
+
+		if inUse {
+			return process.ErrPollHasVotes.Error()
+		}
+	}
+
+	result, err := tx.Exec(
+		`UPDATE polls SET title = ?, description = ?, opens_at = ?, closes_at = ?, status = ?, options = ?, ranked = ?, secret = ?, modified = ? WHERE id = ?`,
+		poll.GetTitle(),
+		poll.GetDescription(),
+		formatNullTime(poll.GetOpensAt()),
+		formatNullTime(poll.GetClosesAt()),
+		poll.GetStatus(),
+		formatList(poll.GetOptions()),
+		poll.IsRanked(),
+		poll.IsSecret(),
+		formatTime(time.Now()),
+		poll.GetId(),
+	)
+	if err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	if err := requireRow(result, ErrPollNotFound); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	return nil
+}
+
+// DeletePoll removes the poll for good. Polls that any voter history or
+// secret ballot refers to, deleted or not, are kept.
+func (v *VoterDB) DeletePoll(id int) error {
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return ErrSaveFailed.Error()
+	}
+	defer tx.Rollback()
+
+	inUse, err := pollInUse(tx, id)
+	if err != nil {
+		return ErrGettingPoll.Error()
+	}
+
+	exists, err := pollExists(tx, id)
+	if err != nil {
+		return ErrGettingPoll.Error()
+	}
+
+	if !exists {
+		return ErrPollNotFound.Error()
+	}
+
+	if inUse {
+		return process.ErrPollInUse.Error()
+	}
+
+	if _, err := tx.Exec(`DELETE FROM polls WHERE id = ?`, id); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	return nil
+}
+
+func (v *VoterDB) SetPollWindow(id int, opensAt time.Time, closesAt time.Time) error {
+
+	result, err := v.db.Exec(
+		`UPDATE polls SET opens_at = ?, closes_at = ?, modified = ? WHERE id = ?`,
+		formatNullTime(opensAt),
+		formatNullTime(closesAt),
+		formatTime(time.Now()),
+		id,
+	)
+	if err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	return requireRow(result, ErrPollNotFound)
+}
+
+// GetPoll is used by the process service to check the poll window.
+func (v *VoterDB) GetPoll(id int) (process.PollDTO, error) {
+
+	poll, err := scanPoll(v.db.QueryRow(`SELECT `+pollColumns+` FROM polls WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return process.PollDTO{}, process.ErrUnknownPoll.Error()
+	}
+	if err != nil {
+		return process.PollDTO{}, ErrGettingPoll.Error()
+	}
+
+	return process.NewPollDTO(poll.id, poll.title, poll.description, poll.opensAt, poll.closesAt, poll.status).WithOptions(poll.options).WithRanked(poll.ranked).WithSecret(poll.secret), nil
+}
+
+func (v *VoterDB) GetAllPolls() ([]retrieve.PollDTO, error) {
+
+	rows, err := v.db.Query(`SELECT ` + pollColumns + ` FROM polls ORDER BY id`)
+	if err != nil {
+		return nil, ErrGettingPoll.Error()
+	}
+	defer rows.Close()
+
+	polls := []retrieve.PollDTO{}
+
+	for rows.Next() {
+		poll, err := scanPoll(rows)
+		if err != nil {
+			return nil, ErrGettingPoll.Error()
+		}
+		polls = append(polls, poll.toDTO())
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, ErrGettingPoll.Error()
+	}
+
+	return polls, nil
+}
+
+func (v *VoterDB) GetSinglePoll(id int) (retrieve.PollDTO, error) {
+
+	poll, err := scanPoll(v.db.QueryRow(`SELECT `+pollColumns+` FROM polls WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return retrieve.PollDTO{}, ErrPollNotFound.Error()
+	}
+	if err != nil {
+		return retrieve.PollDTO{}, ErrGettingPoll.Error()
+	}
+
+	return poll.toDTO(), nil
+}
+
+// GetPollVotes returns the history recorded for the poll by voters that have
+// not been deleted, leaving out deleted history.
+func (v *VoterDB) GetPollVotes(pollId int) ([]retrieve.VoterHistoryDTO, error) {
+
+	exists, err := pollExists(v.db, pollId)
+	if err != nil {
+		return nil, ErrGettingPoll.Error()
+	}
+
+	if !exists {
+		return nil, ErrPollNotFound.Error()
+	}
+
+	history, err := queryHistory(v.db, `SELECT `+historyColumns+` FROM voter_history
+		WHERE poll_id = ? AND deleted IS NULL
+		AND voter_id IN (SELECT id FROM voters WHERE deleted IS NULL)
+		ORDER BY voter_id`, pollId)
+	if err != nil {
+		return nil, ErrGettingPoll.Error()
+	}
+
+	var votes []retrieve.VoterHistoryDTO
+
+	for _, item := range history {
+		votes = append(votes, item.toDTO())
+	}
+
+	return votes, nil
+}
+
+func (v *VoterDB) CountVoters() (int, error) {
+
+	var count int
+
+	if err := v.db.QueryRow(`SELECT COUNT(*) FROM voters WHERE deleted IS NULL`).Scan(&count); err != nil {
+		return 0, ErrGettingVoter.Error()
+	}
+
+	return count, nil
+}
+
+// pollInUse reports whether any voter history or secret ballot refers to the
+// poll.
+func pollInUse(q querier, id int) (bool, error) {
+	var inUse bool
+
+	err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM voter_history WHERE poll_id = ?) OR EXISTS (SELECT 1 FROM ballots WHERE poll_id = ?)`, id, id).Scan(&inUse)
+
+	return inUse, err
+}
+
+func pollExists(q querier, id int) (bool, error) {
+	var exists bool
+
+	err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM polls WHERE id = ?)`, id).Scan(&exists)
+
+	return exists, err
+}
@@ -0,0 +1,601 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/revision"
+	"github.com/mattn/go-sqlite3"
+)
+
+const (
+	voterColumns   = `id, name, email, created, modified, deleted, delete_reason, version, ` + profileColumns
+	profileColumns = `date_of_birth, residential_street, residential_city, residential_state, residential_postal_code,
+		mailing_street, mailing_city, mailing_state, mailing_postal_code, jurisdiction`
+	profileUpdate = `date_of_birth = ?, residential_street = ?, residential_city = ?, residential_state = ?, residential_postal_code = ?,
+		mailing_street = ?, mailing_city = ?, mailing_state = ?, mailing_postal_code = ?, jurisdiction = ?`
+	keyColumns     = `email_key, name_key, email_domain`
+	keyUpdate      = `email_key = ?, name_key = ?, email_domain = ?`
+	historyColumns = `voter_id, poll_id, vote_id, vote_date, choice, ranking, created, modified, deleted, delete_reason, version`
+
+	activeVoterVersion   = `SELECT version FROM voters WHERE id = ? AND deleted IS NULL`
+	activeHistoryVersion = `SELECT h.version FROM voter_history h JOIN voters v ON v.id = h.voter_id
+		WHERE h.voter_id = ? AND h.poll_id = ? AND h.deleted IS NULL AND v.deleted IS NULL`
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// VoterDB stores voters and their history in an embedded SQLite database.
+// Voters and history live in separate tables linked by a foreign key, and the
+// primary keys enforce the same uniqueness rules as the json repository.
+// Deleted rows are kept with a deleted timestamp so they can be restored.
+type VoterDB struct {
+	db *sql.DB
+
+	// chainEnabled is set once the audit chain has been started, see
+	// EnableChain
+	chainEnabled bool
+}
+
+func NewSqliteDB(dbFile string) (*VoterDB, error) {
+
+	db, err := sql.Open("sqlite3", "file:"+dbFile+"?_foreign_keys=on&_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite only allows a single writer, serialising access here avoids
+	// "database is locked" errors under concurrent requests
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+
+		// the voters sharing an address are listed so they can be fixed
+		if errors.Is(err, ErrDuplicateEmails.Error()) {
+			return nil, err
+		}
+
+		return nil, ErrFailedToLoadDB.Error()
+	}
+
+	if err := indexMissingNames(db); err != nil {
+		db.Close()
+		return nil, ErrFailedToLoadDB.Error()
+	}
+
+	// once started the audit chain is kept whether or not it was asked for,
+	// so no change to the history is missed
+	var chainStarted bool
+	if err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM audit_chain)`).Scan(&chainStarted); err != nil {
+		db.Close()
+		return nil, ErrFailedToLoadDB.Error()
+	}
+
+	return &VoterDB{db: db, chainEnabled: chainStarted}, nil
+}
+
+func (v *VoterDB) Close() error {
+	return v.db.Close()
+}
+
+func (v *VoterDB) CreateVoter(voter process.VoterDTO) error {
+
+	currentTime := formatTime(time.Now())
+
+	return v.withRevision(voter.GetId(), revision.ActionCreate, func(tx *sql.Tx) error {
+		// a deleted voter still holds its id until it is restored
+		args := append([]any{voter.GetId(), voter.GetName(), voter.GetEmail(), currentTime, currentTime}, keyValues(voter.GetName(), voter.GetEmail())...)
+
+		_, err := tx.Exec(
+			`INSERT INTO voters (id, name, email, created, modified, `+keyColumns+`, `+profileColumns+`) VALUES (`+placeholders(18)+`)`,
+			append(args, voterProfileValues(voter)...)...,
+		)
+		if isConstraintError(err, sqlite3.ErrConstraintPrimaryKey) {
+			return ErrVoterAlreadyExists.Error()
+		}
+		if isConstraintError(err, sqlite3.ErrConstraintUnique) {
+			return process.ErrEmailTaken.Error()
+		}
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		return nil
+	})
+}
+
+func (v *VoterDB) UpdateVoterInfo(voter process.VoterDTO, expectedVersion int) error {
+
+	return v.withRevision(voter.GetId(), revision.ActionUpdate, func(tx *sql.Tx) error {
+		if err := checkVersion(tx, expectedVersion, ErrVoterNotFound, activeVoterVersion, voter.GetId()); err != nil {
+			return err
+		}
+
+		args := append([]any{voter.GetName(), voter.GetEmail(), formatTime(time.Now())}, keyValues(voter.GetName(), voter.GetEmail())...)
+		args = append(args, voterProfileValues(voter)...)
+
+		result, err := tx.Exec(
+			`UPDATE voters SET name = ?, email = ?, modified = ?, version = version + 1, `+keyUpdate+`, `+profileUpdate+` WHERE id = ? AND deleted IS NULL`,
+			append(args, voter.GetId())...,
+		)
+		if isConstraintError(err, sqlite3.ErrConstraintUnique) {
+			return process.ErrEmailTaken.Error()
+		}
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		return requireRow(result, ErrVoterNotFound)
+	})
+}
+
+// DeleteSingleVoter marks the voter as deleted, it can be brought back with
+// RestoreVoter.
+func (v *VoterDB) DeleteSingleVoter(id int, reason string, expectedVersion int) error {
+
+	return v.withRevision(id, revision.ActionDelete, func(tx *sql.Tx) error {
+		if err := checkVersion(tx, expectedVersion, ErrVoterNotFound, activeVoterVersion, id); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(
+			`UPDATE voters SET deleted = ?, delete_reason = ?, version = version + 1 WHERE id = ? AND deleted IS NULL`,
+			formatTime(time.Now()),
+			reason,
+			id,
+		)
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		return requireRow(result, ErrVoterNotFound)
+	})
+}
+
+func (v *VoterDB) RestoreVoter(id int) error {
+
+	return v.withRevision(id, revision.ActionRestore, func(tx *sql.Tx) error {
+		result, err := tx.Exec(
+			`UPDATE voters SET deleted = NULL, delete_reason = '', modified = ?, version = version + 1 WHERE id = ? AND deleted IS NOT NULL`,
+			formatTime(time.Now()),
+			id,
+		)
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		if err := requireRow(result, ErrVoterNotDeleted); err == nil {
+			return nil
+		}
+
+		exists, err := voterExists(tx, id, true)
+		if err != nil {
+			return ErrGettingVoter.Error()
+		}
+		if !exists {
+			return ErrVoterNotFound.Error()
+		}
+
+		return ErrVoterNotDeleted.Error()
+	})
+}
+
+func (v *VoterDB) CreateVoterHistory(voterId int, pollId int, history process.VoterHistoryDTO) error {
+
+	return v.withRevision(voterId, revision.ActionCreateHistory, func(tx *sql.Tx) error {
+		return insertHistory(tx, voterId, pollId, history)
+	})
+}
+
+func insertHistory(tx *sql.Tx, voterId int, pollId int, history process.VoterHistoryDTO) error {
+	exists, err := voterExists(tx, voterId, false)
+	if err != nil {
+		return ErrGettingVoter.Error()
+	}
+	if !exists {
+		return ErrVoterNotFound.Error()
+	}
+
+	exists, err = pollExists(tx, pollId)
+	if err != nil {
+		return ErrGettingPoll.Error()
+	}
+	if !exists {
+		return process.ErrUnknownPoll.Error()
+	}
+
+	currentTime := formatTime(time.Now())
+
+	_, err = tx.Exec(
+		`INSERT INTO voter_history (voter_id, poll_id, vote_id, vote_date, choice, ranking, created, modified) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		voterId,
+		pollId,
+		history.GetVoteID(),
+		formatTime(history.GetVoteDate()),
+		history.GetChoice(),
+		formatList(history.GetRanking()),
+		currentTime,
+		currentTime,
+	)
+	if isConstraintError(err, sqlite3.ErrConstraintForeignKey) {
+		return ErrVoterNotFound.Error()
+	}
+	if isConstraintError(err, sqlite3.ErrConstraintPrimaryKey) {
+		return ErrHistoryAlreadyExists.Error()
+	}
+	if err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	return touchVoter(tx, voterId)
+}
+
+func (v *VoterDB) UpdateVoterHistoryInfo(voterId int, pollId int, history process.VoterHistoryDTO, expectedVersion int) error {
+
+	return v.withRevision(voterId, revision.ActionUpdateHistory, func(tx *sql.Tx) error {
+		if err := checkVersion(tx, expectedVersion, ErrHistoryNotFound, activeHistoryVersion, voterId, pollId); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(
+			`UPDATE voter_history SET vote_id = ?, vote_date = ?, choice = ?, ranking = ?, modified = ?, version = version + 1
+			WHERE voter_id = ? AND poll_id = ? AND deleted IS NULL
+			AND voter_id IN (SELECT id FROM voters WHERE deleted IS NULL)`,
+			history.GetVoteID(),
+			formatTime(history.GetVoteDate()),
+			history.GetChoice(),
+			formatList(history.GetRanking()),
+			formatTime(time.Now()),
+			voterId,
+			pollId,
+		)
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		if err := requireRow(result, ErrHistoryNotFound); err != nil {
+			return err
+		}
+
+		return touchVoter(tx, voterId)
+	})
+}
+
+// DeleteSingleVoterPoll marks the history as deleted, it can be brought back
+// with RestoreVoterPoll.
+func (v *VoterDB) DeleteSingleVoterPoll(voterId int, pollId int, reason string, expectedVersion int) error {
+
+	return v.withRevision(voterId, revision.ActionDeleteHistory, func(tx *sql.Tx) error {
+		if err := checkVersion(tx, expectedVersion, ErrHistoryNotFound, activeHistoryVersion, voterId, pollId); err != nil {
+			return err
+		}
+
+		result, err := tx.Exec(
+			`UPDATE voter_history SET deleted = ?, delete_reason = ?, version = version + 1
+			WHERE voter_id = ? AND poll_id = ? AND deleted IS NULL
+			AND voter_id IN (SELECT id FROM voters WHERE deleted IS NULL)`,
+			formatTime(time.Now()),
+			reason,
+			voterId,
+			pollId,
+		)
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		if err := requireRow(result, ErrHistoryNotFound); err != nil {
+			return err
+		}
+
+		return touchVoter(tx, voterId)
+	})
+}
+
+// GetVoterPoll returns the voter's history for the poll, deleted or not.
+func (v *VoterDB) GetVoterPoll(voterId int, pollId int) (process.VoterHistoryDTO, error) {
+
+	exists, err := voterExists(v.db, voterId, false)
+	if err != nil {
+		return process.VoterHistoryDTO{}, ErrGettingVoter.Error()
+	}
+	if !exists {
+		return process.VoterHistoryDTO{}, ErrVoterNotFound.Error()
+	}
+
+	history, err := queryHistory(v.db, `SELECT `+historyColumns+` FROM voter_history WHERE voter_id = ? AND poll_id = ?`, voterId, pollId)
+	if err != nil {
+		return process.VoterHistoryDTO{}, ErrGettingVoter.Error()
+	}
+	if len(history) == 0 {
+		return process.VoterHistoryDTO{}, ErrHistoryNotFound.Error()
+	}
+
+	return revision.HistoryDTO(pollId, history[0].toSnapshot()), nil
+}
+
+func (v *VoterDB) RestoreVoterPoll(voterId int, pollId int) error {
+
+	return v.withRevision(voterId, revision.ActionRestoreHistory, func(tx *sql.Tx) error {
+		exists, err := voterExists(tx, voterId, false)
+		if err != nil {
+			return ErrGettingVoter.Error()
+		}
+		if !exists {
+			return ErrVoterNotFound.Error()
+		}
+
+		history, err := queryHistory(tx, `SELECT `+historyColumns+` FROM voter_history WHERE voter_id = ? AND poll_id = ?`, voterId, pollId)
+		if err != nil {
+			return ErrGettingVoter.Error()
+		}
+		if len(history) == 0 {
+			return ErrHistoryNotFound.Error()
+		}
+		if history[0].deleted.IsZero() {
+			return ErrHistoryNotDeleted.Error()
+		}
+
+		_, err = tx.Exec(
+			`UPDATE voter_history SET deleted = NULL, delete_reason = '', modified = ?, version = version + 1 WHERE voter_id = ? AND poll_id = ?`,
+			formatTime(time.Now()),
+			voterId,
+			pollId,
+		)
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		return touchVoter(tx, voterId)
+	})
+}
+
+func (v *VoterDB) GetAllVoters(includeDeleted bool) ([]retrieve.VoterDTO, error) {
+
+	voterQuery := `SELECT ` + voterColumns + ` FROM voters WHERE deleted IS NULL ORDER BY id`
+	historyQuery := `SELECT ` + historyColumns + ` FROM voter_history WHERE deleted IS NULL`
+
+	if includeDeleted {
+		voterQuery = `SELECT ` + voterColumns + ` FROM voters ORDER BY id`
+		historyQuery = `SELECT ` + historyColumns + ` FROM voter_history`
+	}
+
+	rows, err := v.db.Query(voterQuery)
+	if err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+	defer rows.Close()
+
+	var voters []voterRow
+
+	for rows.Next() {
+		voter, err := scanVoter(rows)
+		if err != nil {
+			return nil, ErrGettingVoter.Error()
+		}
+		voters = append(voters, voter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+
+	history, err := queryHistory(v.db, historyQuery)
+	if err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+
+	historyByVoter := make(map[int]retrieve.HistoryMap)
+	for _, item := range history {
+		if historyByVoter[item.voterId] == nil {
+			historyByVoter[item.voterId] = make(retrieve.HistoryMap)
+		}
+		historyByVoter[item.voterId][item.pollId] = item.toDTO()
+	}
+
+	var votersList []retrieve.VoterDTO
+
+	for _, voter := range voters {
+		voterHistory := historyByVoter[voter.id]
+		if voterHistory == nil {
+			voterHistory = make(retrieve.HistoryMap)
+		}
+		votersList = append(votersList, voter.toDTO(voterHistory))
+	}
+
+	return votersList, nil
+}
+
+func (v *VoterDB) GetSingleVoter(id int, includeHistory bool) (retrieve.VoterDTO, error) {
+
+	row := v.db.QueryRow(`SELECT `+voterColumns+` FROM voters WHERE id = ? AND deleted IS NULL`, id)
+
+	voter, err := scanVoter(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return retrieve.VoterDTO{}, ErrVoterNotFound.Error()
+	}
+	if err != nil {
+		return retrieve.VoterDTO{}, ErrGettingVoter.Error()
+	}
+
+	voterHistory := make(retrieve.HistoryMap)
+
+	if !includeHistory {
+		return voter.toDTO(voterHistory), nil
+	}
+
+	history, err := queryHistory(v.db, `SELECT `+historyColumns+` FROM voter_history WHERE voter_id = ? AND deleted IS NULL`, id)
+	if err != nil {
+		return retrieve.VoterDTO{}, ErrGettingVoter.Error()
+	}
+
+	for _, item := range history {
+		voterHistory[item.pollId] = item.toDTO()
+	}
+
+	return voter.toDTO(voterHistory), nil
+}
+
+// GetVoterByEmail uses the voters_email_key index, which holds
+// process.EmailKey of every address.
+func (v *VoterDB) GetVoterByEmail(email string, includeHistory bool) (retrieve.VoterDTO, error) {
+
+	var id int
+
+	err := v.db.QueryRow(`SELECT id FROM voters WHERE email_key = ? AND deleted IS NULL`, process.EmailKey(email)).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return retrieve.VoterDTO{}, ErrVoterNotFound.Error()
+	}
+	if err != nil {
+		return retrieve.VoterDTO{}, ErrGettingVoter.Error()
+	}
+
+	return v.GetSingleVoter(id, includeHistory)
+}
+
+func (v *VoterDB) GetVoterHistory(voterId int, includeDeleted bool) ([]retrieve.VoterHistoryDTO, error) {
+
+	exists, err := voterExists(v.db, voterId, false)
+	if err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+	if !exists {
+		return nil, ErrVoterNotFound.Error()
+	}
+
+	history, err := queryHistory(v.db, `SELECT `+historyColumns+` FROM voter_history WHERE voter_id = ? ORDER BY poll_id`, voterId)
+	if err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+
+	if len(history) == 0 {
+		return nil, ErrNoVoterHistory.Error()
+	}
+
+	var historyList []retrieve.VoterHistoryDTO
+
+	for _, item := range history {
+		if !item.deleted.IsZero() && !includeDeleted {
+			continue
+		}
+
+		historyList = append(historyList, item.toDTO())
+	}
+
+	return historyList, nil
+}
+
+func (v *VoterDB) GetSingleEvent(voterId int, pollId int) (retrieve.VoterHistoryDTO, error) {
+
+	exists, err := voterExists(v.db, voterId, false)
+	if err != nil {
+		return retrieve.VoterHistoryDTO{}, ErrGettingVoter.Error()
+	}
+	if !exists {
+		return retrieve.VoterHistoryDTO{}, ErrVoterNotFound.Error()
+	}
+
+	history, err := queryHistory(v.db, `SELECT `+historyColumns+` FROM voter_history WHERE voter_id = ? AND poll_id = ? AND deleted IS NULL`, voterId, pollId)
+	if err != nil {
+		return retrieve.VoterHistoryDTO{}, ErrGettingVoter.Error()
+	}
+
+	if len(history) == 0 {
+		return retrieve.VoterHistoryDTO{}, ErrHistoryNotFound.Error()
+	}
+
+	return history[0].toDTO(), nil
+}
+
+func voterExists(q querier, id int, includeDeleted bool) (bool, error) {
+	var exists bool
+
+	query := `SELECT EXISTS (SELECT 1 FROM voters WHERE id = ? AND deleted IS NULL)`
+	if includeDeleted {
+		query = `SELECT EXISTS (SELECT 1 FROM voters WHERE id = ?)`
+	}
+
+	err := q.QueryRow(query, id).Scan(&exists)
+
+	return exists, err
+}
+
+func queryHistory(q querier, query string, args ...any) ([]historyRow, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []historyRow
+
+	for rows.Next() {
+		item, err := scanHistory(rows)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, item)
+	}
+
+	return history, rows.Err()
+}
+
+// checkVersion looks up the version of a record with query. It returns
+// notFound if there is no such record and ErrVersionMismatch if it is not at
+// the expected version.
+func checkVersion(q querier, expectedVersion int, notFound RepositoryError, query string, args ...any) error {
+	var version int
+
+	err := q.QueryRow(query, args...).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound.Error()
+	}
+	if err != nil {
+		return ErrGettingVoter.Error()
+	}
+
+	if expectedVersion != process.AnyVersion && expectedVersion != version {
+		return process.ErrVersionMismatch.Error()
+	}
+
+	return nil
+}
+
+// touchVoter moves the voter to its next version after a change to its
+// history.
+func touchVoter(q querier, voterId int) error {
+	_, err := q.Exec(`UPDATE voters SET version = version + 1 WHERE id = ?`, voterId)
+	if err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	return nil
+}
+
+// requireRow turns an update or delete that matched nothing into notFound.
+func requireRow(result sql.Result, notFound RepositoryError) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	if affected == 0 {
+		return notFound.Error()
+	}
+
+	return nil
+}
+
+func isConstraintError(err error, code sqlite3.ErrNoExtended) bool {
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == code
+}
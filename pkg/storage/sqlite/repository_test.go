@@ -0,0 +1,1054 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	fake "github.com/brianvoe/gofakeit/v6" //aliasing package name
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDB(t *testing.T) *VoterDB {
+	db, err := NewSqliteDB(filepath.Join(t.TempDir(), "voters.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestCreateRetrieveVoterWithRandomData(t *testing.T) {
+	db := newTestDB(t)
+
+	expectedVoter := process.NewVoterDTO(
+		fake.IntRange(1, 10),
+		fake.Name(),
+		fake.Email(),
+	)
+
+	err := db.CreateVoter(expectedVoter)
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(expectedVoter)
+	assert.Equal(t, ErrVoterAlreadyExists.Error(), err)
+
+	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId(), true)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedVoter.GetId(), actualVoter.GetId())
+	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
+	assert.Equal(t, expectedVoter.GetEmail(), actualVoter.GetEmail())
+}
+
+func TestUpdateDeleteVoter(t *testing.T) {
+	db := newTestDB(t)
+
+	expectedVoter := process.NewVoterDTO(
+		1,
+		fake.Name(),
+		fake.Email(),
+	)
+
+	err := db.UpdateVoterInfo(expectedVoter, process.AnyVersion)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	err = db.CreateVoter(expectedVoter)
+	assert.NoError(t, err)
+
+	expectedVoter = process.NewVoterDTO(
+		expectedVoter.GetId(),
+		fake.Name(),
+		fake.Email(),
+	)
+
+	err = db.UpdateVoterInfo(expectedVoter, process.AnyVersion)
+	assert.NoError(t, err)
+
+	actualVoter, err := db.GetSingleVoter(expectedVoter.GetId(), true)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedVoter.GetName(), actualVoter.GetName())
+	assert.Equal(t, expectedVoter.GetEmail(), actualVoter.GetEmail())
+
+	err = db.DeleteSingleVoter(expectedVoter.GetId(), "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	actualVoter, err = db.GetSingleVoter(expectedVoter.GetId(), true)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+	assert.Equal(t, retrieve.VoterDTO{}, actualVoter)
+
+	err = db.DeleteSingleVoter(expectedVoter.GetId(), "", process.AnyVersion)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+}
+
+func TestVoterHistory(t *testing.T) {
+	db := newTestDB(t)
+
+	err := db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	_, err = db.GetVoterHistory(1, false)
+	assert.Equal(t, ErrNoVoterHistory.Error(), err)
+
+	expectedPoll := process.NewVoterHistoryDTO(
+		fake.IntRange(1, 10),
+		fake.IntRange(11, 20),
+		fake.Date(),
+	)
+
+	err = db.CreateVoterHistory(1, expectedPoll.GetPollID(), expectedPoll)
+	assert.Equal(t, process.ErrUnknownPoll.Error(), err)
+
+	createPolls(t, db, expectedPoll.GetPollID())
+
+	err = db.CreateVoterHistory(1, expectedPoll.GetPollID(), expectedPoll)
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, expectedPoll.GetPollID(), expectedPoll)
+	assert.Equal(t, ErrHistoryAlreadyExists.Error(), err)
+
+	expectedPoll = process.NewVoterHistoryDTO(
+		expectedPoll.GetPollID(),
+		expectedPoll.GetVoteID(),
+		fake.Date(),
+	)
+
+	err = db.UpdateVoterHistoryInfo(1, expectedPoll.GetPollID(), expectedPoll, process.AnyVersion)
+	assert.NoError(t, err)
+
+	actualPoll, err := db.GetSingleEvent(1, expectedPoll.GetPollID())
+	assert.NoError(t, err)
+	assert.Equal(t, expectedPoll.GetPollID(), actualPoll.GetPollID())
+	assert.True(t, expectedPoll.GetVoteDate().Equal(actualPoll.GetVoteDate()))
+
+	voters, err := db.GetAllVoters(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(voters))
+	assert.Equal(t, 1, len(voters[0].GetHistory()))
+
+	err = db.DeleteSingleVoterPoll(1, expectedPoll.GetPollID(), "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	_, err = db.GetSingleEvent(1, expectedPoll.GetPollID())
+	assert.Equal(t, ErrHistoryNotFound.Error(), err)
+
+	err = db.UpdateVoterHistoryInfo(1, expectedPoll.GetPollID(), expectedPoll, process.AnyVersion)
+	assert.Equal(t, ErrHistoryNotFound.Error(), err)
+}
+
+func TestSoftDeleteAndRestore(t *testing.T) {
+	db := newTestDB(t)
+	createPolls(t, db, 1)
+
+	err := db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	err = db.RestoreVoterPoll(1, 1)
+	assert.Equal(t, ErrHistoryNotDeleted.Error(), err)
+
+	err = db.DeleteSingleVoterPoll(1, 1, "entered in error", process.AnyVersion)
+	assert.NoError(t, err)
+
+	history, err := db.GetVoterHistory(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(history))
+	assert.True(t, history[0].IsDeleted())
+	assert.Equal(t, "entered in error", history[0].GetDeleteReason())
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.Equal(t, ErrHistoryAlreadyExists.Error(), err)
+
+	err = db.DeleteSingleVoter(1, "moved away", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.Equal(t, ErrVoterAlreadyExists.Error(), err)
+
+	voters, err := db.GetAllVoters(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(voters))
+
+	voters, err = db.GetAllVoters(true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(voters))
+	assert.Equal(t, "moved away", voters[0].GetDeleteReason())
+
+	err = db.RestoreVoter(1)
+	assert.NoError(t, err)
+
+	err = db.RestoreVoter(1)
+	assert.Equal(t, ErrVoterNotDeleted.Error(), err)
+
+	err = db.RestoreVoter(2)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	err = db.RestoreVoterPoll(1, 1)
+	assert.NoError(t, err)
+
+	_, err = db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+}
+
+func TestMigrateExistingDatabase(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "voters.db")
+
+	//a database created before the schema was versioned
+	old, err := sql.Open("sqlite3", dbFile)
+	assert.NoError(t, err)
+	_, err = old.Exec(migrations[0])
+	assert.NoError(t, err)
+	_, err = old.Exec(`INSERT INTO voters (id, name, email, created, modified) VALUES (1, 'a', 'a@b.com', ?, ?)`,
+		formatTime(time.Now()), formatTime(time.Now()))
+	assert.NoError(t, err)
+	old.Close()
+
+	db, err := NewSqliteDB(dbFile)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	voter, err := db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "a", voter.GetName())
+	assert.False(t, voter.IsDeleted())
+
+	//the keys the listing filters on are filled in for existing voters
+	page, err := db.ListVoters(retrieve.VoterQuery{Sort: retrieve.SortId, Page: 1, PerPage: retrieve.DefaultPerPage, NamePrefix: "A", EmailDomain: "B.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.GetTotal())
+}
+
+// openAtVersion creates a database with only the first version migrations
+// applied, holding a voter for each of the emails.
+func openAtVersion(t *testing.T, version int, emails ...string) string {
+	dbFile := filepath.Join(t.TempDir(), "voters.db")
+
+	old, err := sql.Open("sqlite3", dbFile)
+	assert.NoError(t, err)
+	defer old.Close()
+
+	for _, migration := range migrations[:version] {
+		_, err = old.Exec(migration)
+		assert.NoError(t, err)
+	}
+	_, err = old.Exec(fmt.Sprintf("PRAGMA user_version = %d", version))
+	assert.NoError(t, err)
+
+	for i, email := range emails {
+		_, err = old.Exec(`INSERT INTO voters (id, name, email, created, modified) VALUES (?, 'a', ?, ?, ?)`,
+			i+1, email, formatTime(time.Now()), formatTime(time.Now()))
+		assert.NoError(t, err)
+	}
+
+	return dbFile
+}
+
+func TestMigrateDuplicateEmails(t *testing.T) {
+	//case differences are caught before voters_email is built
+	dbFile := openAtVersion(t, 4, "Pat@Example.com", "c@d.com", "pat@example.com")
+
+	_, err := NewSqliteDB(dbFile)
+	assert.ErrorIs(t, err, ErrDuplicateEmails.Error())
+	assert.ErrorContains(t, err, `voter 3: "pat@example.com" is also the email of voter 1`)
+
+	//lower() let these through, but process.EmailKey does not
+	dbFile = openAtVersion(t, 12, "a@b.com", " a@b.com", "ÉMILE@b.com", "émile@b.com")
+
+	_, err = NewSqliteDB(dbFile)
+	assert.ErrorIs(t, err, ErrDuplicateEmails.Error())
+	assert.ErrorContains(t, err, "voter 2:")
+	assert.ErrorContains(t, err, "voter 4:")
+
+	//nothing is changed, so the addresses can be fixed and the database opened
+	fix, err := sql.Open("sqlite3", dbFile)
+	assert.NoError(t, err)
+	_, err = fix.Exec(`UPDATE voters SET email = 'x' || id || '@b.com' WHERE id IN (2, 4)`)
+	assert.NoError(t, err)
+	fix.Close()
+
+	db, err := NewSqliteDB(dbFile)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	voter, err := db.GetVoterByEmail(" A@B.com", false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, voter.GetId())
+
+	voter, err = db.GetVoterByEmail("émile@b.com", false)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, voter.GetId())
+}
+
+func TestRevisionsAndRevert(t *testing.T) {
+	db := newTestDB(t)
+	createPolls(t, db, 1)
+
+	err := db.CreateVoter(process.NewVoterDTO(1, "first", "first@abc.com"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "second", "second@abc.com"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	//a failed change does not add a revision
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.Equal(t, ErrHistoryAlreadyExists.Error(), err)
+
+	revisions, err := db.GetVoterRevisions(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, "update", revisions[2].GetAction())
+	assert.Equal(t, 2, len(revisions[2].GetChanges()))
+
+	err = db.RevertVoter(1, 1)
+	assert.NoError(t, err)
+
+	voter, err := db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", voter.GetName())
+	assert.Equal(t, 0, len(voter.GetHistory()))
+
+	err = db.RevertVoter(1, 2)
+	assert.NoError(t, err)
+
+	_, err = db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+
+	revision, err := db.GetVoterRevision(1, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "revert", revision.GetAction())
+
+	_, err = db.GetVoterRevision(1, 6)
+	assert.Equal(t, ErrRevisionNotFound.Error(), err)
+
+	_, err = db.GetVoterRevisions(2)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+}
+
+func TestRevertedHistory(t *testing.T) {
+	db := newTestDB(t)
+
+	createPolls(t, db, 1, 2)
+
+	err := db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	voteDate := time.Date(2024, time.November, 5, 12, 0, 0, 0, time.UTC)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, voteDate).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterHistoryInfo(1, 1, process.NewVoterHistoryDTO(1, 1, voteDate).WithChoice("no"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 1, voteDate))
+	assert.NoError(t, err)
+
+	//revision 2 has the first choice in poll 1, and nothing in poll 2, which
+	//the revert would delete rather than bring back
+	reverted, err := db.GetRevertedHistory(1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []process.VoterHistoryDTO{process.NewVoterHistoryDTO(1, 1, voteDate).WithChoice("yes")}, reverted)
+
+	reverted, err = db.GetRevertedHistory(1, 4)
+	assert.NoError(t, err)
+	assert.Empty(t, reverted)
+
+	_, err = db.GetRevertedHistory(1, 9)
+	assert.Equal(t, ErrRevisionNotFound.Error(), err)
+
+	//a deleted vote is still returned so it can be checked before a restore
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	history, err := db.GetVoterPoll(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "no", history.GetChoice())
+	assert.True(t, voteDate.Equal(history.GetVoteDate()))
+
+	_, err = db.GetVoterPoll(1, 3)
+	assert.Equal(t, ErrHistoryNotFound.Error(), err)
+
+	_, err = db.GetVoterPoll(2, 1)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+}
+
+func TestVersionCheck(t *testing.T) {
+	db := newTestDB(t)
+	createPolls(t, db, 1)
+
+	err := db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "first", fake.Email()), 1)
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "second", fake.Email()), 1)
+	assert.Equal(t, process.ErrVersionMismatch.Error(), err)
+
+	voter, err := db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "first", voter.GetName())
+	assert.Equal(t, 2, voter.GetVersion())
+
+	//history changes move the voter on as well
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	history, err := db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, history.GetVersion())
+
+	err = db.UpdateVoterHistoryInfo(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()), 2)
+	assert.Equal(t, process.ErrVersionMismatch.Error(), err)
+
+	err = db.DeleteSingleVoterPoll(1, 1, "", 1)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(1, "", 2)
+	assert.Equal(t, process.ErrVersionMismatch.Error(), err)
+
+	err = db.DeleteSingleVoter(1, "", 4)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(1, "", 4)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+}
+
+func TestGetVoterIncludeHistory(t *testing.T) {
+	db := newTestDB(t)
+	var err error
+
+	createPolls(t, db, 1)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), "pat@example.com"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+	assert.NoError(t, err)
+
+	voter, err := db.GetSingleVoter(1, false)
+	assert.NoError(t, err)
+	assert.Empty(t, voter.GetHistory())
+
+	voter, err = db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Len(t, voter.GetHistory(), 1)
+
+	voter, err = db.GetVoterByEmail("pat@example.com", false)
+	assert.NoError(t, err)
+	assert.Empty(t, voter.GetHistory())
+
+	voter, err = db.GetVoterByEmail("pat@example.com", true)
+	assert.NoError(t, err)
+	assert.Len(t, voter.GetHistory(), 1)
+}
+
+func TestUniqueEmail(t *testing.T) {
+	db := newTestDB(t)
+
+	err := db.CreateVoter(process.NewVoterDTO(1, fake.Name(), "Pat@Example.com"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(2, fake.Name(), "pat@example.COM"))
+	assert.Equal(t, process.ErrEmailTaken.Error(), err)
+
+	//addresses are compared as process.EmailKey does, not as SQLite's lower()
+	err = db.CreateVoter(process.NewVoterDTO(2, fake.Name(), " pat@example.com "))
+	assert.Equal(t, process.ErrEmailTaken.Error(), err)
+
+	err = db.CreateVoter(process.NewVoterDTO(3, fake.Name(), "ÉMILE@example.com"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(4, fake.Name(), "émile@example.com"))
+	assert.Equal(t, process.ErrEmailTaken.Error(), err)
+
+	voter, err := db.GetVoterByEmail("Émile@Example.com", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, voter.GetId())
+
+	err = db.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(2, fake.Name(), "pat@example.com"), process.AnyVersion)
+	assert.Equal(t, process.ErrEmailTaken.Error(), err)
+
+	voter, err = db.GetVoterByEmail("PAT@example.com", true)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, voter.GetId())
+
+	//deleted voters keep their address reserved
+	err = db.DeleteSingleVoter(1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	_, err = db.GetVoterByEmail("pat@example.com", true)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(2, fake.Name(), "pat@example.com"), process.AnyVersion)
+	assert.Equal(t, process.ErrEmailTaken.Error(), err)
+}
+
+func TestPolls(t *testing.T) {
+	db := newTestDB(t)
+
+	opensAt := time.Date(2024, time.November, 5, 6, 0, 0, 0, time.UTC)
+
+	err := db.CreatePoll(process.NewPollDTO(2, "General", "", opensAt, opensAt.Add(14*time.Hour), process.PollDraft))
+	assert.NoError(t, err)
+
+	err = db.CreatePoll(process.NewPollDTO(2, "General", "", time.Time{}, time.Time{}, process.PollDraft))
+	assert.Equal(t, ErrPollAlreadyExists.Error(), err)
+
+	createPolls(t, db, 1)
+
+	err = db.UpdatePoll(process.NewPollDTO(2, "General election", "", opensAt, opensAt.Add(14*time.Hour), process.PollOpen))
+	assert.NoError(t, err)
+
+	err = db.UpdatePoll(process.NewPollDTO(3, "Primary", "", time.Time{}, time.Time{}, process.PollOpen))
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	poll, err := db.GetSinglePoll(2)
+	assert.NoError(t, err)
+	assert.Equal(t, "General election", poll.GetTitle())
+	assert.Equal(t, process.PollOpen, poll.GetStatus())
+	assert.True(t, opensAt.Equal(poll.GetOpensAt()))
+
+	poll, err = db.GetSinglePoll(1)
+	assert.NoError(t, err)
+	assert.True(t, poll.GetOpensAt().IsZero())
+
+	polls, err := db.GetAllPolls()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(polls))
+	assert.Equal(t, 1, polls[0].GetId())
+
+	//a poll with voter history is kept
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 2, fake.Date()))
+	assert.NoError(t, err)
+
+	err = db.DeletePoll(2)
+	assert.Equal(t, process.ErrPollInUse.Error(), err)
+
+	err = db.DeletePoll(1)
+	assert.NoError(t, err)
+
+	_, err = db.GetSinglePoll(1)
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+}
+
+func TestUpdatePollWithVotes(t *testing.T) {
+	db := newTestDB(t)
+	var err error
+
+	measure := process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"})
+
+	err = db.CreatePoll(measure)
+	assert.NoError(t, err)
+
+	err = db.CreatePoll(measure.WithSecret(true))
+	assert.Equal(t, ErrPollAlreadyExists.Error(), err)
+
+	//without votes the ballot can still change
+	err = db.UpdatePoll(measure.WithOptions([]string{"yes", "no", "abstain"}))
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("abstain"))
+	assert.NoError(t, err)
+
+	for _, changed := range []process.PollDTO{
+		measure,
+		measure.WithOptions([]string{"yes", "no", "abstain"}).WithRanked(true),
+		measure.WithOptions([]string{"yes", "no", "abstain"}).WithSecret(true),
+	} {
+		err = db.UpdatePoll(changed)
+		assert.Equal(t, process.ErrPollHasVotes.Error(), err)
+	}
+
+	//the rest of the poll can still change, and the options be reordered
+	err = db.UpdatePoll(process.NewPollDTO(1, "Measure 1A", "", time.Time{}, time.Time{}, process.PollClosed).WithOptions([]string{"abstain", "no", "yes"}))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.Equal(t, "Measure 1A", poll.GetTitle())
+	assert.Equal(t, []string{"abstain", "no", "yes"}, poll.GetOptions())
+	assert.False(t, poll.IsSecret())
+
+	//a deleted Poll event still counts as a vote
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.UpdatePoll(measure)
+	assert.Equal(t, process.ErrPollHasVotes.Error(), err)
+
+	//and so does a secret ballot
+	err = db.CreatePoll(process.NewPollDTO(2, "Measure 2", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}).WithSecret(true))
+	assert.NoError(t, err)
+
+	err = db.CastSecretBallot(1, 2, process.NewVoterHistoryDTO(2, 2, fake.Date()), process.NewBallotDTO(2, "QRSTUVWXYZ234567", "yes", nil))
+	assert.NoError(t, err)
+
+	err = db.UpdatePoll(process.NewPollDTO(2, "Measure 2", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}))
+	assert.Equal(t, process.ErrPollHasVotes.Error(), err)
+}
+
+func createPolls(t *testing.T, db *VoterDB, ids ...int) {
+	for _, id := range ids {
+		err := db.CreatePoll(process.NewPollDTO(id, fake.Sentence(3), "", time.Time{}, time.Time{}, process.PollOpen))
+		assert.NoError(t, err)
+	}
+}
+
+func TestPollWindow(t *testing.T) {
+	db := newTestDB(t)
+	createPolls(t, db, 1)
+
+	opensAt := time.Date(2024, time.November, 5, 6, 0, 0, 0, time.UTC)
+
+	err := db.SetPollWindow(1, opensAt, opensAt.Add(14*time.Hour))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.True(t, opensAt.Equal(poll.GetOpensAt()))
+	assert.True(t, opensAt.Add(14*time.Hour).Equal(poll.GetClosesAt()))
+	assert.Equal(t, process.PollOpen, poll.GetStatus())
+
+	err = db.SetPollWindow(1, opensAt, time.Time{})
+	assert.NoError(t, err)
+
+	err = db.SetPollWindow(2, opensAt, time.Time{})
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	_, err = db.GetPoll(2)
+	assert.Equal(t, process.ErrUnknownPoll.Error(), err)
+}
+
+func TestPollVotes(t *testing.T) {
+	db := newTestDB(t)
+
+	err := db.CreatePoll(process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"yes", "no"}, poll.GetOptions())
+
+	for id := 1; id <= 3; id++ {
+		err = db.CreateVoter(process.NewVoterDTO(id, fake.Name(), fake.Email()))
+		assert.NoError(t, err)
+	}
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterHistoryInfo(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("no"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	//a deleted voter is neither counted nor has a vote
+	err = db.CreateVoterHistory(3, 1, process.NewVoterHistoryDTO(1, 3, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(3, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	votes, err := db.GetPollVotes(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(votes))
+
+	choices := []string{}
+	for _, vote := range votes {
+		choices = append(choices, vote.GetChoice())
+	}
+	assert.ElementsMatch(t, []string{"yes", "no"}, choices)
+
+	count, err := db.CountVoters()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	_, err = db.GetPollVotes(2)
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	//a ranked-choice poll keeps the order of the ranking
+	err = db.CreatePoll(process.NewPollDTO(2, "Board", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"alice", "bob"}).WithRanked(true))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 2, process.NewVoterHistoryDTO(2, 1, fake.Date()).WithRanking([]string{"bob", "alice"}))
+	assert.NoError(t, err)
+
+	ranked, err := db.GetSinglePoll(2)
+	assert.NoError(t, err)
+	assert.True(t, ranked.IsRanked())
+
+	votes, err = db.GetPollVotes(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bob", "alice"}, votes[0].GetRanking())
+}
+
+func TestSecretBallot(t *testing.T) {
+	db := newTestDB(t)
+
+	err := db.CreatePoll(process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}).WithSecret(true))
+	assert.NoError(t, err)
+
+	poll, err := db.GetPoll(1)
+	assert.NoError(t, err)
+	assert.True(t, poll.IsSecret())
+
+	for id := 1; id <= 2; id++ {
+		err = db.CreateVoter(process.NewVoterDTO(id, fake.Name(), fake.Email()))
+		assert.NoError(t, err)
+	}
+
+	err = db.CastSecretBallot(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()), process.NewBallotDTO(1, "QRSTUVWXYZ234567", "yes", nil))
+	assert.NoError(t, err)
+
+	err = db.CastSecretBallot(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()), process.NewBallotDTO(1, "ABCDEFGHIJKLMNOP", "no", nil))
+	assert.NoError(t, err)
+
+	//a receipt is never handed out twice
+	err = db.CreateVoter(process.NewVoterDTO(3, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CastSecretBallot(3, 1, process.NewVoterHistoryDTO(1, 3, fake.Date()), process.NewBallotDTO(1, "ABCDEFGHIJKLMNOP", "yes", nil))
+	assert.Equal(t, ErrBallotAlreadyExists.Error(), err)
+
+	//the history records that the voter took part but not how
+	history, err := db.GetSingleEvent(1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "", history.GetChoice())
+
+	_, err = db.GetSingleEvent(3, 1)
+	assert.Error(t, err)
+
+	ballots, err := db.GetPollBallots(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(ballots))
+	assert.Equal(t, "ABCDEFGHIJKLMNOP", ballots[0].GetReceipt())
+	assert.Equal(t, "no", ballots[0].GetChoice())
+	assert.Equal(t, "yes", ballots[1].GetChoice())
+
+	ballot, err := db.GetBallot(1, "QRSTUVWXYZ234567")
+	assert.NoError(t, err)
+	assert.Equal(t, "yes", ballot.GetChoice())
+
+	_, err = db.GetBallot(1, "AAAAAAAAAAAAAAAA")
+	assert.Equal(t, process.ErrUnknownBallot.Error(), err)
+
+	_, err = db.GetPollBallots(2)
+	assert.Equal(t, ErrPollNotFound.Error(), err)
+
+	//the ballots keep the poll in use
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.DeletePoll(1)
+	assert.Equal(t, process.ErrPollInUse.Error(), err)
+}
+
+func TestAuditChain(t *testing.T) {
+	db := newTestDB(t)
+
+	_, err := db.VerifyChain("")
+	assert.Equal(t, process.ErrChainDisabled.Error(), err)
+
+	err = db.CreatePoll(process.NewPollDTO(1, "Measure 1", "", time.Time{}, time.Time{}, process.PollOpen).WithOptions([]string{"yes", "no"}))
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(1, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	//history that existed before the chain is the baseline
+	err = db.EnableChain()
+	assert.NoError(t, err)
+
+	err = db.CreateVoter(process.NewVoterDTO(2, fake.Name(), fake.Email()))
+	assert.NoError(t, err)
+
+	err = db.CreateVoterHistory(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("yes"))
+	assert.NoError(t, err)
+
+	err = db.UpdateVoterHistoryInfo(2, 1, process.NewVoterHistoryDTO(1, 2, fake.Date()).WithChoice("no"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	report, err := db.VerifyChain("")
+	assert.NoError(t, err)
+	assert.True(t, report.IsValid())
+	assert.Equal(t, 4, report.GetEntries())
+
+	head := report.GetHead()
+
+	//an edit made with the sqlite3 command line tool
+	_, err = db.db.Exec(`UPDATE voter_history SET choice = 'yes' WHERE voter_id = 2`)
+	assert.NoError(t, err)
+
+	report, err = db.VerifyChain(head)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"voter 2 poll 1: choice changed outside the API"}, report.GetProblems())
+
+	_, err = db.db.Exec(`DELETE FROM audit_chain WHERE seq = 4`)
+	assert.NoError(t, err)
+
+	report, err = db.VerifyChain(head)
+	assert.NoError(t, err)
+	assert.Contains(t, report.GetProblems(), "the chain does not contain head "+head)
+}
+
+func TestVoterProfile(t *testing.T) {
+	db := newTestDB(t)
+
+	dateOfBirth := time.Date(1990, time.May, 1, 0, 0, 0, 0, time.UTC)
+	home := process.NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104")
+	mail := process.NewAddressDTO("PO Box 1", "Philadelphia", "PA", "19104-0001")
+
+	err := db.CreateVoter(process.NewVoterDTO(1, "Pat", "pat@abc.com").WithProfile(dateOfBirth, home, mail, "Philadelphia County"))
+	assert.NoError(t, err)
+
+	voter, err := db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, dateOfBirth, voter.GetDateOfBirth())
+	assert.Equal(t, retrieve.NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104"), voter.GetResidentialAddress())
+	assert.Equal(t, retrieve.NewAddressDTO("PO Box 1", "Philadelphia", "PA", "19104-0001"), voter.GetMailingAddress())
+	assert.Equal(t, "Philadelphia County", voter.GetJurisdiction())
+
+	//moving drops the mailing address
+	moved := process.NewAddressDTO("2 Oak Ave", "Pittsburgh", "PA", "15213")
+	err = db.UpdateVoterInfo(process.NewVoterDTO(1, "Pat", "pat@abc.com").WithProfile(dateOfBirth, moved, process.AddressDTO{}, "Allegheny County"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	voter, err = db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, retrieve.NewAddressDTO("2 Oak Ave", "Pittsburgh", "PA", "15213"), voter.GetResidentialAddress())
+	assert.Equal(t, retrieve.AddressDTO{}, voter.GetMailingAddress())
+
+	revisions, err := db.GetVoterRevisions(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, 8, len(revisions[1].GetChanges()))
+
+	err = db.RevertVoter(1, 1)
+	assert.NoError(t, err)
+
+	voter, err = db.GetSingleVoter(1, true)
+	assert.NoError(t, err)
+	assert.Equal(t, dateOfBirth, voter.GetDateOfBirth())
+	assert.Equal(t, retrieve.NewAddressDTO("1 Main St", "Philadelphia", "PA", "19104"), voter.GetResidentialAddress())
+	assert.Equal(t, retrieve.NewAddressDTO("PO Box 1", "Philadelphia", "PA", "19104-0001"), voter.GetMailingAddress())
+	assert.Equal(t, "Philadelphia County", voter.GetJurisdiction())
+}
+
+func TestListVoters(t *testing.T) {
+	db := newTestDB(t)
+	createPolls(t, db, 1)
+
+	var middle time.Time
+
+	for id, name := range []string{"", "carol", "Alice", "bob", "alan"} {
+		if id == 0 {
+			continue
+		}
+		if id == 3 {
+			middle = time.Now()
+		}
+
+		domain := "drexel.edu"
+		if id == 2 {
+			domain = "example.com"
+		}
+
+		err := db.CreateVoter(process.NewVoterDTO(id, name, name+"@"+domain))
+		assert.NoError(t, err)
+
+		//keeps the created times apart
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	for _, id := range []int{1, 2, 3} {
+		err := db.CreateVoterHistory(id, 1, process.NewVoterHistoryDTO(1, 1, fake.Date()))
+		assert.NoError(t, err)
+	}
+
+	err := db.DeleteSingleVoterPoll(1, 1, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	err = db.DeleteSingleVoter(4, "", process.AnyVersion)
+	assert.NoError(t, err)
+
+	ids := func(query retrieve.VoterQuery) ([]int, int) {
+		if query.Sort == "" {
+			query.Sort = retrieve.SortId
+		}
+		if query.Page == 0 {
+			query.Page = 1
+		}
+		if query.PerPage == 0 {
+			query.PerPage = retrieve.DefaultPerPage
+		}
+
+		page, err := db.ListVoters(query)
+		assert.NoError(t, err)
+
+		var ids []int
+		for _, voter := range page.GetVoters() {
+			ids = append(ids, voter.GetId())
+		}
+		return ids, page.GetTotal()
+	}
+
+	list, total := ids(retrieve.VoterQuery{})
+	assert.Equal(t, []int{1, 2, 3}, list)
+	assert.Equal(t, 3, total)
+
+	list, total = ids(retrieve.VoterQuery{Sort: "name", IncludeDeleted: true})
+	assert.Equal(t, []int{4, 2, 3, 1}, list)
+	assert.Equal(t, 4, total)
+
+	list, total = ids(retrieve.VoterQuery{Sort: "-created", Page: 2, PerPage: 2})
+	assert.Equal(t, []int{1}, list)
+	assert.Equal(t, 3, total)
+
+	list, _ = ids(retrieve.VoterQuery{NamePrefix: "AL", IncludeDeleted: true})
+	assert.Equal(t, []int{2, 4}, list)
+
+	list, _ = ids(retrieve.VoterQuery{EmailDomain: "Drexel.edu"})
+	assert.Equal(t, []int{1, 3}, list)
+
+	list, _ = ids(retrieve.VoterQuery{CreatedAfter: middle})
+	assert.Equal(t, []int{3}, list)
+
+	list, _ = ids(retrieve.VoterQuery{CreatedBefore: middle})
+	assert.Equal(t, []int{1, 2}, list)
+
+	list, _ = ids(retrieve.VoterQuery{VotedInPoll: 1})
+	assert.Equal(t, []int{2, 3}, list)
+
+	//history is only loaded when asked for
+	page, err := db.ListVoters(retrieve.VoterQuery{Sort: retrieve.SortId, Page: 1, PerPage: 1, VotedInPoll: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(page.GetVoters()[0].GetHistory()))
+
+	page, err = db.ListVoters(retrieve.VoterQuery{Sort: retrieve.SortId, Page: 1, PerPage: 1, VotedInPoll: 1, IncludeHistory: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(page.GetVoters()[0].GetHistory()))
+}
+
+func TestListVotersNonASCII(t *testing.T) {
+	db := newTestDB(t)
+
+	for id, name := range map[int]string{1: "Émile", 2: "émilie", 3: "Eve", 4: "Ölaf"} {
+		err := db.CreateVoter(process.NewVoterDTO(id, name, fmt.Sprintf("v%d@Drexel.EDU", id)))
+		assert.NoError(t, err)
+	}
+
+	ids := func(query retrieve.VoterQuery) []int {
+		query.Page = 1
+		query.PerPage = retrieve.DefaultPerPage
+		if query.Sort == "" {
+			query.Sort = retrieve.SortId
+		}
+
+		page, err := db.ListVoters(query)
+		assert.NoError(t, err)
+
+		var ids []int
+		for _, voter := range page.GetVoters() {
+			ids = append(ids, voter.GetId())
+		}
+		return ids
+	}
+
+	//case is folded beyond ASCII, the same in every backend
+	assert.Equal(t, []int{1, 2}, ids(retrieve.VoterQuery{NamePrefix: "éMI"}))
+	assert.Equal(t, []int{4}, ids(retrieve.VoterQuery{NamePrefix: "ö"}))
+	assert.Equal(t, []int{1, 2, 3, 4}, ids(retrieve.VoterQuery{EmailDomain: "drexel.edu"}))
+	assert.Equal(t, []int{3, 1, 2, 4}, ids(retrieve.VoterQuery{Sort: retrieve.SortName}))
+}
+
+func TestSearchVoters(t *testing.T) {
+	dbFile := filepath.Join(t.TempDir(), "voters.db")
+
+	db, err := NewSqliteDB(dbFile)
+	assert.NoError(t, err)
+
+	for id, name := range []string{"", "Anna Kopp", "Ben Koepp", "José Núñez", "Carl Smith"} {
+		if id == 0 {
+			continue
+		}
+
+		err := db.CreateVoter(process.NewVoterDTO(id, name, fmt.Sprintf("voter%d@drexel.edu", id)))
+		assert.NoError(t, err)
+	}
+
+	search := func(text string, limit int) []string {
+		matches, err := db.SearchVoters(retrieve.SearchQuery{Text: text, Limit: limit})
+		assert.NoError(t, err)
+
+		var found []string
+		for _, match := range matches {
+			voter := match.GetVoter()
+			found = append(found, fmt.Sprintf("%d:%d", voter.GetId(), match.GetDistance()))
+		}
+		return found
+	}
+
+	assert.Equal(t, []string{"2:0", "1:1"}, search("Koepp", 10))
+	assert.Equal(t, []string{"2:0"}, search("Koepp", 1))
+	assert.Equal(t, []string{"3:0"}, search("jose nunez", 10))
+	assert.Empty(t, search("--", 10))
+
+	err = db.UpdateVoterInfo(process.NewVoterDTO(2, "Ben Smyth", "voter2@drexel.edu"), process.AnyVersion)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1:1"}, search("Koepp", 10))
+	assert.Equal(t, []string{"4:0", "2:1"}, search("smith", 10))
+
+	err = db.DeleteSingleVoter(1, "", process.AnyVersion)
+	assert.NoError(t, err)
+	assert.Empty(t, search("Koepp", 10))
+
+	err = db.RestoreVoter(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1:1"}, search("Koepp", 10))
+
+	err = db.RevertVoter(2, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2:0", "1:1"}, search("Koepp", 10))
+
+	//voters without keys, as in databases from before the index, are
+	//indexed when the database is opened
+	_, err = db.db.Exec(`DELETE FROM voter_name_keys`)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	db, err = NewSqliteDB(dbFile)
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.Equal(t, []string{"2:0", "1:1"}, search("Koepp", 10))
+}
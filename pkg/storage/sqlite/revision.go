@@ -0,0 +1,346 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/revision"
+	"github.com/mattn/go-sqlite3"
+)
+
+const revisionColumns = `revision, created, action, snapshot, changes`
+
+func (v *VoterDB) GetVoterRevisions(voterId int) ([]retrieve.RevisionDTO, error) {
+
+	exists, err := voterExists(v.db, voterId, true)
+	if err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+	if !exists {
+		return nil, ErrVoterNotFound.Error()
+	}
+
+	revisions, err := queryRevisions(v.db, `SELECT `+revisionColumns+` FROM voter_revisions WHERE voter_id = ? ORDER BY revision`, voterId)
+	if err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+
+	var revisionList []retrieve.RevisionDTO
+
+	for _, item := range revisions {
+		revisionList = append(revisionList, revision.ToDTO(voterId, item))
+	}
+
+	return revisionList, nil
+}
+
+func (v *VoterDB) GetVoterRevision(voterId int, number int) (retrieve.RevisionDTO, error) {
+
+	exists, err := voterExists(v.db, voterId, true)
+	if err != nil {
+		return retrieve.RevisionDTO{}, ErrGettingVoter.Error()
+	}
+	if !exists {
+		return retrieve.RevisionDTO{}, ErrVoterNotFound.Error()
+	}
+
+	revisions, err := queryRevisions(v.db, `SELECT `+revisionColumns+` FROM voter_revisions WHERE voter_id = ? AND revision = ?`, voterId, number)
+	if err != nil {
+		return retrieve.RevisionDTO{}, ErrGettingVoter.Error()
+	}
+
+	if len(revisions) == 0 {
+		return retrieve.RevisionDTO{}, ErrRevisionNotFound.Error()
+	}
+
+	return revision.ToDTO(voterId, revisions[0]), nil
+}
+
+// GetRevertedHistory returns the history that reverting the voter to the
+// given revision would bring back or change.
+func (v *VoterDB) GetRevertedHistory(voterId int, number int) ([]process.VoterHistoryDTO, error) {
+
+	current, _, err := loadSnapshot(v.db, voterId)
+	if err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+	if current == nil || current.Deleted {
+		return nil, ErrVoterNotFound.Error()
+	}
+
+	revisions, err := queryRevisions(v.db, `SELECT `+revisionColumns+` FROM voter_revisions WHERE voter_id = ? AND revision = ?`, voterId, number)
+	if err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+	if len(revisions) == 0 {
+		return nil, ErrRevisionNotFound.Error()
+	}
+
+	return revision.Reverted(*current, revisions[0].Snapshot), nil
+}
+
+// RevertVoter sets the voter and its history back to the given revision.
+// History added after that revision is marked as deleted rather than removed.
+func (v *VoterDB) RevertVoter(voterId int, number int) error {
+
+	return v.withRevision(voterId, revision.ActionRevert, func(tx *sql.Tx) error {
+		revisions, err := queryRevisions(tx, `SELECT `+revisionColumns+` FROM voter_revisions WHERE voter_id = ? AND revision = ?`, voterId, number)
+		if err != nil {
+			return ErrGettingVoter.Error()
+		}
+
+		currentTime := formatTime(time.Now())
+
+		result, err := tx.Exec(
+			`UPDATE voters SET modified = ?, version = version + 1 WHERE id = ? AND deleted IS NULL`,
+			currentTime,
+			voterId,
+		)
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		if err := requireRow(result, ErrVoterNotFound); err != nil {
+			return err
+		}
+
+		if len(revisions) == 0 {
+			return ErrRevisionNotFound.Error()
+		}
+
+		target := revisions[0]
+
+		args := append([]any{target.Snapshot.Name, target.Snapshot.Email}, keyValues(target.Snapshot.Name, target.Snapshot.Email)...)
+		args = append(args, snapshotProfileValues(target.Snapshot)...)
+
+		_, err = tx.Exec(
+			`UPDATE voters SET name = ?, email = ?, `+keyUpdate+`, `+profileUpdate+` WHERE id = ?`,
+			append(args, voterId)...,
+		)
+		if isConstraintError(err, sqlite3.ErrConstraintUnique) {
+			return process.ErrEmailTaken.Error()
+		}
+		if err != nil {
+			return ErrSaveFailed.Error()
+		}
+
+		history, err := queryHistory(tx, `SELECT `+historyColumns+` FROM voter_history WHERE voter_id = ? AND deleted IS NULL`, voterId)
+		if err != nil {
+			return ErrGettingVoter.Error()
+		}
+
+		for _, item := range history {
+			if _, exists := target.Snapshot.History[item.pollId]; exists {
+				continue
+			}
+
+			_, err = tx.Exec(
+				`UPDATE voter_history SET deleted = ?, delete_reason = ?, modified = ?, version = version + 1 WHERE voter_id = ? AND poll_id = ?`,
+				currentTime,
+				fmt.Sprintf("reverted to revision %d", target.Number),
+				currentTime,
+				voterId,
+				item.pollId,
+			)
+			if err != nil {
+				return ErrSaveFailed.Error()
+			}
+		}
+
+		for pollId, item := range target.Snapshot.History {
+			var deleted sql.NullString
+			if item.Deleted {
+				deleted = sql.NullString{String: currentTime, Valid: true}
+			}
+
+			_, err = tx.Exec(
+				`INSERT INTO voter_history (voter_id, poll_id, vote_id, vote_date, choice, ranking, created, modified, deleted, delete_reason)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (voter_id, poll_id) DO UPDATE SET
+				vote_id = excluded.vote_id, vote_date = excluded.vote_date, choice = excluded.choice, ranking = excluded.ranking, modified = excluded.modified,
+				deleted = excluded.deleted, delete_reason = excluded.delete_reason, version = voter_history.version + 1`,
+				voterId,
+				pollId,
+				item.VoteId,
+				formatTime(item.VoteDate),
+				item.Choice,
+				formatList(item.Ranking),
+				currentTime,
+				currentTime,
+				deleted,
+				item.DeleteReason,
+			)
+			if err != nil {
+				return ErrSaveFailed.Error()
+			}
+		}
+
+		return nil
+	})
+}
+
+// withRevision runs change in a transaction and stores a revision of the
+// voter as it is afterwards, along with the audit chain entries for any
+// history it changed. If change fails nothing is written.
+func (v *VoterDB) withRevision(voterId int, action revision.Action, change func(tx *sql.Tx) error) error {
+
+	tx, err := v.db.Begin()
+	if err != nil {
+		return ErrSaveFailed.Error()
+	}
+	defer tx.Rollback()
+
+	before, beforeTime, err := loadSnapshot(tx, voterId)
+	if err != nil {
+		return ErrGettingVoter.Error()
+	}
+
+	if err := change(tx); err != nil {
+		return err
+	}
+
+	after, _, err := loadSnapshot(tx, voterId)
+	if err != nil || after == nil {
+		return ErrGettingVoter.Error()
+	}
+
+	if before == nil || before.Name != after.Name || before.Deleted != after.Deleted {
+		if err := indexName(tx, voterId, *after); err != nil {
+			return ErrSaveFailed.Error()
+		}
+	}
+
+	if v.chainEnabled {
+		var history map[int]revision.HistorySnapshot
+		if before != nil {
+			history = before.History
+		}
+
+		if err := appendChain(tx, voterId, history, after.History, action); err != nil {
+			return ErrSaveFailed.Error()
+		}
+	}
+
+	var lastNumber int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(revision), 0) FROM voter_revisions WHERE voter_id = ?`, voterId).Scan(&lastNumber); err != nil {
+		return ErrGettingVoter.Error()
+	}
+
+	for _, item := range revision.Next(lastNumber, before, beforeTime, *after, action, time.Now()) {
+		if err := insertRevision(tx, voterId, item); err != nil {
+			return ErrSaveFailed.Error()
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return ErrSaveFailed.Error()
+	}
+
+	return nil
+}
+
+// loadSnapshot reads the current state of a voter, nil if there is no voter
+// with that id.
+func loadSnapshot(q querier, voterId int) (*revision.Snapshot, time.Time, error) {
+
+	voter, err := scanVoter(q.QueryRow(`SELECT `+voterColumns+` FROM voters WHERE id = ?`, voterId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, nil
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	history, err := queryHistory(q, `SELECT `+historyColumns+` FROM voter_history WHERE voter_id = ?`, voterId)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	snapshot := revision.Snapshot{
+		Name:         voter.name,
+		Email:        voter.email,
+		Deleted:      !voter.deleted.IsZero(),
+		DeleteReason: voter.deleteReason,
+
+		DateOfBirth:        revision.DateOf(voter.dateOfBirth),
+		ResidentialAddress: voter.residentialAddress,
+		MailingAddress:     voter.mailingAddress,
+		Jurisdiction:       voter.jurisdiction,
+	}
+
+	if len(history) > 0 {
+		snapshot.History = make(map[int]revision.HistorySnapshot)
+	}
+
+	for _, item := range history {
+		snapshot.History[item.pollId] = item.toSnapshot()
+	}
+
+	return &snapshot, voter.modified, nil
+}
+
+func insertRevision(tx *sql.Tx, voterId int, item revision.Revision) error {
+	snapshot, err := json.Marshal(item.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	changes, err := json.Marshal(item.Changes)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO voter_revisions (voter_id, `+revisionColumns+`) VALUES (?, ?, ?, ?, ?, ?)`,
+		voterId,
+		item.Number,
+		formatTime(item.Created),
+		string(item.Action),
+		string(snapshot),
+		string(changes),
+	)
+
+	return err
+}
+
+func queryRevisions(q querier, query string, args ...any) ([]revision.Revision, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []revision.Revision
+
+	for rows.Next() {
+		var item revision.Revision
+		var created, action, snapshot, changes string
+
+		if err := rows.Scan(&item.Number, &created, &action, &snapshot, &changes); err != nil {
+			return nil, err
+		}
+
+		if item.Created, err = time.Parse(timeFormat, created); err != nil {
+			return nil, err
+		}
+
+		item.Action = revision.Action(action)
+
+		if err := json.Unmarshal([]byte(snapshot), &item.Snapshot); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(changes), &item.Changes); err != nil {
+			return nil, err
+		}
+
+		revisions = append(revisions, item)
+	}
+
+	return revisions, rows.Err()
+}
@@ -0,0 +1,325 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/listing"
+	"drexel.edu/voter-api/pkg/storage/revision"
+)
+
+// times are stored as RFC 3339 text so the database stays readable with the
+// sqlite3 command line tool
+const timeFormat = time.RFC3339Nano
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+type voterRow struct {
+	id           int
+	name         string
+	email        string
+	created      time.Time
+	modified     time.Time
+	deleted      time.Time
+	deleteReason string
+	version      int
+
+	dateOfBirth        time.Time
+	residentialAddress *revision.Address
+	mailingAddress     *revision.Address
+	jurisdiction       string
+}
+
+type pollRow struct {
+	id          int
+	title       string
+	description string
+	opensAt     time.Time
+	closesAt    time.Time
+	status      string
+	options     []string
+	ranked      bool
+	secret      bool
+	created     time.Time
+	modified    time.Time
+}
+
+type historyRow struct {
+	voterId      int
+	pollId       int
+	voteId       int
+	voteDate     time.Time
+	choice       string
+	ranking      []string
+	created      time.Time
+	modified     time.Time
+	deleted      time.Time
+	deleteReason string
+	version      int
+}
+
+// scanVoter reads a row selected with voterColumns.
+func scanVoter(s scanner) (voterRow, error) {
+	var voter voterRow
+	var created, modified string
+	var deleted, dateOfBirth sql.NullString
+	var residential, mailing revision.Address
+
+	if err := s.Scan(&voter.id, &voter.name, &voter.email, &created, &modified, &deleted, &voter.deleteReason, &voter.version,
+		&dateOfBirth,
+		&residential.Street, &residential.City, &residential.State, &residential.PostalCode,
+		&mailing.Street, &mailing.City, &mailing.State, &mailing.PostalCode,
+		&voter.jurisdiction); err != nil {
+		return voterRow{}, err
+	}
+
+	voter.residentialAddress = optionalAddress(residential)
+	voter.mailingAddress = optionalAddress(mailing)
+
+	var err error
+
+	if voter.created, err = time.Parse(timeFormat, created); err != nil {
+		return voterRow{}, err
+	}
+
+	if voter.modified, err = time.Parse(timeFormat, modified); err != nil {
+		return voterRow{}, err
+	}
+
+	if voter.deleted, err = parseNullTime(deleted); err != nil {
+		return voterRow{}, err
+	}
+
+	if dateOfBirth.Valid {
+		if voter.dateOfBirth, err = time.Parse(revision.DateFormat, dateOfBirth.String); err != nil {
+			return voterRow{}, err
+		}
+	}
+
+	return voter, nil
+}
+
+// scanHistory reads a row selected with historyColumns.
+func scanHistory(s scanner) (historyRow, error) {
+	var history historyRow
+	var voteDate, ranking, created, modified string
+	var deleted sql.NullString
+
+	if err := s.Scan(&history.voterId, &history.pollId, &history.voteId, &voteDate, &history.choice, &ranking, &created, &modified, &deleted, &history.deleteReason, &history.version); err != nil {
+		return historyRow{}, err
+	}
+
+	var err error
+
+	if history.voteDate, err = time.Parse(timeFormat, voteDate); err != nil {
+		return historyRow{}, err
+	}
+
+	if history.ranking, err = parseList(ranking); err != nil {
+		return historyRow{}, err
+	}
+
+	if history.created, err = time.Parse(timeFormat, created); err != nil {
+		return historyRow{}, err
+	}
+
+	if history.modified, err = time.Parse(timeFormat, modified); err != nil {
+		return historyRow{}, err
+	}
+
+	if history.deleted, err = parseNullTime(deleted); err != nil {
+		return historyRow{}, err
+	}
+
+	return history, nil
+}
+
+// scanPoll reads a row selected with pollColumns.
+func scanPoll(s scanner) (pollRow, error) {
+	var poll pollRow
+	var options, created, modified string
+	var opensAt, closesAt sql.NullString
+
+	if err := s.Scan(&poll.id, &poll.title, &poll.description, &opensAt, &closesAt, &poll.status, &options, &poll.ranked, &poll.secret, &created, &modified); err != nil {
+		return pollRow{}, err
+	}
+
+	var err error
+
+	if poll.options, err = parseList(options); err != nil {
+		return pollRow{}, err
+	}
+
+	if poll.opensAt, err = parseNullTime(opensAt); err != nil {
+		return pollRow{}, err
+	}
+
+	if poll.closesAt, err = parseNullTime(closesAt); err != nil {
+		return pollRow{}, err
+	}
+
+	if poll.created, err = time.Parse(timeFormat, created); err != nil {
+		return pollRow{}, err
+	}
+
+	if poll.modified, err = time.Parse(timeFormat, modified); err != nil {
+		return pollRow{}, err
+	}
+
+	return poll, nil
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(timeFormat)
+}
+
+// formatNullTime stores the zero time as NULL.
+func formatNullTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+
+	return sql.NullString{String: formatTime(t), Valid: true}
+}
+
+// formatList stores poll options and rankings as a Json array.
+func formatList(list []string) string {
+	if list == nil {
+		return "[]"
+	}
+
+	data, _ := json.Marshal(list)
+
+	return string(data)
+}
+
+// parseList reads an empty array back as nil, the same as a poll created
+// without options or a vote without a ranking.
+func parseList(s string) ([]string, error) {
+	var list []string
+
+	if err := json.Unmarshal([]byte(s), &list); err != nil {
+		return nil, err
+	}
+
+	if len(list) == 0 {
+		return nil, nil
+	}
+
+	return list, nil
+}
+
+// keyValues are the values for keyColumns or keyUpdate, which are worked out
+// in Go as SQLite only folds the case of ASCII letters.
+func keyValues(name string, email string) []any {
+	return []any{process.EmailKey(email), listing.NameKey(name), listing.DomainKey(email)}
+}
+
+// voterProfileValues are the values for profileColumns or profileUpdate.
+func voterProfileValues(voter process.VoterDTO) []any {
+	return profileValues(revision.DateOf(voter.GetDateOfBirth()), revision.AddressOf(voter.GetResidentialAddress()), revision.AddressOf(voter.GetMailingAddress()), voter.GetJurisdiction())
+}
+
+func snapshotProfileValues(snapshot revision.Snapshot) []any {
+	return profileValues(snapshot.DateOfBirth, snapshot.ResidentialAddress, snapshot.MailingAddress, snapshot.Jurisdiction)
+}
+
+// profileValues stores a missing date of birth as NULL and a missing address
+// as blank columns.
+func profileValues(dateOfBirth *time.Time, residential *revision.Address, mailing *revision.Address, jurisdiction string) []any {
+	date := sql.NullString{}
+	if dateOfBirth != nil {
+		date = sql.NullString{String: dateOfBirth.Format(revision.DateFormat), Valid: true}
+	}
+
+	values := []any{date}
+
+	for _, address := range []*revision.Address{residential, mailing} {
+		if address == nil {
+			address = &revision.Address{}
+		}
+		values = append(values, address.Street, address.City, address.State, address.PostalCode)
+	}
+
+	return append(values, jurisdiction)
+}
+
+// optionalAddress reads blank address columns back as no address.
+func optionalAddress(address revision.Address) *revision.Address {
+	if address == (revision.Address{}) {
+		return nil
+	}
+	return &address
+}
+
+func parseNullTime(s sql.NullString) (time.Time, error) {
+	if !s.Valid {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(timeFormat, s.String)
+}
+
+func (v voterRow) toDTO(history retrieve.HistoryMap) retrieve.VoterDTO {
+	voterDTO := retrieve.NewVoterDTO(
+		v.id,
+		v.name,
+		v.email,
+		history,
+		v.created,
+		v.modified,
+	)
+
+	if !v.deleted.IsZero() {
+		voterDTO = voterDTO.WithDeleted(v.deleted, v.deleteReason)
+	}
+
+	voterDTO = voterDTO.WithProfile(v.dateOfBirth, v.residentialAddress.ToDTO(), v.mailingAddress.ToDTO(), v.jurisdiction)
+
+	return voterDTO.WithVersion(v.version)
+}
+
+func (h historyRow) toDTO() retrieve.VoterHistoryDTO {
+	historyDTO := retrieve.NewVoterHistoryDTO(
+		h.pollId,
+		h.voteId,
+		h.voteDate,
+		h.created,
+		h.modified,
+	)
+
+	if !h.deleted.IsZero() {
+		historyDTO = historyDTO.WithDeleted(h.deleted, h.deleteReason)
+	}
+
+	return historyDTO.WithChoice(h.choice).WithRanking(h.ranking).WithVersion(h.version)
+}
+
+func (h historyRow) toSnapshot() revision.HistorySnapshot {
+	return revision.HistorySnapshot{
+		VoteId:       h.voteId,
+		VoteDate:     h.voteDate,
+		Choice:       h.choice,
+		Ranking:      h.ranking,
+		Deleted:      !h.deleted.IsZero(),
+		DeleteReason: h.deleteReason,
+	}
+}
+
+func (p pollRow) toDTO() retrieve.PollDTO {
+	return retrieve.NewPollDTO(
+		p.id,
+		p.title,
+		p.description,
+		p.opensAt,
+		p.closesAt,
+		p.status,
+		p.created,
+		p.modified,
+	).WithOptions(p.options).WithRanked(p.ranked).WithSecret(p.secret)
+}
@@ -0,0 +1,312 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/storage/listing"
+)
+
+// migrations holds every change made to the schema, in order. The number of
+// migrations applied is stored in PRAGMA user_version, so a migration must
+// never be edited once released, only appended to.
+var migrations = []string{
+	`
+CREATE TABLE IF NOT EXISTS voters (
+	id       INTEGER PRIMARY KEY,
+	name     TEXT    NOT NULL,
+	email    TEXT    NOT NULL,
+	created  TEXT    NOT NULL,
+	modified TEXT    NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS voter_history (
+	voter_id  INTEGER NOT NULL REFERENCES voters(id) ON DELETE CASCADE,
+	poll_id   INTEGER NOT NULL,
+	vote_id   INTEGER NOT NULL,
+	vote_date TEXT    NOT NULL,
+	created   TEXT    NOT NULL,
+	modified  TEXT    NOT NULL,
+	PRIMARY KEY (voter_id, poll_id)
+);
+`,
+	`
+ALTER TABLE voters ADD COLUMN deleted TEXT;
+ALTER TABLE voters ADD COLUMN delete_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE voter_history ADD COLUMN deleted TEXT;
+ALTER TABLE voter_history ADD COLUMN delete_reason TEXT NOT NULL DEFAULT '';
+`,
+	`
+CREATE TABLE IF NOT EXISTS voter_revisions (
+	voter_id INTEGER NOT NULL REFERENCES voters(id) ON DELETE CASCADE,
+	revision INTEGER NOT NULL,
+	created  TEXT    NOT NULL,
+	action   TEXT    NOT NULL,
+	snapshot TEXT    NOT NULL,
+	changes  TEXT    NOT NULL,
+	PRIMARY KEY (voter_id, revision)
+);
+`,
+	`
+ALTER TABLE voters ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE voter_history ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+`,
+	`
+CREATE UNIQUE INDEX IF NOT EXISTS voters_email ON voters (lower(email));
+`,
+	// voter_history.poll_id has no foreign key to polls since history recorded
+	// before polls were kept refers to polls that were never created
+	`
+CREATE TABLE IF NOT EXISTS polls (
+	id          INTEGER PRIMARY KEY,
+	title       TEXT    NOT NULL,
+	description TEXT    NOT NULL DEFAULT '',
+	opens_at    TEXT,
+	closes_at   TEXT,
+	status      TEXT    NOT NULL,
+	created     TEXT    NOT NULL,
+	modified    TEXT    NOT NULL
+);
+`,
+	// polls.options holds a Json array of strings
+	`
+ALTER TABLE voter_history ADD COLUMN choice TEXT NOT NULL DEFAULT '';
+ALTER TABLE polls ADD COLUMN options TEXT NOT NULL DEFAULT '[]';
+`,
+	// voter_history.ranking holds a Json array of strings like polls.options
+	`
+ALTER TABLE voter_history ADD COLUMN ranking TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE polls ADD COLUMN ranked INTEGER NOT NULL DEFAULT 0;
+`,
+	// ballots has no voter id or timestamps and no rowid, so rows are stored
+	// in receipt order and cannot be matched to voter_history by the order
+	// they were inserted in
+	`
+ALTER TABLE polls ADD COLUMN secret INTEGER NOT NULL DEFAULT 0;
+CREATE TABLE IF NOT EXISTS ballots (
+	poll_id INTEGER NOT NULL REFERENCES polls (id),
+	receipt TEXT    NOT NULL,
+	choice  TEXT    NOT NULL DEFAULT '',
+	ranking TEXT    NOT NULL DEFAULT '[]',
+	PRIMARY KEY (poll_id, receipt)
+) WITHOUT ROWID;
+`,
+	`
+CREATE TABLE IF NOT EXISTS audit_chain (
+	seq   INTEGER PRIMARY KEY,
+	entry TEXT    NOT NULL
+);
+`,
+	// the date of birth is a YYYY-MM-DD date, NULL if it was not given
+	`
+ALTER TABLE voters ADD COLUMN date_of_birth TEXT;
+ALTER TABLE voters ADD COLUMN residential_street TEXT NOT NULL DEFAULT '';
+ALTER TABLE voters ADD COLUMN residential_city TEXT NOT NULL DEFAULT '';
+ALTER TABLE voters ADD COLUMN residential_state TEXT NOT NULL DEFAULT '';
+ALTER TABLE voters ADD COLUMN residential_postal_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE voters ADD COLUMN mailing_street TEXT NOT NULL DEFAULT '';
+ALTER TABLE voters ADD COLUMN mailing_city TEXT NOT NULL DEFAULT '';
+ALTER TABLE voters ADD COLUMN mailing_state TEXT NOT NULL DEFAULT '';
+ALTER TABLE voters ADD COLUMN mailing_postal_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE voters ADD COLUMN jurisdiction TEXT NOT NULL DEFAULT '';
+`,
+	// voter_name_keys holds the search.Keys of the names of the voters that
+	// are not deleted. Voters created before it existed are indexed when the
+	// database is opened, see indexMissingNames
+	`
+CREATE TABLE IF NOT EXISTS voter_name_keys (
+	key      TEXT    NOT NULL,
+	voter_id INTEGER NOT NULL REFERENCES voters(id) ON DELETE CASCADE,
+	PRIMARY KEY (key, voter_id)
+) WITHOUT ROWID;
+CREATE INDEX IF NOT EXISTS voter_name_keys_voter ON voter_name_keys (voter_id);
+`,
+	// email_key holds process.EmailKey of the email, which SQLite's lower()
+	// cannot compute since it only folds ASCII and does not trim. It is
+	// filled in by fillEmailKeys before the next migration indexes it
+	`
+ALTER TABLE voters ADD COLUMN email_key TEXT NOT NULL DEFAULT '';
+`,
+	`
+DROP INDEX IF EXISTS voters_email;
+CREATE UNIQUE INDEX IF NOT EXISTS voters_email_key ON voters (email_key);
+`,
+	// name_key and email_domain are what ListVoters filters and sorts on,
+	// folded by the listing package as LIKE and NOCASE only fold ASCII.
+	// They are filled in by fillListingKeys before the next migration
+	`
+ALTER TABLE voters ADD COLUMN name_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE voters ADD COLUMN email_domain TEXT NOT NULL DEFAULT '';
+`,
+	`
+CREATE INDEX IF NOT EXISTS voters_name_key ON voters (name_key);
+CREATE INDEX IF NOT EXISTS voters_email_domain ON voters (email_domain);
+`,
+}
+
+// migrationSteps are run before the SQL of the migration with the same
+// index, in the same transaction, for work that needs Go.
+var migrationSteps = map[int]func(tx *sql.Tx) error{
+	// voters_email fails on addresses that are already shared, and would
+	// only say so with a constraint error
+	4:  checkDuplicateEmails,
+	13: fillEmailKeys,
+	15: fillListingKeys,
+}
+
+// migrate brings the schema up to date, one transaction per migration.
+func migrate(db *sql.DB) error {
+	var version int
+
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return err
+	}
+
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this version supports (%d)", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+
+		if step, exists := migrationSteps[i]; exists {
+			if err := step(tx); err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if _, err := tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkDuplicateEmails reports every voter whose email is the same as that
+// of a voter with a lower id, as process.EmailKey compares them, in the same
+// way as the json fsck command. Deleted voters are included since they keep
+// their address. The addresses have to be changed before the database can be
+// opened.
+func checkDuplicateEmails(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, email FROM voters ORDER BY id`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	owners := make(map[string]int)
+	var problems []string
+
+	for rows.Next() {
+		var id int
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			return err
+		}
+
+		key := process.EmailKey(email)
+		if owner, exists := owners[key]; exists {
+			problems = append(problems, fmt.Sprintf("voter %d: %q is also the email of voter %d", id, email, owner))
+			continue
+		}
+		owners[key] = id
+	}
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w %s", ErrDuplicateEmails.Error(), strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// fillEmailKeys sets the email_key of every voter, once no two voters share
+// a key.
+func fillEmailKeys(tx *sql.Tx) error {
+	if err := checkDuplicateEmails(tx); err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`SELECT id, email FROM voters`)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[int]string)
+
+	for rows.Next() {
+		var id int
+		var email string
+		if err := rows.Scan(&id, &email); err != nil {
+			rows.Close()
+			return err
+		}
+		keys[id] = process.EmailKey(email)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, key := range keys {
+		if _, err := tx.Exec(`UPDATE voters SET email_key = ? WHERE id = ?`, key, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fillListingKeys sets the name_key and email_domain of every voter.
+func fillListingKeys(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, name, email FROM voters`)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[int][]any)
+
+	for rows.Next() {
+		var id int
+		var name, email string
+		if err := rows.Scan(&id, &name, &email); err != nil {
+			rows.Close()
+			return err
+		}
+		keys[id] = []any{listing.NameKey(name), listing.DomainKey(email)}
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for id, values := range keys {
+		if _, err := tx.Exec(`UPDATE voters SET name_key = ?, email_domain = ? WHERE id = ?`, append(values, id)...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
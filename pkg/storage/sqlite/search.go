@@ -0,0 +1,139 @@
+package sqlite
+
+import (
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/revision"
+	"drexel.edu/voter-api/pkg/storage/search"
+)
+
+// SearchVoters finds the voters sharing a key with the text in
+// voter_name_keys, ranks them and only loads the voters it returns.
+func (v *VoterDB) SearchVoters(query retrieve.SearchQuery) ([]retrieve.VoterMatchDTO, error) {
+
+	keys := search.Keys(query.Text)
+	if len(keys) == 0 {
+		return []retrieve.VoterMatchDTO{}, nil
+	}
+
+	args := make([]any, 0, len(keys))
+	for _, key := range keys {
+		args = append(args, key)
+	}
+
+	rows, err := v.db.Query(
+		`SELECT DISTINCT v.id, v.name FROM voter_name_keys k JOIN voters v ON v.id = k.voter_id
+		WHERE k.key IN (`+placeholders(len(keys))+`) AND v.deleted IS NULL`,
+		args...,
+	)
+	if err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+	defer rows.Close()
+
+	var candidates []search.Candidate
+
+	for rows.Next() {
+		var candidate search.Candidate
+		if err := rows.Scan(&candidate.Id, &candidate.Name); err != nil {
+			return nil, ErrGettingVoter.Error()
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+
+	matches := search.Rank(query.Text, candidates, query.Limit)
+	if len(matches) == 0 {
+		return []retrieve.VoterMatchDTO{}, nil
+	}
+
+	ids := make([]any, 0, len(matches))
+	for _, match := range matches {
+		ids = append(ids, match.Id)
+	}
+
+	voterRows, err := v.db.Query(`SELECT `+voterColumns+` FROM voters WHERE id IN (`+placeholders(len(ids))+`)`, ids...)
+	if err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+	defer voterRows.Close()
+
+	voters := make(map[int]voterRow, len(ids))
+
+	for voterRows.Next() {
+		voter, err := scanVoter(voterRows)
+		if err != nil {
+			return nil, ErrGettingVoter.Error()
+		}
+		voters[voter.id] = voter
+	}
+
+	if err := voterRows.Err(); err != nil {
+		return nil, ErrGettingVoter.Error()
+	}
+
+	voterMatches := make([]retrieve.VoterMatchDTO, 0, len(matches))
+	for _, match := range matches {
+		voterMatches = append(voterMatches, retrieve.NewVoterMatchDTO(voters[match.Id].toDTO(make(retrieve.HistoryMap)), match.Distance))
+	}
+
+	return voterMatches, nil
+}
+
+// indexName replaces the voter's search keys with those of its name, or
+// removes them if the voter is deleted.
+func indexName(q querier, voterId int, voter revision.Snapshot) error {
+
+	if _, err := q.Exec(`DELETE FROM voter_name_keys WHERE voter_id = ?`, voterId); err != nil {
+		return err
+	}
+
+	if voter.Deleted {
+		return nil
+	}
+
+	for _, key := range search.Keys(voter.Name) {
+		if _, err := q.Exec(`INSERT INTO voter_name_keys (key, voter_id) VALUES (?, ?)`, key, voterId); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// indexMissingNames indexes the voters that are not deleted and have no
+// search keys, which are those created before voter_name_keys existed and
+// those whose names have no letters or digits.
+func indexMissingNames(db querier) error {
+
+	rows, err := db.Query(`SELECT id, name FROM voters WHERE deleted IS NULL AND id NOT IN (SELECT voter_id FROM voter_name_keys)`)
+	if err != nil {
+		return err
+	}
+
+	var missing []search.Candidate
+
+	for rows.Next() {
+		var voter search.Candidate
+		if err := rows.Scan(&voter.Id, &voter.Name); err != nil {
+			rows.Close()
+			return err
+		}
+		missing = append(missing, voter)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, voter := range missing {
+		if err := indexName(db, voter.Id, revision.Snapshot{Name: voter.Name}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
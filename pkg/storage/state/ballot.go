@@ -0,0 +1,91 @@
+package state
+
+import (
+	"sort"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+)
+
+// Ballot is the choice made in a secret poll. It has no voter id and no
+// timestamps so it cannot be matched to the history that recorded the vote.
+type Ballot struct {
+	PollId  int      `json:"poll_id"`
+	Receipt string   `json:"receipt"`
+	Choice  string   `json:"choice,omitempty"`
+	Ranking []string `json:"ranking,omitempty"`
+}
+
+// CastSecretBallot records the history and stores the ballot together, so
+// neither is kept without the other.
+func (s *Store) CastSecretBallot(voterId int, pollId int, history process.VoterHistoryDTO, ballot process.BallotDTO) (Change, error) {
+	if _, exists := s.Ballots[pollId][ballot.GetReceipt()]; exists {
+		return Change{}, ErrBallotAlreadyExists.Error()
+	}
+
+	change, err := s.CreateVoterHistory(voterId, pollId, history)
+	if err != nil {
+		return Change{}, err
+	}
+
+	if s.Ballots[pollId] == nil {
+		s.Ballots[pollId] = make(map[string]Ballot)
+	}
+
+	s.Ballots[pollId][ballot.GetReceipt()] = Ballot{
+		PollId:  pollId,
+		Receipt: ballot.GetReceipt(),
+		Choice:  ballot.GetChoice(),
+		Ranking: ballot.GetRanking(),
+	}
+
+	return change, nil
+}
+
+func (s *Store) GetPollBallots(pollId int) ([]retrieve.BallotDTO, error) {
+	if _, exists := s.Polls[pollId]; !exists {
+		return nil, ErrPollNotFound.Error()
+	}
+
+	ballots := []retrieve.BallotDTO{}
+
+	for _, ballot := range s.SortedBallots() {
+		if ballot.PollId == pollId {
+			ballots = append(ballots, toBallotDTO(ballot))
+		}
+	}
+
+	return ballots, nil
+}
+
+func (s *Store) GetBallot(pollId int, receipt string) (retrieve.BallotDTO, error) {
+	if ballot, exists := s.Ballots[pollId][receipt]; exists {
+		return toBallotDTO(ballot), nil
+	}
+
+	return retrieve.BallotDTO{}, process.ErrUnknownBallot.Error()
+}
+
+// SortedBallots returns the ballots ordered by poll id and receipt, so a
+// file does not give away the order they were cast in.
+func (s *Store) SortedBallots() []Ballot {
+	var ballotList []Ballot
+	for _, ballots := range s.Ballots {
+		for _, item := range ballots {
+			ballotList = append(ballotList, item)
+		}
+	}
+
+	sort.Slice(ballotList, func(i, j int) bool {
+		if ballotList[i].PollId != ballotList[j].PollId {
+			return ballotList[i].PollId < ballotList[j].PollId
+		}
+		return ballotList[i].Receipt < ballotList[j].Receipt
+	})
+
+	return ballotList
+}
+
+func toBallotDTO(ballot Ballot) retrieve.BallotDTO {
+	return retrieve.NewBallotDTO(ballot.PollId, ballot.Receipt, ballot.Choice, ballot.Ranking)
+}
@@ -0,0 +1,37 @@
+package state
+
+import "drexel.edu/voter-api/pkg/process"
+
+type StateError string
+
+const (
+	ErrVoterAlreadyExists   StateError = "Attempted to create a voter but the id already exists."
+	ErrVoterNotFound        StateError = "The Voter Id was not found."
+	ErrHistoryNotFound      StateError = "The History Id for the Voter was not found"
+	ErrHistoryAlreadyExists StateError = "Attempted to create new history for the voter but the poll Id already exists"
+	ErrNoVoterHistory       StateError = "No history was found for the voter Id"
+	ErrVoterNotDeleted      StateError = "The Voter Id has not been deleted."
+	ErrHistoryNotDeleted    StateError = "The History Id for the Voter has not been deleted."
+	ErrRevisionNotFound     StateError = "The revision was not found for the Voter Id."
+	ErrPollAlreadyExists    StateError = "Attempted to create a poll but the id already exists."
+	ErrPollNotFound         StateError = "The Poll Id was not found."
+	ErrBallotAlreadyExists  StateError = "Attempted to cast a ballot but the receipt already exists."
+)
+
+var stateErrors = map[StateError]process.Error{
+	ErrVoterAlreadyExists:   {Kind: process.KindConflict, Code: "voter_exists"},
+	ErrVoterNotFound:        {Kind: process.KindNotFound, Code: "voter_not_found"},
+	ErrHistoryNotFound:      {Kind: process.KindNotFound, Code: "history_not_found"},
+	ErrHistoryAlreadyExists: {Kind: process.KindConflict, Code: "history_exists"},
+	ErrNoVoterHistory:       {Kind: process.KindNotFound, Code: "no_voter_history"},
+	ErrVoterNotDeleted:      {Kind: process.KindConflict, Code: "voter_not_deleted"},
+	ErrHistoryNotDeleted:    {Kind: process.KindConflict, Code: "history_not_deleted"},
+	ErrRevisionNotFound:     {Kind: process.KindNotFound, Code: "revision_not_found"},
+	ErrPollAlreadyExists:    {Kind: process.KindConflict, Code: "poll_exists"},
+	ErrPollNotFound:         {Kind: process.KindNotFound, Code: "poll_not_found"},
+	ErrBallotAlreadyExists:  {Kind: process.KindConflict, Code: "ballot_exists"},
+}
+
+func (e StateError) Error() error {
+	return stateErrors[e].WithMessage(string(e))
+}
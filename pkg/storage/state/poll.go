@@ -0,0 +1,243 @@
+package state
+
+import (
+	"sort"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+)
+
+type PollMap map[int]Poll
+
+type Poll struct {
+	Id          int        `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description,omitempty"`
+	OpensAt     *time.Time `json:"opens_at,omitempty"`
+	ClosesAt    *time.Time `json:"closes_at,omitempty"`
+	Status      string     `json:"status"`
+	Options     []string   `json:"options,omitempty"`
+	Ranked      bool       `json:"ranked,omitempty"`
+	Secret      bool       `json:"secret,omitempty"`
+	Created     time.Time  `json:"created"`
+	Modified    time.Time  `json:"modified"`
+}
+
+func (s *Store) CreatePoll(poll process.PollDTO) error {
+	if _, exists := s.Polls[poll.GetId()]; exists {
+		return ErrPollAlreadyExists.Error()
+	}
+
+	currentTime := time.Now()
+
+	s.Polls[poll.GetId()] = toPoll(poll, currentTime, currentTime)
+
+	return nil
+}
+
+func (s *Store) UpdatePoll(poll process.PollDTO) error {
+	previousPoll, exists := s.Polls[poll.GetId()]
+	if !exists {
+		return ErrPollNotFound.Error()
+	}
+
+	// votes already cast were checked against the old options
+	if !poll.SameBallot(previousPoll.Options, previousPoll.Ranked, previousPoll.Secret) && s.pollInUse(poll.GetId()) {
+		return process.ErrPollHasVotes.Error()
+	}
+
+	s.Polls[poll.GetId()] = toPoll(poll, previousPoll.Created, time.Now())
+
+	return nil
+}
+
+// DeletePoll removes the poll for good. Polls that any voter history or
+// secret ballot refers to, deleted or not, are kept.
+func (s *Store) DeletePoll(id int) error {
+	if _, exists := s.Polls[id]; !exists {
+		return ErrPollNotFound.Error()
+	}
+
+	if s.pollInUse(id) {
+		return process.ErrPollInUse.Error()
+	}
+
+	delete(s.Polls, id)
+
+	return nil
+}
+
+func (s *Store) SetPollWindow(id int, opensAt time.Time, closesAt time.Time) error {
+	poll, exists := s.Polls[id]
+	if !exists {
+		return ErrPollNotFound.Error()
+	}
+
+	poll.OpensAt = optionalTime(opensAt)
+	poll.ClosesAt = optionalTime(closesAt)
+	poll.Modified = time.Now()
+
+	s.Polls[id] = poll
+
+	return nil
+}
+
+// UndoPoll returns a function that puts the poll back the way it is now, for
+// a change that could not be persisted.
+func (s *Store) UndoPoll(id int) func() {
+	previous, existed := s.Polls[id]
+
+	return func() {
+		if existed {
+			s.Polls[id] = previous
+		} else {
+			delete(s.Polls, id)
+		}
+	}
+}
+
+// GetPoll is used by the process service to check the poll window.
+func (s *Store) GetPoll(id int) (process.PollDTO, error) {
+	poll, exists := s.Polls[id]
+	if !exists {
+		return process.PollDTO{}, process.ErrUnknownPoll.Error()
+	}
+
+	opensAt, closesAt := poll.window()
+
+	return process.NewPollDTO(poll.Id, poll.Title, poll.Description, opensAt, closesAt, poll.Status).WithOptions(poll.Options).WithRanked(poll.Ranked).WithSecret(poll.Secret), nil
+}
+
+func (s *Store) GetAllPolls() []retrieve.PollDTO {
+	pollList := make([]retrieve.PollDTO, 0, len(s.Polls))
+
+	for _, poll := range s.SortedPolls() {
+		pollList = append(pollList, toPollDTO(poll))
+	}
+
+	return pollList
+}
+
+func (s *Store) GetSinglePoll(id int) (retrieve.PollDTO, error) {
+	if poll, exists := s.Polls[id]; exists {
+		return toPollDTO(poll), nil
+	}
+
+	return retrieve.PollDTO{}, ErrPollNotFound.Error()
+}
+
+// GetPollVotes returns the history recorded for the poll by voters that have
+// not been deleted, leaving out deleted history.
+func (s *Store) GetPollVotes(pollId int) ([]retrieve.VoterHistoryDTO, error) {
+	if _, exists := s.Polls[pollId]; !exists {
+		return nil, ErrPollNotFound.Error()
+	}
+
+	var votes []retrieve.VoterHistoryDTO
+
+	for _, voter := range s.SortedVoters() {
+		if history, exists := s.activeHistory(voter.Id, pollId); exists {
+			votes = append(votes, toHistoryDTO(history))
+		}
+	}
+
+	return votes, nil
+}
+
+func (s *Store) CountVoters() int {
+	count := 0
+
+	for _, voter := range s.Voters {
+		if voter.Deleted == nil {
+			count++
+		}
+	}
+
+	return count
+}
+
+// pollInUse reports whether any voter history or secret ballot refers to the
+// poll.
+func (s *Store) pollInUse(id int) bool {
+	if len(s.Ballots[id]) > 0 {
+		return true
+	}
+
+	for _, voter := range s.Voters {
+		if _, exists := voter.VoterHistory[id]; exists {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SortedPolls returns the polls ordered by id.
+func (s *Store) SortedPolls() []Poll {
+	pollList := make([]Poll, 0, len(s.Polls))
+	for _, item := range s.Polls {
+		pollList = append(pollList, item)
+	}
+
+	sort.Slice(pollList, func(i, j int) bool {
+		return pollList[i].Id < pollList[j].Id
+	})
+
+	return pollList
+}
+
+func toPoll(poll process.PollDTO, created time.Time, modified time.Time) Poll {
+	return Poll{
+		Id:          poll.GetId(),
+		Title:       poll.GetTitle(),
+		Description: poll.GetDescription(),
+		OpensAt:     optionalTime(poll.GetOpensAt()),
+		ClosesAt:    optionalTime(poll.GetClosesAt()),
+		Status:      poll.GetStatus(),
+		Options:     poll.GetOptions(),
+		Ranked:      poll.IsRanked(),
+		Secret:      poll.IsSecret(),
+		Created:     created,
+		Modified:    modified,
+	}
+}
+
+// window returns the zero time for an unset end of the window.
+func (p Poll) window() (time.Time, time.Time) {
+	var opensAt, closesAt time.Time
+
+	if p.OpensAt != nil {
+		opensAt = *p.OpensAt
+	}
+
+	if p.ClosesAt != nil {
+		closesAt = *p.ClosesAt
+	}
+
+	return opensAt, closesAt
+}
+
+func toPollDTO(poll Poll) retrieve.PollDTO {
+	opensAt, closesAt := poll.window()
+
+	return retrieve.NewPollDTO(
+		poll.Id,
+		poll.Title,
+		poll.Description,
+		opensAt,
+		closesAt,
+		poll.Status,
+		poll.Created,
+		poll.Modified,
+	).WithOptions(poll.Options).WithRanked(poll.Ranked).WithSecret(poll.Secret)
+}
+
+// optionalTime leaves an unset time out of the file.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
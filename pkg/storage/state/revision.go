@@ -0,0 +1,191 @@
+package state
+
+import (
+	"fmt"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/revision"
+)
+
+// GetVoterRevisions lists the revisions of a voter, deleted or not.
+func (s *Store) GetVoterRevisions(voterId int) ([]retrieve.RevisionDTO, error) {
+	voter, exists := s.Voters[voterId]
+	if !exists {
+		return nil, ErrVoterNotFound.Error()
+	}
+
+	var revisionList []retrieve.RevisionDTO
+
+	for _, item := range voter.Revisions {
+		revisionList = append(revisionList, revision.ToDTO(voterId, item))
+	}
+
+	return revisionList, nil
+}
+
+func (s *Store) GetVoterRevision(voterId int, number int) (retrieve.RevisionDTO, error) {
+	voter, exists := s.Voters[voterId]
+	if !exists {
+		return retrieve.RevisionDTO{}, ErrVoterNotFound.Error()
+	}
+
+	item, exists := revision.Find(voter.Revisions, number)
+	if !exists {
+		return retrieve.RevisionDTO{}, ErrRevisionNotFound.Error()
+	}
+
+	return revision.ToDTO(voterId, item), nil
+}
+
+// GetRevertedHistory returns the history that reverting the voter to the
+// given revision would bring back or change.
+func (s *Store) GetRevertedHistory(voterId int, number int) ([]process.VoterHistoryDTO, error) {
+	voter, exists := s.activeVoter(voterId)
+	if !exists {
+		return nil, ErrVoterNotFound.Error()
+	}
+
+	target, exists := revision.Find(voter.Revisions, number)
+	if !exists {
+		return nil, ErrRevisionNotFound.Error()
+	}
+
+	return revision.Reverted(snapshotOf(voter), target.Snapshot), nil
+}
+
+// RevertVoter sets the voter and its history back to the given revision.
+// History added after that revision is marked as deleted rather than removed.
+func (s *Store) RevertVoter(voterId int, number int) (Change, error) {
+	voter, exists := s.activeVoter(voterId)
+	if !exists {
+		return Change{}, ErrVoterNotFound.Error()
+	}
+
+	target, exists := revision.Find(voter.Revisions, number)
+	if !exists {
+		return Change{}, ErrRevisionNotFound.Error()
+	}
+
+	if s.emailTaken(target.Snapshot.Email, voterId) {
+		return Change{}, process.ErrEmailTaken.Error()
+	}
+
+	before := snapshotOf(voter)
+	beforeTime := voter.Modified
+	previousEmail := voter.Email
+	currentTime := time.Now()
+
+	voter.Name = target.Snapshot.Name
+	voter.Email = target.Snapshot.Email
+	voter.DateOfBirth = target.Snapshot.DateOfBirth
+	voter.ResidentialAddress = target.Snapshot.ResidentialAddress
+	voter.MailingAddress = target.Snapshot.MailingAddress
+	voter.Jurisdiction = target.Snapshot.Jurisdiction
+	voter.Modified = currentTime
+	voter.Version++
+	voter.VoterHistory = revertHistory(voter.VoterHistory, target, currentTime)
+
+	s.Voters[voterId] = voter
+	s.indexEmail(previousEmail, voter)
+	s.indexName(voter)
+
+	return s.recordRevision(voterId, &before, beforeTime, revision.ActionRevert, currentTime), nil
+}
+
+// revertHistory returns a new history map matching the history in target.
+func revertHistory(history HistoryMap, target revision.Revision, currentTime time.Time) HistoryMap {
+	reverted := make(HistoryMap)
+
+	for pollId, item := range history {
+		if _, exists := target.Snapshot.History[pollId]; !exists && item.Deleted == nil {
+			item.Deleted = &currentTime
+			item.DeleteReason = fmt.Sprintf("reverted to revision %d", target.Number)
+			item.Modified = currentTime
+			item.Version++
+		}
+		reverted[pollId] = item
+	}
+
+	for pollId, snapshot := range target.Snapshot.History {
+		item, exists := reverted[pollId]
+		if !exists {
+			item = VoterHistory{PollId: pollId, Created: currentTime}
+		}
+
+		item.Version++
+		item.VoteId = snapshot.VoteId
+		item.VoteDate = snapshot.VoteDate
+		item.Choice = snapshot.Choice
+		item.Ranking = snapshot.Ranking
+		item.Modified = currentTime
+		item.Deleted = nil
+		item.DeleteReason = ""
+
+		if snapshot.Deleted {
+			item.Deleted = &currentTime
+			item.DeleteReason = snapshot.DeleteReason
+		}
+
+		reverted[pollId] = item
+	}
+
+	return reverted
+}
+
+// recordRevision appends the revisions for a change that has already been
+// applied to the voter and returns the change.
+func (s *Store) recordRevision(voterId int, before *revision.Snapshot, beforeTime time.Time, action revision.Action, currentTime time.Time) Change {
+	voter := s.Voters[voterId]
+	after := snapshotOf(voter)
+
+	revisions := revision.Next(revision.Last(voter.Revisions), before, beforeTime, after, action, currentTime)
+
+	voter.Revisions = append(voter.Revisions, revisions...)
+	s.Voters[voterId] = voter
+
+	if before == nil {
+		before = &revision.Snapshot{}
+	}
+
+	return Change{
+		VoterId:   voterId,
+		Action:    action,
+		Time:      currentTime,
+		Before:    before.History,
+		After:     after.History,
+		Revisions: revisions,
+	}
+}
+
+func snapshotOf(voter Voter) revision.Snapshot {
+	snapshot := revision.Snapshot{
+		Name:         voter.Name,
+		Email:        voter.Email,
+		Deleted:      voter.Deleted != nil,
+		DeleteReason: voter.DeleteReason,
+
+		DateOfBirth:        voter.DateOfBirth,
+		ResidentialAddress: voter.ResidentialAddress,
+		MailingAddress:     voter.MailingAddress,
+		Jurisdiction:       voter.Jurisdiction,
+	}
+
+	if len(voter.VoterHistory) > 0 {
+		snapshot.History = make(map[int]revision.HistorySnapshot)
+	}
+
+	for pollId, item := range voter.VoterHistory {
+		snapshot.History[pollId] = revision.HistorySnapshot{
+			VoteId:       item.VoteId,
+			VoteDate:     item.VoteDate,
+			Choice:       item.Choice,
+			Ranking:      item.Ranking,
+			Deleted:      item.Deleted != nil,
+			DeleteReason: item.DeleteReason,
+		}
+	}
+
+	return snapshot
+}
@@ -0,0 +1,659 @@
+package state
+
+import (
+	"maps"
+	"sort"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"drexel.edu/voter-api/pkg/storage/chain"
+	"drexel.edu/voter-api/pkg/storage/listing"
+	"drexel.edu/voter-api/pkg/storage/revision"
+	"drexel.edu/voter-api/pkg/storage/search"
+)
+
+// Store holds the voters, polls and ballots of the backends that keep them in
+// memory, the json and memory repositories, and applies their changes. It
+// does no locking and writes nothing, so the caller must hold its own lock
+// around every call and persist the changes it makes.
+type Store struct {
+	Voters DbMap
+	Polls  PollMap
+
+	// Ballots holds the ballots of secret polls by poll id and receipt,
+	// with nothing that links them to a voter
+	Ballots map[int]map[string]Ballot
+
+	// emailIndex maps process.EmailKey of every voter's email, deleted or
+	// not, to the voter id
+	emailIndex map[string]int
+
+	// nameIndex holds the names of the voters that are not deleted, for
+	// SearchVoters
+	nameIndex *search.Index
+}
+
+// Change is a change to a voter that has been applied to the store. It holds
+// the revisions the change added and the voter's history before and after it,
+// for the audit chain.
+type Change struct {
+	VoterId   int
+	Action    revision.Action
+	Time      time.Time
+	Before    map[int]revision.HistorySnapshot
+	After     map[int]revision.HistorySnapshot
+	Revisions []revision.Revision
+}
+
+func New() *Store {
+	return Load(nil, nil, nil)
+}
+
+// Load returns a store holding the voters, polls and ballots read back from
+// a file.
+func Load(voterList []Voter, pollList []Poll, ballotList []Ballot) *Store {
+	s := &Store{
+		Voters:  make(DbMap, len(voterList)),
+		Polls:   make(PollMap, len(pollList)),
+		Ballots: make(map[int]map[string]Ballot),
+	}
+
+	for _, item := range voterList {
+		s.Voters[item.Id] = WithInitialVersions(item)
+	}
+
+	for _, item := range pollList {
+		s.Polls[item.Id] = item
+	}
+
+	for _, item := range ballotList {
+		if s.Ballots[item.PollId] == nil {
+			s.Ballots[item.PollId] = make(map[string]Ballot)
+		}
+		s.Ballots[item.PollId][item.Receipt] = item
+	}
+
+	s.Reindex()
+
+	return s
+}
+
+// Reindex rebuilds the email and name indexes after Voters was changed
+// directly. Files written before emails were unique may share an address,
+// the lowest id keeps it.
+func (s *Store) Reindex() {
+	s.emailIndex = make(map[string]int, len(s.Voters))
+	s.nameIndex = search.NewIndex()
+
+	for _, voter := range s.SortedVoters() {
+		key := process.EmailKey(voter.Email)
+		if _, exists := s.emailIndex[key]; !exists {
+			s.emailIndex[key] = voter.Id
+		}
+
+		s.indexName(voter)
+	}
+}
+
+func (s *Store) CreateVoter(voter process.VoterDTO) (Change, error) {
+	// a deleted voter still holds its id until it is restored
+	if _, exists := s.Voters[voter.GetId()]; exists {
+		return Change{}, ErrVoterAlreadyExists.Error()
+	}
+
+	if s.emailTaken(voter.GetEmail(), voter.GetId()) {
+		return Change{}, process.ErrEmailTaken.Error()
+	}
+
+	currentTime := time.Now()
+
+	newVoter := Voter{
+		Id:           voter.GetId(),
+		Name:         voter.GetName(),
+		Email:        voter.GetEmail(),
+		VoterHistory: nil,
+		Created:      currentTime,
+		Modified:     currentTime,
+		Version:      1,
+
+		DateOfBirth:        revision.DateOf(voter.GetDateOfBirth()),
+		ResidentialAddress: revision.AddressOf(voter.GetResidentialAddress()),
+		MailingAddress:     revision.AddressOf(voter.GetMailingAddress()),
+		Jurisdiction:       voter.GetJurisdiction(),
+	}
+
+	s.Voters[newVoter.Id] = newVoter
+	s.emailIndex[process.EmailKey(newVoter.Email)] = newVoter.Id
+	s.indexName(newVoter)
+
+	return s.recordRevision(newVoter.Id, nil, time.Time{}, revision.ActionCreate, currentTime), nil
+}
+
+func (s *Store) UpdateVoterInfo(voter process.VoterDTO, expectedVersion int) (Change, error) {
+	previousVoter, exists := s.activeVoter(voter.GetId())
+	if !exists {
+		return Change{}, ErrVoterNotFound.Error()
+	}
+
+	if !versionMatches(previousVoter.Version, expectedVersion) {
+		return Change{}, process.ErrVersionMismatch.Error()
+	}
+
+	if s.emailTaken(voter.GetEmail(), voter.GetId()) {
+		return Change{}, process.ErrEmailTaken.Error()
+	}
+
+	currentTime := time.Now()
+
+	updatedVoter := Voter{
+		Id:           voter.GetId(),
+		Name:         voter.GetName(),
+		Email:        voter.GetEmail(),
+		VoterHistory: previousVoter.VoterHistory,
+		Created:      previousVoter.Created,
+		Modified:     currentTime,
+		Version:      previousVoter.Version + 1,
+		Revisions:    previousVoter.Revisions,
+
+		DateOfBirth:        revision.DateOf(voter.GetDateOfBirth()),
+		ResidentialAddress: revision.AddressOf(voter.GetResidentialAddress()),
+		MailingAddress:     revision.AddressOf(voter.GetMailingAddress()),
+		Jurisdiction:       voter.GetJurisdiction(),
+	}
+
+	s.Voters[updatedVoter.Id] = updatedVoter
+	s.indexEmail(previousVoter.Email, updatedVoter)
+	s.indexName(updatedVoter)
+
+	before := snapshotOf(previousVoter)
+
+	return s.recordRevision(updatedVoter.Id, &before, previousVoter.Modified, revision.ActionUpdate, currentTime), nil
+}
+
+// DeleteSingleVoter marks the voter as deleted. The voter and its history are
+// kept and can be brought back with RestoreVoter.
+func (s *Store) DeleteSingleVoter(id int, reason string, expectedVersion int) (Change, error) {
+	voter, exists := s.activeVoter(id)
+	if !exists {
+		return Change{}, ErrVoterNotFound.Error()
+	}
+
+	if !versionMatches(voter.Version, expectedVersion) {
+		return Change{}, process.ErrVersionMismatch.Error()
+	}
+
+	before := snapshotOf(voter)
+	beforeTime := voter.Modified
+	currentTime := time.Now()
+
+	voter.Deleted = &currentTime
+	voter.DeleteReason = reason
+	voter.Version++
+
+	s.Voters[id] = voter
+	s.indexName(voter)
+
+	return s.recordRevision(id, &before, beforeTime, revision.ActionDelete, currentTime), nil
+}
+
+func (s *Store) RestoreVoter(id int) (Change, error) {
+	voter, exists := s.Voters[id]
+	if !exists {
+		return Change{}, ErrVoterNotFound.Error()
+	}
+
+	if voter.Deleted == nil {
+		return Change{}, ErrVoterNotDeleted.Error()
+	}
+
+	before := snapshotOf(voter)
+	beforeTime := voter.Modified
+
+	voter.Deleted = nil
+	voter.DeleteReason = ""
+	voter.Modified = time.Now()
+	voter.Version++
+
+	s.Voters[id] = voter
+	s.indexName(voter)
+
+	return s.recordRevision(id, &before, beforeTime, revision.ActionRestore, voter.Modified), nil
+}
+
+func (s *Store) CreateVoterHistory(voterId int, pollId int, history process.VoterHistoryDTO) (Change, error) {
+	voter, exists := s.activeVoter(voterId)
+	if !exists {
+		return Change{}, ErrVoterNotFound.Error()
+	}
+
+	if _, exists := s.Polls[pollId]; !exists {
+		return Change{}, process.ErrUnknownPoll.Error()
+	}
+
+	if _, exists := voter.VoterHistory[pollId]; exists {
+		return Change{}, ErrHistoryAlreadyExists.Error()
+	}
+
+	before := snapshotOf(voter)
+	currentTime := time.Now()
+
+	if voter.VoterHistory == nil {
+		voter.VoterHistory = make(HistoryMap)
+	}
+
+	voter.VoterHistory[pollId] = VoterHistory{
+		PollId:   pollId,
+		VoteId:   history.GetVoteID(),
+		VoteDate: history.GetVoteDate(),
+		Choice:   history.GetChoice(),
+		Ranking:  history.GetRanking(),
+		Created:  currentTime,
+		Modified: currentTime,
+		Version:  1,
+	}
+
+	voter.Version++
+
+	s.Voters[voterId] = voter
+
+	return s.recordRevision(voterId, &before, voter.Modified, revision.ActionCreateHistory, currentTime), nil
+}
+
+func (s *Store) UpdateVoterHistoryInfo(voterId int, pollId int, history process.VoterHistoryDTO, expectedVersion int) (Change, error) {
+	previousHistory, exists := s.activeHistory(voterId, pollId)
+	if !exists {
+		return Change{}, ErrHistoryNotFound.Error()
+	}
+
+	if !versionMatches(previousHistory.Version, expectedVersion) {
+		return Change{}, process.ErrVersionMismatch.Error()
+	}
+
+	before := snapshotOf(s.Voters[voterId])
+	currentTime := time.Now()
+
+	s.Voters[voterId].VoterHistory[pollId] = VoterHistory{
+		PollId:   pollId,
+		VoteId:   history.GetVoteID(),
+		VoteDate: history.GetVoteDate(),
+		Choice:   history.GetChoice(),
+		Ranking:  history.GetRanking(),
+		Created:  previousHistory.Created,
+		Modified: currentTime,
+		Version:  previousHistory.Version + 1,
+	}
+
+	s.touchVoter(voterId)
+
+	return s.recordRevision(voterId, &before, previousHistory.Modified, revision.ActionUpdateHistory, currentTime), nil
+}
+
+// DeleteSingleVoterPoll marks the voter history as deleted. It can be brought
+// back with RestoreVoterPoll.
+func (s *Store) DeleteSingleVoterPoll(voterId int, pollId int, reason string, expectedVersion int) (Change, error) {
+	history, exists := s.activeHistory(voterId, pollId)
+	if !exists {
+		return Change{}, ErrHistoryNotFound.Error()
+	}
+
+	if !versionMatches(history.Version, expectedVersion) {
+		return Change{}, process.ErrVersionMismatch.Error()
+	}
+
+	before := snapshotOf(s.Voters[voterId])
+	beforeTime := history.Modified
+	currentTime := time.Now()
+
+	history.Deleted = &currentTime
+	history.DeleteReason = reason
+	history.Version++
+
+	s.Voters[voterId].VoterHistory[pollId] = history
+	s.touchVoter(voterId)
+
+	return s.recordRevision(voterId, &before, beforeTime, revision.ActionDeleteHistory, currentTime), nil
+}
+
+func (s *Store) RestoreVoterPoll(voterId int, pollId int) (Change, error) {
+	voter, exists := s.activeVoter(voterId)
+	if !exists {
+		return Change{}, ErrVoterNotFound.Error()
+	}
+
+	history, exists := voter.VoterHistory[pollId]
+	if !exists {
+		return Change{}, ErrHistoryNotFound.Error()
+	}
+
+	if history.Deleted == nil {
+		return Change{}, ErrHistoryNotDeleted.Error()
+	}
+
+	before := snapshotOf(voter)
+	beforeTime := history.Modified
+
+	history.Deleted = nil
+	history.DeleteReason = ""
+	history.Modified = time.Now()
+	history.Version++
+
+	voter.VoterHistory[pollId] = history
+	s.touchVoter(voterId)
+
+	return s.recordRevision(voterId, &before, beforeTime, revision.ActionRestoreHistory, history.Modified), nil
+}
+
+// GetVoterPoll returns the voter's history for the poll, deleted or not.
+func (s *Store) GetVoterPoll(voterId int, pollId int) (process.VoterHistoryDTO, error) {
+	voter, exists := s.activeVoter(voterId)
+	if !exists {
+		return process.VoterHistoryDTO{}, ErrVoterNotFound.Error()
+	}
+
+	if _, exists := voter.VoterHistory[pollId]; !exists {
+		return process.VoterHistoryDTO{}, ErrHistoryNotFound.Error()
+	}
+
+	return revision.HistoryDTO(pollId, snapshotOf(voter).History[pollId]), nil
+}
+
+func (s *Store) GetAllVoters(includeDeleted bool) []retrieve.VoterDTO {
+	var votersList []retrieve.VoterDTO
+
+	for _, voter := range s.Voters {
+		if voter.Deleted != nil && !includeDeleted {
+			continue
+		}
+
+		votersList = append(votersList, toVoterDTO(voter, includeDeleted))
+	}
+
+	return votersList
+}
+
+func (s *Store) ListVoters(query retrieve.VoterQuery) retrieve.VoterPageDTO {
+	records := make([]listing.Record, 0, len(s.Voters))
+
+	for _, voter := range s.Voters {
+		history, voted := voter.VoterHistory[query.VotedInPoll]
+
+		records = append(records, listing.Record{
+			Id:       voter.Id,
+			Name:     voter.Name,
+			Email:    voter.Email,
+			Created:  voter.Created,
+			Modified: voter.Modified,
+			Deleted:  voter.Deleted != nil,
+			Voted:    voted && history.Deleted == nil,
+		})
+	}
+
+	ids, total := listing.Select(query, records)
+
+	votersList := make([]retrieve.VoterDTO, 0, len(ids))
+	for _, id := range ids {
+		voter := s.Voters[id]
+		if !query.IncludeHistory {
+			voter.VoterHistory = nil
+		}
+
+		votersList = append(votersList, toVoterDTO(voter, query.IncludeDeleted))
+	}
+
+	return retrieve.NewVoterPageDTO(votersList, total)
+}
+
+func (s *Store) SearchVoters(query retrieve.SearchQuery) []retrieve.VoterMatchDTO {
+	matches := search.Rank(query.Text, s.nameIndex.Candidates(query.Text), query.Limit)
+
+	voters := make([]retrieve.VoterMatchDTO, 0, len(matches))
+	for _, match := range matches {
+		voter := s.Voters[match.Id]
+		voter.VoterHistory = nil
+
+		voters = append(voters, retrieve.NewVoterMatchDTO(toVoterDTO(voter, false), match.Distance))
+	}
+
+	return voters
+}
+
+func (s *Store) GetSingleVoter(id int, includeHistory bool) (retrieve.VoterDTO, error) {
+	voter, exists := s.activeVoter(id)
+	if !exists {
+		return retrieve.VoterDTO{}, ErrVoterNotFound.Error()
+	}
+
+	if !includeHistory {
+		voter.VoterHistory = nil
+	}
+
+	return toVoterDTO(voter, false), nil
+}
+
+func (s *Store) GetVoterByEmail(email string, includeHistory bool) (retrieve.VoterDTO, error) {
+	if id, exists := s.emailIndex[process.EmailKey(email)]; exists {
+		return s.GetSingleVoter(id, includeHistory)
+	}
+
+	return retrieve.VoterDTO{}, ErrVoterNotFound.Error()
+}
+
+func (s *Store) GetVoterHistory(voterId int, includeDeleted bool) ([]retrieve.VoterHistoryDTO, error) {
+	voter, exists := s.activeVoter(voterId)
+	if !exists {
+		return nil, ErrVoterNotFound.Error()
+	}
+
+	if voter.VoterHistory == nil {
+		return nil, ErrNoVoterHistory.Error()
+	}
+
+	var historyList []retrieve.VoterHistoryDTO
+
+	for _, item := range voter.VoterHistory {
+		if item.Deleted != nil && !includeDeleted {
+			continue
+		}
+
+		historyList = append(historyList, toHistoryDTO(item))
+	}
+
+	return historyList, nil
+}
+
+func (s *Store) GetSingleEvent(voterId int, pollId int) (retrieve.VoterHistoryDTO, error) {
+	if _, exists := s.activeVoter(voterId); !exists {
+		return retrieve.VoterHistoryDTO{}, ErrVoterNotFound.Error()
+	}
+
+	history, exists := s.activeHistory(voterId, pollId)
+	if !exists {
+		return retrieve.VoterHistoryDTO{}, ErrHistoryNotFound.Error()
+	}
+
+	return toHistoryDTO(history), nil
+}
+
+// HistoryRecords returns every voter's history, deleted or not, for the
+// audit chain.
+func (s *Store) HistoryRecords() map[chain.Key]revision.HistorySnapshot {
+	records := make(map[chain.Key]revision.HistorySnapshot)
+
+	for voterId, voter := range s.Voters {
+		chain.AddRecords(records, voterId, snapshotOf(voter).History)
+	}
+
+	return records
+}
+
+// UndoVoter returns a function that puts the voter back the way it is now,
+// along with the email and name indexes, for a change that could not be
+// persisted. The history is copied because changes write to it in place.
+func (s *Store) UndoVoter(id int) func() {
+	previous, existed := s.Voters[id]
+	previous.VoterHistory = maps.Clone(previous.VoterHistory)
+
+	previousKey := process.EmailKey(previous.Email)
+	previousOwner, indexed := s.emailIndex[previousKey]
+
+	return func() {
+		key := process.EmailKey(s.Voters[id].Email)
+		if owner, exists := s.emailIndex[key]; exists && owner == id {
+			delete(s.emailIndex, key)
+		}
+
+		if indexed {
+			s.emailIndex[previousKey] = previousOwner
+		}
+
+		if !existed {
+			delete(s.Voters, id)
+			s.nameIndex.Remove(id)
+			return
+		}
+
+		s.Voters[id] = previous
+		s.indexName(previous)
+	}
+}
+
+// SortedVoters returns the voters ordered by id.
+func (s *Store) SortedVoters() []Voter {
+	voterList := make([]Voter, 0, len(s.Voters))
+	for _, item := range s.Voters {
+		voterList = append(voterList, item)
+	}
+
+	sort.Slice(voterList, func(i, j int) bool {
+		return voterList[i].Id < voterList[j].Id
+	})
+
+	return voterList
+}
+
+// activeVoter looks up a voter that has not been deleted.
+func (s *Store) activeVoter(id int) (Voter, bool) {
+	voter, exists := s.Voters[id]
+	if !exists || voter.Deleted != nil {
+		return Voter{}, false
+	}
+
+	return voter, true
+}
+
+// activeHistory looks up history that has not been deleted for a voter that
+// has not been deleted.
+func (s *Store) activeHistory(voterId int, pollId int) (VoterHistory, bool) {
+	voter, exists := s.activeVoter(voterId)
+	if !exists {
+		return VoterHistory{}, false
+	}
+
+	history, exists := voter.VoterHistory[pollId]
+	if !exists || history.Deleted != nil {
+		return VoterHistory{}, false
+	}
+
+	return history, true
+}
+
+// emailTaken reports whether another voter than id has the email.
+func (s *Store) emailTaken(email string, id int) bool {
+	owner, exists := s.emailIndex[process.EmailKey(email)]
+
+	return exists && owner != id
+}
+
+// indexEmail moves the voter's index entry from its previous email.
+func (s *Store) indexEmail(previousEmail string, voter Voter) {
+	if owner := s.emailIndex[process.EmailKey(previousEmail)]; owner == voter.Id {
+		delete(s.emailIndex, process.EmailKey(previousEmail))
+	}
+
+	s.emailIndex[process.EmailKey(voter.Email)] = voter.Id
+}
+
+// indexName keeps the voter's name in the search index while it is not
+// deleted.
+func (s *Store) indexName(voter Voter) {
+	if voter.Deleted == nil {
+		s.nameIndex.Set(voter.Id, voter.Name)
+	} else {
+		s.nameIndex.Remove(voter.Id)
+	}
+}
+
+// touchVoter moves the voter to its next version after a change to its
+// history.
+func (s *Store) touchVoter(voterId int) {
+	voter := s.Voters[voterId]
+	voter.Version++
+	s.Voters[voterId] = voter
+}
+
+func versionMatches(version int, expectedVersion int) bool {
+	return expectedVersion == process.AnyVersion || expectedVersion == version
+}
+
+// WithInitialVersions puts records written before versions were kept at
+// version 1.
+func WithInitialVersions(voter Voter) Voter {
+	if voter.Version == 0 {
+		voter.Version = 1
+	}
+
+	for pollId, item := range voter.VoterHistory {
+		if item.Version == 0 {
+			item.Version = 1
+			voter.VoterHistory[pollId] = item
+		}
+	}
+
+	return voter
+}
+
+func toVoterDTO(voter Voter, includeDeleted bool) retrieve.VoterDTO {
+	history := make(retrieve.HistoryMap)
+
+	for pollId, item := range voter.VoterHistory {
+		if item.Deleted != nil && !includeDeleted {
+			continue
+		}
+
+		history[pollId] = toHistoryDTO(item)
+	}
+
+	voterDTO := retrieve.NewVoterDTO(
+		voter.Id,
+		voter.Name,
+		voter.Email,
+		history,
+		voter.Created,
+		voter.Modified,
+	)
+
+	if voter.Deleted != nil {
+		voterDTO = voterDTO.WithDeleted(*voter.Deleted, voter.DeleteReason)
+	}
+
+	voterDTO = voterDTO.WithProfile(revision.DateValue(voter.DateOfBirth), voter.ResidentialAddress.ToDTO(), voter.MailingAddress.ToDTO(), voter.Jurisdiction)
+
+	return voterDTO.WithVersion(voter.Version)
+}
+
+func toHistoryDTO(history VoterHistory) retrieve.VoterHistoryDTO {
+	historyDTO := retrieve.NewVoterHistoryDTO(
+		history.PollId,
+		history.VoteId,
+		history.VoteDate,
+		history.Created,
+		history.Modified,
+	)
+
+	if history.Deleted != nil {
+		historyDTO = historyDTO.WithDeleted(*history.Deleted, history.DeleteReason)
+	}
+
+	return historyDTO.WithChoice(history.Choice).WithRanking(history.Ranking).WithVersion(history.Version)
+}
@@ -0,0 +1,88 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"drexel.edu/voter-api/pkg/process"
+	"drexel.edu/voter-api/pkg/retrieve"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	now := time.Now()
+
+	s := Load([]Voter{
+		{Id: 2, Name: "Pat", Email: "pat@abc.com", Created: now, Modified: now, VoterHistory: HistoryMap{1: {PollId: 1}}},
+		{Id: 1, Name: "Sam", Email: "PAT@abc.com", Created: now, Modified: now},
+	}, []Poll{{Id: 1, Title: "first"}}, []Ballot{{PollId: 1, Receipt: "b"}, {PollId: 1, Receipt: "a"}})
+
+	//records written before versions were kept start at version 1
+	assert.Equal(t, 1, s.Voters[2].Version)
+	assert.Equal(t, 1, s.Voters[2].VoterHistory[1].Version)
+
+	//voters that share an email from an old file, the lowest id keeps it
+	voter, err := s.GetVoterByEmail("pat@abc.com", false)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, voter.GetId())
+
+	assert.Equal(t, []Ballot{{PollId: 1, Receipt: "a"}, {PollId: 1, Receipt: "b"}}, s.SortedBallots())
+}
+
+func TestChange(t *testing.T) {
+	s := New()
+
+	err := s.CreatePoll(process.NewPollDTO(1, "first", "", time.Time{}, time.Time{}, "open"))
+	assert.NoError(t, err)
+
+	change, err := s.CreateVoter(process.NewVoterDTO(1, "Pat", "pat@abc.com"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, change.VoterId)
+	assert.Equal(t, 1, len(change.Revisions))
+	assert.Empty(t, change.After)
+
+	change, err = s.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, time.Now()).WithChoice("yes"))
+	assert.NoError(t, err)
+	assert.Empty(t, change.Before)
+	assert.Equal(t, "yes", change.After[1].Choice)
+	assert.Equal(t, 2, change.Revisions[0].Number)
+
+	//a change that fails leaves the store as it was
+	_, err = s.CreateVoterHistory(1, 1, process.NewVoterHistoryDTO(1, 1, time.Now()))
+	assert.Equal(t, ErrHistoryAlreadyExists.Error(), err)
+	assert.Equal(t, 2, s.Voters[1].Version)
+}
+
+func TestUndoVoter(t *testing.T) {
+	s := New()
+
+	_, err := s.CreateVoter(process.NewVoterDTO(1, "Pat", "pat@abc.com"))
+	assert.NoError(t, err)
+
+	undo := s.UndoVoter(1)
+
+	_, err = s.UpdateVoterInfo(process.NewVoterDTO(1, "Sam", "sam@abc.com"), process.AnyVersion)
+	assert.NoError(t, err)
+
+	undo()
+
+	assert.Equal(t, "Pat", s.Voters[1].Name)
+	assert.Equal(t, 1, s.Voters[1].Version)
+
+	_, err = s.GetVoterByEmail("pat@abc.com", false)
+	assert.NoError(t, err)
+
+	_, err = s.GetVoterByEmail("sam@abc.com", false)
+	assert.Equal(t, ErrVoterNotFound.Error(), err)
+
+	//undoing a voter that did not exist takes it out again
+	undo = s.UndoVoter(2)
+
+	_, err = s.CreateVoter(process.NewVoterDTO(2, "Lee", "lee@abc.com"))
+	assert.NoError(t, err)
+
+	undo()
+
+	assert.NotContains(t, s.Voters, 2)
+	assert.Empty(t, s.SearchVoters(retrieve.SearchQuery{Text: "Lee", Limit: 10}))
+}
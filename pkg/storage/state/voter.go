@@ -0,0 +1,35 @@
+package state
+
+import (
+	"time"
+
+	"drexel.edu/voter-api/pkg/storage/revision"
+)
+
+type DbMap map[int]Voter
+
+type HistoryMap map[int]VoterHistory
+
+// Voter is a voter as the backends that keep their voters in memory hold it.
+// The json tags are the format of the Json DB file.
+type Voter struct {
+	Id           int        `json:"id"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	VoterHistory HistoryMap `json:"history"`
+	Created      time.Time  `json:"created"`
+	Modified     time.Time  `json:"modified"`
+	Deleted      *time.Time `json:"deleted,omitempty"`
+	DeleteReason string     `json:"delete_reason,omitempty"`
+
+	// Version goes up whenever the voter or any of its history changes
+	Version int `json:"version"`
+
+	// Revisions holds every version of the voter, oldest first
+	Revisions []revision.Revision `json:"revisions,omitempty"`
+
+	DateOfBirth        *time.Time        `json:"date_of_birth,omitempty"`
+	ResidentialAddress *revision.Address `json:"residential_address,omitempty"`
+	MailingAddress     *revision.Address `json:"mailing_address,omitempty"`
+	Jurisdiction       string            `json:"jurisdiction,omitempty"`
+}
@@ -0,0 +1,20 @@
+package state
+
+import (
+	"time"
+)
+
+type VoterHistory struct {
+	PollId   int       `json:"poll_id"`
+	VoteId   int       `json:"vote_id"`
+	VoteDate time.Time `json:"vote_date"`
+	Choice   string    `json:"choice,omitempty"`
+	Ranking  []string  `json:"ranking,omitempty"`
+	Created  time.Time `json:"created"`
+	Modified time.Time `json:"modified"`
+
+	Deleted      *time.Time `json:"deleted,omitempty"`
+	DeleteReason string     `json:"delete_reason,omitempty"`
+
+	Version int `json:"version"`
+}